@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+func TestRunChangesOnly(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		"README.md":   "# {{name}}\n",
+		"main.go":     "package {{name}}\n",
+		"docs/new.md": "new\n",
+	})
+	// A partially up-to-date output: README.md is current, main.go is
+	// stale and old.txt is no longer generated
+	existing := map[string]string{
+		"README.md": "# app\n",
+		"main.go":   "package old\n",
+		"old.txt":   "old\n",
+	}
+	writeTree(t, out, existing)
+
+	tests := []struct {
+		name  string
+		prune bool
+		want  []generator.FileChange
+	}{
+		{
+			name: "without pruning",
+			want: []generator.FileChange{
+				{Path: filepath.Join(out, "docs", "new.md"), Kind: generator.ChangeCreate},
+				{Path: filepath.Join(out, "main.go"), Kind: generator.ChangeModify},
+			},
+		},
+		{
+			name:  "with pruning",
+			prune: true,
+			want: []generator.FileChange{
+				{Path: filepath.Join(out, "docs", "new.md"), Kind: generator.ChangeCreate},
+				{Path: filepath.Join(out, "main.go"), Kind: generator.ChangeModify},
+				{Path: filepath.Join(out, "old.txt"), Kind: generator.ChangeDelete},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, asJSON := range []bool{false, true} {
+			name := tt.name
+			if asJSON {
+				name += " as JSON"
+			}
+			t.Run(name, func(t *testing.T) {
+				saved := logger
+				var log bytes.Buffer
+				jsonOutput, logger = asJSON, generator.NewConsoleLogger(&log, io.Discard)
+				t.Cleanup(func() { jsonOutput, logger = false, saved })
+
+				cfg := config.DefaultConfig()
+				cfg.TemplateDir, cfg.OutputDir = tmpl, out
+				cfg.Variables = map[string]string{"name": "app"}
+				cfg.DryRun, cfg.PruneOutput = true, tt.prune
+				gen := generator.NewGenerator(cfg)
+				gen.SetLogger(generator.NewConsoleLogger(io.Discard, io.Discard))
+
+				var buf bytes.Buffer
+				if code := runChangesOnly(gen, &buf); code != 0 {
+					t.Fatalf("exit code = %d, want 0", code)
+				}
+
+				var got []generator.FileChange
+				if asJSON {
+					var list struct {
+						Changes []generator.FileChange `json:"changes"`
+					}
+					if err := json.Unmarshal(buf.Bytes(), &list); err != nil {
+						t.Fatalf("output %q is not JSON: %v", buf.String(), err)
+					}
+					got = list.Changes
+				} else {
+					for _, line := range strings.Split(log.String(), "\n") {
+						kind, path, ok := strings.Cut(strings.TrimPrefix(line, "[DRY RUN] Would "), " file: ")
+						if ok {
+							got = append(got, generator.FileChange{Path: path, Kind: kind})
+						}
+					}
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("changes = %v, want %v", got, tt.want)
+				}
+				// The up-to-date file is not listed
+				if strings.Contains(buf.String()+log.String(), "README.md") {
+					t.Errorf("output lists the up-to-date README.md:\n%s%s", buf.String(), log.String())
+				}
+
+				// The output is left as it was
+				if got := readTree(t, out); !maps.Equal(got, existing) {
+					t.Errorf("output = %q, want %q", got, existing)
+				}
+			})
+		}
+	}
+}
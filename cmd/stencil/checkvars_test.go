@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+func TestRunCheckVars(t *testing.T) {
+	tmpl := t.TempDir()
+	writeTree(t, tmpl, map[string]string{"main.go": "package {{name}}\n\n// {{author}} {{year}}\n"})
+
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		json     bool
+		want     string
+		wantCode int
+	}{
+		{name: "all supplied", vars: map[string]string{"name": "app", "author": "Jane", "year": "2026"}},
+		{name: "all supplied as JSON", vars: map[string]string{"name": "app", "author": "Jane", "year": "2026"}, json: true, want: "{\n  \"missing\": []\n}\n"},
+		{name: "some missing", vars: map[string]string{"name": "app"}, want: "author\nyear\n", wantCode: 1},
+		{name: "some missing as JSON", vars: map[string]string{"author": "Jane"}, json: true, want: "{\n  \"missing\": [\n    \"name\",\n    \"year\"\n  ]\n}\n", wantCode: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := logger
+			jsonOutput, logger = tt.json, generator.NewConsoleLogger(io.Discard, io.Discard)
+			t.Cleanup(func() { jsonOutput, logger = false, saved })
+
+			cfg := config.DefaultConfig()
+			cfg.TemplateDir, cfg.OutputDir = tmpl, filepath.Join(t.TempDir(), "output")
+			cfg.Variables = tt.vars
+			gen := generator.NewGenerator(cfg)
+			gen.SetLogger(logger)
+
+			var buf bytes.Buffer
+			if code := runCheckVars(gen, &buf); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/diff"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+// runDiff implements the diff subcommand, which renders the template with two
+// variable sets in memory and prints the differences
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+
+	var tmplDir, cfgFile, varsA, varsB string
+	var trustedKeys keyList
+	fs.StringVar(&tmplDir, "t", "", "Template directory path")
+	fs.StringVar(&tmplDir, "template", "", "Template directory path")
+	fs.StringVar(&cfgFile, "c", "", "Configuration file path (JSON)")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file path (JSON)")
+	fs.StringVar(&varsA, "vars-a", "", "Variables for the first render")
+	fs.StringVar(&varsB, "vars-b", "", "Variables for the second render")
+	fs.Var(&trustedKeys, "trusted-key", "Public key the template must be signed with (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	base := config.DefaultConfig()
+	if cfgFile != "" {
+		var err error
+		base, err = config.LoadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config file '%s': %w", cfgFile, err)
+		}
+	}
+	if tmplDir != "" {
+		base.TemplateDir = tmplDir
+	}
+	if err := verifyTemplate(base.TemplateDir, append(base.TrustedKeys, trustedKeys...)); err != nil {
+		return err
+	}
+
+	filesA, err := renderWithVars(base, varsA)
+	if err != nil {
+		return err
+	}
+	filesB, err := renderWithVars(base, varsB)
+	if err != nil {
+		return err
+	}
+
+	out := diffRenders(filesA, filesB)
+	if out == "" {
+		fmt.Println("No differences.")
+		return nil
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// renderWithVars renders the template in memory with vars merged over the
+// base configuration's variables
+func renderWithVars(base *config.Config, vars string) ([]generator.RenderedFile, error) {
+	cfg := *base
+
+	parsed := parseKeyValues(vars)
+	loaded, err := config.ResolveFileValues(parsed, ".")
+	if err != nil {
+		return nil, err
+	}
+	cfg.Variables = config.MergeVariables(base.Variables, parsed)
+	cfg.FileValues = append(append([]string(nil), base.FileValues...), loaded...)
+
+	return generator.NewGenerator(&cfg).Render()
+}
+
+// diffRenders returns a unified diff between two sets of rendered files
+func diffRenders(a, b []generator.RenderedFile) string {
+	filesA := renderedByPath(a)
+	filesB := renderedByPath(b)
+
+	paths := make(map[string]bool)
+	for path := range filesA {
+		paths[path] = true
+	}
+	for path := range filesB {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var out bytes.Buffer
+	for _, path := range sorted {
+		fileA, okA := filesA[path]
+		fileB, okB := filesB[path]
+
+		nameA, nameB := "a/"+path, "b/"+path
+		if !okA {
+			nameA = "/dev/null"
+		}
+		if !okB {
+			nameB = "/dev/null"
+		}
+
+		if (okA && fileA.Binary) || (okB && fileB.Binary) {
+			if !okA || !okB || !bytes.Equal(fileA.Content, fileB.Content) {
+				fmt.Fprintf(&out, "Binary files %s and %s differ\n", nameA, nameB)
+			}
+			continue
+		}
+
+		out.WriteString(diff.Unified(nameA, nameB, fileA.Content, fileB.Content))
+	}
+	return out.String()
+}
+
+// renderedByPath indexes rendered files by their slash-separated path
+func renderedByPath(files []generator.RenderedFile) map[string]generator.RenderedFile {
+	result := make(map[string]generator.RenderedFile, len(files))
+	for _, file := range files {
+		result[filepath.ToSlash(file.Path)] = file
+	}
+	return result
+}
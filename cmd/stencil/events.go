@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+// Event types of the --events stream
+const (
+	eventStart = "start"
+	eventFile  = "file"
+	eventDone  = "done"
+)
+
+// startEvent opens the stream
+type startEvent struct {
+	Type     string `json:"type"`
+	Template string `json:"template"`
+	Output   string `json:"output"`
+	DryRun   bool   `json:"dryRun"`
+}
+
+// fileEvent reports a file as it completes
+type fileEvent struct {
+	Type   string `json:"type"`
+	Path   string `json:"path"`
+	Index  int    `json:"index"`
+	Total  int    `json:"total"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// issueEvent reports a warning or error from the generation report; its
+// type is the issue's severity
+type issueEvent struct {
+	Type    string `json:"type"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// doneEvent closes the stream
+type doneEvent struct {
+	Type     string `json:"type"`
+	Success  bool   `json:"success"`
+	Files    int    `json:"files"`
+	Warnings int    `json:"warnings"`
+	Errors   int    `json:"errors"`
+	Error    string `json:"error,omitempty"`
+}
+
+// eventWriter writes events as JSON lines
+type eventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// newEventWriter creates an eventWriter writing to w
+func newEventWriter(w io.Writer) *eventWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &eventWriter{enc: enc}
+}
+
+// emit writes one event line
+func (w *eventWriter) emit(event any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(event)
+}
+
+// runEvents generates the project while streaming one JSON object per line
+// to out: a start event, a file event as each file completes, the report's
+// warnings and errors, and a done event. It returns the exit code.
+func runEvents(gen *generator.Generator, cfg *config.Config, out io.Writer) int {
+	events := newEventWriter(out)
+	if cfg.Interactive {
+		events.emit(doneEvent{Type: eventDone, Error: "--events cannot be combined with interactive mode"})
+		return 1
+	}
+
+	events.emit(startEvent{Type: eventStart, Template: cfg.TemplateDir, Output: cfg.OutputDir, DryRun: cfg.DryRun})
+	gen.SetProgress(func(event generator.ProgressEvent) {
+		e := fileEvent{Type: eventFile, Path: event.Path, Index: event.Index, Total: event.Total, Status: "ok"}
+		if event.Err != nil {
+			e.Status, e.Error = "failed", event.Err.Error()
+		}
+		events.emit(e)
+	})
+
+	err := gen.Generate()
+
+	report := gen.Report()
+	for _, issue := range report.Issues {
+		events.emit(issueEvent{Type: issue.Severity.String(), Path: issue.Path, Message: issue.Message})
+	}
+	done := doneEvent{
+		Type:     eventDone,
+		Success:  err == nil && !report.HasErrors(),
+		Files:    len(report.Files),
+		Warnings: len(report.Warnings()),
+		Errors:   len(report.Errors()),
+	}
+	if err != nil {
+		done.Error = err.Error()
+	} else if report.HasErrors() {
+		done.Error = fmt.Sprintf("generation finished with %d error(s)", done.Errors)
+	}
+	events.emit(done)
+
+	if !done.Success {
+		return 1
+	}
+	return 0
+}
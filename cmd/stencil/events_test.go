@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+func TestRunEvents(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		"README.md": "# {{name}}\n",
+		"main.go":   "package {{name}}\n\n// {{unknown}}\n",
+	})
+
+	tests := []struct {
+		name        string
+		interactive bool
+		wantTypes   []string
+		wantCode    int
+	}{
+		{
+			name:      "generation",
+			wantTypes: []string{"start", "file", "file", "warning", "done"},
+		},
+		{
+			name:        "interactive mode is rejected",
+			interactive: true,
+			wantTypes:   []string{"done"},
+			wantCode:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.TemplateDir, cfg.OutputDir = tmpl, filepath.Join(out, tt.name)
+			cfg.Variables = map[string]string{"name": "app"}
+			cfg.Interactive = tt.interactive
+			gen := generator.NewGenerator(cfg)
+			gen.SetLogger(generator.NewConsoleLogger(io.Discard, io.Discard))
+
+			var buf bytes.Buffer
+			if code := runEvents(gen, cfg, &buf); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+
+			// Every line is one JSON object
+			var types, files []string
+			var last map[string]any
+			for _, line := range bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n")) {
+				var event map[string]any
+				if err := json.Unmarshal(line, &event); err != nil {
+					t.Fatalf("line %q is not a JSON object: %v", line, err)
+				}
+				types = append(types, event["type"].(string))
+				if event["type"] == "file" {
+					files = append(files, event["path"].(string))
+				}
+				last = event
+			}
+			if !slices.Equal(types, tt.wantTypes) {
+				t.Errorf("event types = %q, want %q", types, tt.wantTypes)
+			}
+			if success := last["success"] == true; success != (tt.wantCode == 0) {
+				t.Errorf("done event = %v, want success %v", last, tt.wantCode == 0)
+			}
+			if tt.wantCode == 0 {
+				slices.Sort(files)
+				want := []string{filepath.Join(cfg.OutputDir, "README.md"), filepath.Join(cfg.OutputDir, "main.go")}
+				if !slices.Equal(files, want) {
+					t.Errorf("file events = %q, want %q", files, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linxux/stencil/config"
+)
+
+// provenance records which source set each configuration value, keyed by
+// its JSON path (e.g. "outputDir", "variables.port")
+var provenance map[string]string
+
+// flagSet is the set of flags given explicitly on the command line
+type flagSet map[string]bool
+
+// explicitFlags returns the flags given explicitly on the command line
+func explicitFlags() flagSet {
+	set := make(flagSet)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// any reports whether any of the given flag names was set, returning the
+// name as written on the command line
+func (s flagSet) any(names ...string) (string, bool) {
+	for _, name := range names {
+		if s[name] {
+			if len(name) == 1 {
+				return "-" + name, true
+			}
+			return "--" + name, true
+		}
+	}
+	return "", false
+}
+
+// configFileKeys returns the JSON paths present in a config file, including
+// nested keys of objects such as "variables" and "formats"
+func configFileKeys(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(config.StripJSONC(data), &raw); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key, value := range raw {
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(value, &nested) == nil {
+			for nestedKey := range nested {
+				keys = append(keys, key+"."+nestedKey)
+			}
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// printExplainConfig prints each configuration value and the source it came
+// from
+func printExplainConfig(cfg *config.Config) {
+	var lines []string
+	explainValue(reflect.ValueOf(*cfg), "", &lines)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// explainValue appends "path = value (from source)" lines for every JSON
+// field of v
+func explainValue(v reflect.Value, prefix string, lines *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.Struct:
+			explainValue(field, path+".", lines)
+		case reflect.Map:
+			keys := make([]string, 0, field.Len())
+			for _, key := range field.MapKeys() {
+				keys = append(keys, fmt.Sprint(key.Interface()))
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				value := field.MapIndex(reflect.ValueOf(key))
+				*lines = append(*lines, explainLine(path+"."+key, value.Interface()))
+			}
+		default:
+			*lines = append(*lines, explainLine(path, field.Interface()))
+		}
+	}
+}
+
+// explainLine formats a single value and its source
+func explainLine(path string, value any) string {
+	source, ok := provenance[path]
+	if !ok {
+		source = "default"
+	}
+	return fmt.Sprintf("%s = %s (from %s)", path, formatValue(value), source)
+}
+
+// formatValue formats a value, quoting strings that would be ambiguous
+func formatValue(value any) string {
+	if s, ok := value.(string); ok && (s == "" || strings.ContainsAny(s, " \t\r\n")) {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseFlags parses args as the command line, starting from the default
+// value of every flag, and restores the previous command line afterwards
+func parseFlags(t *testing.T, args ...string) {
+	t.Helper()
+	previous := flag.CommandLine
+	fs := flag.NewFlagSet("stencil", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	previous.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "test.") {
+			return
+		}
+		if err := f.Value.Set(f.DefValue); err != nil {
+			t.Fatalf("failed to reset --%s: %v", f.Name, err)
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	flag.CommandLine = fs
+	t.Cleanup(func() {
+		fs.VisitAll(func(f *flag.Flag) { f.Value.Set(f.DefValue) })
+		flag.CommandLine = previous
+	})
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("failed to parse %q: %v", args, err)
+	}
+}
+
+func TestExplainConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeTree(t, dir, map[string]string{
+		"stencil.json": `{
+			"outputDir": "from-config",
+			"concurrency": 2,
+			"variables": {"port": "1", "name": "config-name", "region": "eu"}
+		}`,
+		"values.json": `{"port": "2", "name": "values-name"}`,
+	})
+	configPath := filepath.Join(dir, "stencil.json")
+	valuesPath := filepath.Join(dir, "values.json")
+
+	parseFlags(t, "--config", configPath, "--var-file", valuesPath, "-v", "port=3", "--concurrency", "4", "--disable-percent")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		path  string
+		value any
+		want  string
+	}{
+		{path: "variables.port", value: cfg.Variables["port"], want: "variables.port = 3 (from flag -v)"},
+		{path: "variables.name", value: cfg.Variables["name"], want: "variables.name = values-name (from flag --var-file " + valuesPath + ")"},
+		{path: "variables.region", value: cfg.Variables["region"], want: "variables.region = eu (from config file " + configPath + ")"},
+		{path: "outputDir", value: cfg.OutputDir, want: "outputDir = from-config (from config file " + configPath + ")"},
+		{path: "concurrency", value: cfg.Concurrency, want: "concurrency = 4 (from flag --concurrency)"},
+		{path: "formats.enablePercent", value: cfg.Formats.EnablePercent, want: "formats.enablePercent = false (from flag --disable-percent)"},
+		{path: "formats.enableBraces", value: cfg.Formats.EnableBraces, want: "formats.enableBraces = true (from default)"},
+	}
+	for _, tt := range tests {
+		if got := explainLine(tt.path, tt.value); got != tt.want {
+			t.Errorf("explainLine(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// Every field and variable is listed
+	var lines []string
+	explainValue(reflect.ValueOf(*cfg), "", &lines)
+	joined := strings.Join(lines, "\n")
+	for _, want := range []string{"templateDir = ", "variables.port = 3 ", "formats.customOpen = "} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("explanation lacks %q:\n%s", want, joined)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+// runGrepVar implements the grep-var subcommand, which lists every file and
+// line of the template where a variable is used
+func runGrepVar(args []string) error {
+	fs := flag.NewFlagSet("grep-var", flag.ContinueOnError)
+
+	var tmplDir, cfgFile string
+	var asJSON bool
+	fs.StringVar(&tmplDir, "t", "", "Template directory path")
+	fs.StringVar(&tmplDir, "template", "", "Template directory path")
+	fs.StringVar(&cfgFile, "c", "", "Configuration file path (JSON)")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file path (JSON)")
+	fs.BoolVar(&asJSON, "json", false, "Print matches as JSON")
+
+	// The variable name may come before or after the flags
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		return fmt.Errorf("usage: stencil grep-var <variable> [-t <template>]")
+	}
+
+	cfg := config.DefaultConfig()
+	if cfgFile != "" {
+		var err error
+		cfg, err = config.LoadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config file '%s': %w", cfgFile, err)
+		}
+	}
+	if tmplDir != "" {
+		cfg.TemplateDir = tmplDir
+	}
+	if _, err := os.Stat(cfg.TemplateDir); os.IsNotExist(err) {
+		return fmt.Errorf("template directory does not exist: %s", cfg.TemplateDir)
+	}
+
+	matches, err := generator.NewGenerator(cfg).FindVariable(name)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		if matches == nil {
+			matches = []generator.VariableMatch{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matches)
+	}
+
+	for _, m := range matches {
+		if m.Line == 0 {
+			fmt.Printf("%s: (path) %s\n", m.Path, m.Text)
+		} else {
+			fmt.Printf("%s:%d: %s\n", m.Path, m.Line, m.Text)
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Printf("Variable '%s' is not used in %s\n", name, cfg.TemplateDir)
+	}
+	return nil
+}
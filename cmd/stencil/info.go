@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// templateInfo is the information printed by the info subcommand
+type templateInfo struct {
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	MinVersion  string              `json:"minVersion,omitempty"`
+	Variables   []manifest.Variable `json:"variables"`
+
+	// HasManifest is false when the variables were discovered by scanning
+	// the template instead of read from its manifest
+	HasManifest bool `json:"hasManifest"`
+}
+
+// runInfo implements the info subcommand, which describes a template from
+// its manifest, or from the variables it uses when it has none
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+
+	var tmplDir, cfgFile string
+	var asJSON bool
+	fs.StringVar(&tmplDir, "t", "", "Template directory path")
+	fs.StringVar(&tmplDir, "template", "", "Template directory path")
+	fs.StringVar(&cfgFile, "c", "", "Configuration file path (JSON)")
+	fs.StringVar(&cfgFile, "config", "", "Configuration file path (JSON)")
+	fs.BoolVar(&asJSON, "json", false, "Print the information as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.DefaultConfig()
+	if cfgFile != "" {
+		var err error
+		cfg, err = config.LoadConfig(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config file '%s': %w", cfgFile, err)
+		}
+	}
+	if tmplDir != "" {
+		cfg.TemplateDir = tmplDir
+	}
+	if _, err := os.Stat(cfg.TemplateDir); os.IsNotExist(err) {
+		return fmt.Errorf("template directory does not exist: %s", cfg.TemplateDir)
+	}
+
+	info, err := loadTemplateInfo(cfg)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	printTemplateInfo(os.Stdout, info)
+	return nil
+}
+
+// loadTemplateInfo reads the template manifest, falling back to the
+// variables discovered in the template
+func loadTemplateInfo(cfg *config.Config) (*templateInfo, error) {
+	m, err := manifest.Load(cfg.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	if m != nil {
+		info := &templateInfo{
+			Name:        m.Name,
+			Description: m.Description,
+			MinVersion:  m.MinVersion,
+			Variables:   m.Variables,
+			HasManifest: true,
+		}
+		if info.Variables == nil {
+			info.Variables = []manifest.Variable{}
+		}
+		return info, nil
+	}
+
+	names, err := generator.NewGenerator(cfg).Variables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract variables: %w", err)
+	}
+	info := &templateInfo{Variables: []manifest.Variable{}}
+	for _, name := range names {
+		info.Variables = append(info.Variables, manifest.Variable{Name: name})
+	}
+	return info, nil
+}
+
+// printTemplateInfo prints template information to out, listing variables
+// under their group headings in declaration order
+func printTemplateInfo(out io.Writer, info *templateInfo) {
+	if info.Name != "" {
+		fmt.Fprintf(out, "Name:        %s\n", info.Name)
+	}
+	if info.Description != "" {
+		fmt.Fprintf(out, "Description: %s\n", info.Description)
+	}
+	if info.MinVersion != "" {
+		fmt.Fprintf(out, "Min version: %s\n", info.MinVersion)
+	}
+	if !info.HasManifest {
+		fmt.Fprintln(out, "No manifest found; variables discovered in the template:")
+	}
+
+	if len(info.Variables) == 0 {
+		fmt.Fprintln(out, "Variables:   none")
+		return
+	}
+
+	var groups []string
+	byGroup := make(map[string][]manifest.Variable)
+	for _, v := range info.Variables {
+		if _, ok := byGroup[v.Group]; !ok {
+			groups = append(groups, v.Group)
+		}
+		byGroup[v.Group] = append(byGroup[v.Group], v)
+	}
+
+	fmt.Fprintln(out, "Variables:")
+	for _, group := range groups {
+		indent := "  "
+		if group != "" {
+			fmt.Fprintf(out, "  [%s]\n", group)
+			indent = "    "
+		}
+		for _, v := range byGroup[group] {
+			line := indent + v.Name
+			if v.Default != "" {
+				line += fmt.Sprintf(" (default: %s)", v.Default)
+			}
+			if v.Description != "" {
+				line += " - " + v.Description
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+}
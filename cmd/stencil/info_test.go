@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+)
+
+func TestTemplateInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		template map[string]string
+		want     string
+		wantJSON string
+	}{
+		{
+			name: "with manifest",
+			template: map[string]string{
+				"README.md": "# {{name}} {{undeclared}}\n",
+				"stencil.manifest.json": `{
+					"name": "Go Service",
+					"description": "A small HTTP service",
+					"minVersion": "1.2.0",
+					"variables": [
+						{"name": "name", "description": "Project name"},
+						{"name": "port", "default": "8080", "group": "Server"},
+						{"name": "host", "default": "localhost", "description": "Bind address", "group": "Server"},
+						{"name": "license", "default": "MIT"}
+					]
+				}`,
+			},
+			want: "Name:        Go Service\n" +
+				"Description: A small HTTP service\n" +
+				"Min version: 1.2.0\n" +
+				"Variables:\n" +
+				"  name - Project name\n" +
+				"  license (default: MIT)\n" +
+				"  [Server]\n" +
+				"    port (default: 8080)\n" +
+				"    host (default: localhost) - Bind address\n",
+			wantJSON: `{"name":"Go Service","description":"A small HTTP service","minVersion":"1.2.0",` +
+				`"variables":[{"name":"name","description":"Project name"},{"name":"port","default":"8080","group":"Server"},` +
+				`{"name":"host","default":"localhost","description":"Bind address","group":"Server"},{"name":"license","default":"MIT"}],` +
+				`"hasManifest":true}`,
+		},
+		{
+			name: "without manifest",
+			template: map[string]string{
+				"README.md":            "# {{name}}\n",
+				"cmd/__name__/main.go": "package main // {{author}}\n",
+			},
+			want: "No manifest found; variables discovered in the template:\n" +
+				"Variables:\n" +
+				"  name\n" +
+				"  author\n",
+			wantJSON: `{"variables":[{"name":"name"},{"name":"author"}],"hasManifest":false}`,
+		},
+		{
+			name:     "no variables",
+			template: map[string]string{"README.md": "plain\n"},
+			want:     "No manifest found; variables discovered in the template:\nVariables:   none\n",
+			wantJSON: `{"variables":[],"hasManifest":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.TemplateDir = filepath.Join(t.TempDir(), "template")
+			writeTree(t, cfg.TemplateDir, tt.template)
+
+			info, err := loadTemplateInfo(cfg)
+			if err != nil {
+				t.Fatalf("loadTemplateInfo failed: %v", err)
+			}
+
+			var buf bytes.Buffer
+			printTemplateInfo(&buf, info)
+			if buf.String() != tt.want {
+				t.Errorf("printed:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+
+			data, err := json.Marshal(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.wantJSON {
+				t.Errorf("JSON = %s\nwant   %s", data, tt.wantJSON)
+			}
+		})
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/linxux/stencil/config"
 	"github.com/linxux/stencil/internal/generator"
 	"github.com/linxux/stencil/internal/interactive"
+	"github.com/linxux/stencil/internal/manifest"
+	"github.com/linxux/stencil/internal/source"
 )
 
 var (
@@ -20,15 +26,43 @@ var (
 
 var (
 	// Command-line flags
-	templateDir     string
-	outputDir       string
-	configFile      string
-	variables       string
-	interactiveMode bool
-	dryRun          bool
-	skipConfirm     bool
-	showVersion     bool
-	showHelp        bool
+	templateDir      string
+	outputDir        string
+	configFile       string
+	varFile          string
+	noConfig         bool
+	strictConfig     bool
+	variables        string
+	interactiveMode  bool
+	dryRun           bool
+	trial            bool
+	checkOnly        bool
+	changesOnly      bool
+	checkVars        bool
+	patchFile        string
+	events           bool
+	skipConfirm      bool
+	pruneOutput      bool
+	cleanupOnError   bool
+	backup           bool
+	concurrency      int
+	verbose          bool
+	explainConfig    bool
+	answersFile      string
+	bulkEdit         bool
+	noPathReplace    bool
+	noContentReplace bool
+	noBinarySkip     bool
+	showStats        bool
+	jsonOutput       bool
+	quiet            bool
+	formatList       string
+	customDelims     string
+	showVersion      bool
+	showHelp         bool
+
+	// logger reports status messages and errors; the generator shares it
+	logger = generator.NewConsoleLogger(os.Stdout, os.Stderr)
 
 	// Format flags (use pointers to distinguish "not set" from "false")
 	disableBraces        *bool
@@ -47,18 +81,51 @@ func init() {
 
 	flag.StringVar(&configFile, "c", "", "Configuration file path (JSON)")
 	flag.StringVar(&configFile, "config", "", "Configuration file path (JSON)")
+	flag.BoolVar(&noConfig, "no-config", false, "Ignore config files and use built-in defaults plus flags")
+	flag.BoolVar(&strictConfig, "strict-config", false, "Fail when several config files are detected instead of using the first")
 
-	flag.StringVar(&variables, "v", "", "Variables in format 'key1=value1,key2=value2'")
-	flag.StringVar(&variables, "vars", "", "Variables in format 'key1=value1,key2=value2'")
+	flag.StringVar(&variables, "v", "", "Variables in format 'key1=value1,key2=value2'; escape commas in values as '\\,'")
+	flag.StringVar(&variables, "vars", "", "Variables in format 'key1=value1,key2=value2'; escape commas in values as '\\,'")
+	flag.StringVar(&varFile, "var-file", "", "Read variables from a JSON file such as stencil.values.json")
 
 	flag.BoolVar(&interactiveMode, "i", false, "Interactive mode")
 	flag.BoolVar(&interactiveMode, "interactive", false, "Interactive mode")
 
+	flag.StringVar(&answersFile, "answers-file", "", "Read interactive answers from a file, one per line")
+	flag.BoolVar(&bulkEdit, "bulk-edit", false, "Edit all interactive values at once in $EDITOR")
+
 	flag.BoolVar(&dryRun, "dry-run", false, "Dry run (show what would be generated without creating files)")
+	flag.BoolVar(&changesOnly, "changes-only", false, "With --dry-run, list only the files that would be created, modified or deleted")
+	flag.BoolVar(&trial, "trial", false, "Generate into a temporary directory and discard it, reporting any errors")
+	flag.BoolVar(&checkOnly, "check", false, "List output files generation would change and exit non-zero if there are any")
+	flag.BoolVar(&checkVars, "check-vars", false, "List variables without a value and exit non-zero if there are any, without generating")
+	flag.StringVar(&patchFile, "patch", "", "Write the changes generation would make to the output as a git diff to this file, without writing the output")
 
 	flag.BoolVar(&skipConfirm, "y", false, "Skip confirmation in interactive mode")
 	flag.BoolVar(&skipConfirm, "yes", false, "Skip confirmation in interactive mode")
 
+	flag.BoolVar(&noPathReplace, "no-path-replace", false, "Keep file and directory names as-is")
+	flag.BoolVar(&noContentReplace, "no-content-replace", false, "Keep file contents as-is")
+	flag.BoolVar(&noBinarySkip, "no-binary-skip", false, "Substitute variables in files detected as binary too")
+
+	flag.IntVar(&concurrency, "concurrency", 0, "Number of files to process in parallel")
+
+	flag.BoolVar(&backup, "backup", false, "Save existing files as <file>.bak before overwriting them")
+
+	flag.BoolVar(&pruneOutput, "prune-output", false, "Remove files in the output directory not produced by the template")
+	flag.BoolVar(&cleanupOnError, "cleanup-on-error", false, "Remove the files a failed run created")
+
+	flag.BoolVar(&verbose, "verbose", false, "Show progress for each generated file and debug messages")
+
+	flag.BoolVar(&showStats, "stats", false, "Show template statistics without generating")
+	flag.BoolVar(&jsonOutput, "json", false, "Print machine-readable JSON output")
+	flag.BoolVar(&events, "events", false, "Stream generation progress as JSON lines on stdout")
+
+	flag.BoolVar(&quiet, "q", false, "Suppress the success and post-generation messages")
+	flag.BoolVar(&quiet, "quiet", false, "Suppress the success and post-generation messages")
+
+	flag.BoolVar(&explainConfig, "explain-config", false, "Show where each configuration value came from")
+
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 
 	flag.BoolVar(&showHelp, "h", false, "Show help information")
@@ -69,76 +136,289 @@ func init() {
 	disableAngleBrackets = flag.Bool("disable-angle-brackets", false, "Disable <<var>> format")
 	disableUnderscores = flag.Bool("disable-underscores", false, "Disable __var__ format")
 	disablePercent = flag.Bool("disable-percent", false, "Disable %var% format")
+
+	flag.StringVar(&formatList, "formats", "", "Enable only the listed formats, e.g. 'braces,custom'")
+	flag.StringVar(&customDelims, "custom-delimiters", "", "Custom delimiter pair separated by a space, e.g. '[[ ]]'")
 }
 
 func main() {
+	// Subcommands have their own flag sets
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reverse":
+			if err := runReverse(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "diff":
+			if err := runDiff(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "info":
+			if err := runInfo(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "grep-var":
+			if err := runGrepVar(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "serve":
+			if err := runServe(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "upgrade":
+			if err := runUpgrade(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "preview":
+			if err := runPreview(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "sign":
+			if err := runSign(os.Args[2:]); err != nil {
+				logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	}
+
 	flag.Parse()
 
+	// Stdout carries only events; status messages move to stderr
+	if events {
+		logger.Out = os.Stderr
+	}
+	logger.Verbose = verbose
+
 	if showVersion {
 		fmt.Printf("Stencil %s\n", version)
 		fmt.Printf("Build: %s\n", buildTime)
 		fmt.Printf("Commit: %s\n", gitCommit)
 		fmt.Println("A project scaffolding generator")
-		os.Exit(0)
+		exit(0)
 	}
 
 	if showHelp {
 		printHelp()
-		os.Exit(0)
+		exit(0)
 	}
 
 	// Load configuration
 	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-		os.Exit(1)
+		logger.Error(fmt.Sprintf("Error loading configuration: %v", err), "error", err)
+		exit(1)
+	}
+
+	if explainConfig {
+		printExplainConfig(cfg)
+		exit(0)
+	}
+
+	// A remote template is fetched into a temporary directory for this run
+	if source.IsRemote(cfg.TemplateDir) {
+		dir, cleanup, err := source.Fetch(cfg.TemplateDir, cfg.TempDir, source.FetchOptions{
+			Retries:         cfg.FetchRetries,
+			SkipUnsafeLinks: cfg.SkipUnsafeArchiveLinks,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error fetching template: %v", err), "error", err)
+			exit(1)
+		}
+		cfg.TemplateDir = dir
+		exitHooks = append(exitHooks, cleanup)
+	}
+
+	// Create generator, resolving variables in the configured paths
+	gen := generator.NewGenerator(cfg)
+	gen.SetLogger(logger)
+	if err := gen.ResolveConfigPaths(); err != nil {
+		logger.Error(fmt.Sprintf("Error loading configuration: %v", err), "error", err)
+		exit(1)
 	}
 
 	// Validate template directory exists and provide helpful message
 	if _, err := os.Stat(cfg.TemplateDir); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: Template directory does not exist: %s\n\n", cfg.TemplateDir)
+		logger.Error("Error: Template directory does not exist: "+cfg.TemplateDir+"\n", "path", cfg.TemplateDir)
 		printGettingStarted()
-		os.Exit(1)
+		exit(1)
 	}
 
-	// Create generator
-	gen := generator.NewGenerator(cfg)
+	// With trusted keys configured, only a template signed by one of them
+	// is generated
+	if len(cfg.TrustedKeys) > 0 {
+		if err := source.Verify(cfg.TemplateDir, cfg.TrustedKeys); err != nil {
+			logger.Error(fmt.Sprintf("Error verifying template signature: %v", err), "error", err)
+			exit(1)
+		}
+		logger.Debug("Template signature verified")
+	}
+
+	if showStats {
+		if err := printStats(gen); err != nil {
+			logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+			exit(1)
+		}
+		exit(0)
+	}
+	if checkOnly {
+		exit(runCheck(gen))
+	}
+	if changesOnly {
+		if !cfg.DryRun {
+			logger.Error("Error: --changes-only requires --dry-run")
+			exit(1)
+		}
+		exit(runChangesOnly(gen, os.Stdout))
+	}
+	if checkVars {
+		exit(runCheckVars(gen, os.Stdout))
+	}
+	if patchFile != "" {
+		if err := writePatch(gen, patchFile); err != nil {
+			logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+			exit(1)
+		}
+		exit(0)
+	}
+	if events {
+		exit(runEvents(gen, cfg, os.Stdout))
+	}
+	if verbose {
+		gen.SetProgress(printProgress)
+	}
 
 	// Interactive mode
 	if cfg.Interactive {
-		if err := runInteractiveMode(gen); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		generated, err := runInteractiveMode(gen)
+		printReport(gen)
+		if err == nil && generated {
+			err = printSuccess(os.Stdout, gen, cfg)
 		}
-		os.Exit(0)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+			exit(1)
+		}
+		exit(0)
 	}
 
 	// Generate project
-	if err := gen.Generate(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating project: %v\n", err)
-		os.Exit(1)
+	err = gen.Generate()
+	printReport(gen)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error generating project: %v", err), "error", err)
+		exit(1)
 	}
 
-	fmt.Println("\n✓ Project generated successfully!")
+	if err := printSuccess(os.Stdout, gen, cfg); err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+		exit(1)
+	}
+	exit(0)
+}
+
+// exitHooks run before the process exits, e.g. to remove a fetched template
+var exitHooks []func()
+
+// exit runs the exit hooks and exits with code
+func exit(code int) {
+	for _, hook := range exitHooks {
+		hook()
+	}
+	os.Exit(code)
+}
+
+// printSuccess reports a successful generation to out followed by the
+// template's post-generation message, as JSON with --json and not at all
+// with --quiet
+func printSuccess(out io.Writer, gen *generator.Generator, cfg *config.Config) error {
+	message, err := gen.PostMessage()
+	if err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	if jsonOutput {
+		result := struct {
+			Files       []string `json:"files"`
+			Skipped     []string `json:"skipped,omitempty"`
+			DryRun      bool     `json:"dryRun"`
+			Trial       bool     `json:"trial"`
+			PostMessage string   `json:"postMessage,omitempty"`
+		}{gen.Report().Files, gen.Report().Skipped, cfg.DryRun, cfg.Trial, message}
+		if result.Files == nil {
+			result.Files = []string{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if quiet {
+		return nil
+	}
+
+	if cfg.Trial {
+		fmt.Fprintln(out, "\n✓ Trial generation succeeded!")
+		fmt.Fprintf(out, "  (Generated into a temporary directory - %s was not modified)\n", cfg.OutputDir)
+		return nil
+	}
+
+	fmt.Fprintln(out, "\n✓ Project generated successfully!")
 	if cfg.DryRun {
-		fmt.Println("  (This was a dry run - no files were actually created)")
+		fmt.Fprintln(out, "  (This was a dry run - no files were actually created)")
 	}
+	if n := len(gen.Report().Skipped); n > 0 {
+		fmt.Fprintf(out, "  (Kept %d existing generate-once file(s))\n", n)
+	}
+	if message != "" {
+		fmt.Fprintf(out, "\n%s\n", strings.TrimRight(message, "\n"))
+	}
+	return nil
 }
 
 func loadConfig() (*config.Config, error) {
 	var cfg *config.Config
 	var configUsed bool
 
-	// Auto-detect config file if not specified
-	if configFile == "" {
-		// Check for common config file names
-		candidates := []string{"stencil.json", ".stencil.json", "stencil.config.json"}
-		for _, candidate := range candidates {
-			if _, err := os.Stat(candidate); err == nil {
-				configFile = candidate
-				break
+	set := explicitFlags()
+	provenance = make(map[string]string)
+
+	if noConfig && configFile != "" {
+		return nil, fmt.Errorf("--no-config cannot be combined with --config")
+	}
+
+	// Auto-detect config file if not specified, searching upward from the
+	// current directory like git does for .git
+	autoDetected := false
+	if configFile == "" && !noConfig {
+		found := findAllUpward(configCandidates...)
+		if len(found) > 1 {
+			if strictConfig {
+				return nil, fmt.Errorf("found several config files (%s); keep one or choose one with --config",
+					strings.Join(found, ", "))
 			}
+			logger.Warn(fmt.Sprintf("Found several config files (%s); using %s", strings.Join(found, ", "), found[0]),
+				"files", found, "config", found[0])
 		}
+		if len(found) > 0 {
+			configFile = found[0]
+		}
+		autoDetected = configFile != ""
 	}
 
 	// Load from config file if specified or auto-detected
@@ -149,65 +429,439 @@ func loadConfig() (*config.Config, error) {
 			return nil, fmt.Errorf("failed to load config file '%s': %w", configFile, err)
 		}
 		configUsed = true
+
+		keys, err := configFileKeys(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file '%s': %w", configFile, err)
+		}
+		for _, key := range keys {
+			provenance[key] = "config file " + configFile
+		}
+
+		// Paths in an auto-detected config are relative to its directory,
+		// so stencil can run from any subdirectory of the project
+		if autoDetected {
+			base := filepath.Dir(configFile)
+			if !source.IsRemote(cfg.TemplateDir) {
+				cfg.TemplateDir = resolveRelative(base, cfg.TemplateDir)
+			}
+			cfg.OutputDir = resolveRelative(base, cfg.OutputDir)
+			cfg.TempDir = resolveRelative(base, cfg.TempDir)
+		}
 	} else {
 		cfg = config.DefaultConfig()
+
+		// Inside a template, use the template containing the current
+		// directory
+		if _, err := os.Stat(cfg.TemplateDir); os.IsNotExist(err) && !noConfig {
+			if path := findUpward(manifest.FileName); path != "" {
+				cfg.TemplateDir = filepath.Dir(path)
+				provenance["templateDir"] = "manifest " + path
+			}
+		}
 	}
 
-	// Override with command-line flags (flags take precedence)
-	if templateDir != "" {
+	// Override with command-line flags (flags take precedence). Only flags
+	// given explicitly override the config file.
+	if name, ok := set.any("t", "template"); ok {
 		cfg.TemplateDir = templateDir
+		provenance["templateDir"] = "flag " + name
 	}
-	if outputDir != "" {
+	if name, ok := set.any("o", "output"); ok {
 		cfg.OutputDir = outputDir
+		provenance["outputDir"] = "flag " + name
+	}
+	if name, ok := set.any("i", "interactive"); ok {
+		cfg.Interactive = interactiveMode
+		provenance["interactive"] = "flag " + name
+	}
+	if name, ok := set.any("dry-run"); ok {
+		cfg.DryRun = dryRun
+		provenance["dryRun"] = "flag " + name
+	}
+	if name, ok := set.any("trial"); ok {
+		cfg.Trial = trial
+		provenance["trial"] = "flag " + name
+	}
+	if name, ok := set.any("y", "yes"); ok {
+		cfg.SkipConfirm = skipConfirm
+		provenance["skipConfirm"] = "flag " + name
+	}
+	if name, ok := set.any("backup"); ok {
+		cfg.Backup = backup
+		provenance["backup"] = "flag " + name
+	}
+	if name, ok := set.any("prune-output"); ok {
+		cfg.PruneOutput = pruneOutput
+		provenance["pruneOutput"] = "flag " + name
 	}
-	if interactiveMode {
-		cfg.Interactive = true
+	if name, ok := set.any("cleanup-on-error"); ok {
+		cfg.CleanupOnError = cleanupOnError
+		provenance["cleanupOnError"] = "flag " + name
 	}
-	if dryRun {
-		cfg.DryRun = true
+	if name, ok := set.any("no-path-replace"); ok {
+		cfg.ReplaceInPaths = !noPathReplace
+		provenance["replaceInPaths"] = "flag " + name
 	}
-	if skipConfirm {
-		cfg.SkipConfirm = true
+	if name, ok := set.any("no-content-replace"); ok {
+		cfg.ReplaceInContent = !noContentReplace
+		provenance["replaceInContent"] = "flag " + name
+	}
+	if name, ok := set.any("no-binary-skip"); ok {
+		cfg.ForceTextReplacement = noBinarySkip
+		provenance["forceTextReplacement"] = "flag " + name
+	}
+	if name, ok := set.any("concurrency"); ok {
+		cfg.Concurrency = concurrency
+		provenance["concurrency"] = "flag " + name
 	}
 
-	// Parse variables from command line (merge with config variables)
-	if variables != "" {
-		if cfg.Variables == nil {
-			cfg.Variables = make(map[string]string)
+	// Variables from a values file override the config; -v overrides both.
+	// The config's own variables already have their provenance recorded.
+	layers := []config.VariableLayer{{Variables: cfg.Variables}}
+	if name, ok := set.any("var-file"); ok {
+		values, err := config.LoadValues(varFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load values file '%s': %w", varFile, err)
 		}
-		vars := strings.Split(variables, ",")
-		for _, v := range vars {
-			parts := strings.SplitN(v, "=", 2)
-			if len(parts) == 2 {
-				cfg.Variables[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-			}
+		layers = append(layers, config.VariableLayer{Source: "flag " + name + " " + varFile, Variables: values})
+	}
+
+	// Parse variables from command line
+	if name, ok := set.any("v", "vars"); ok {
+		vars := parseKeyValues(variables)
+		loaded, err := config.ResolveFileValues(vars, ".")
+		if err != nil {
+			return nil, err
+		}
+		vars, loaded, err = cfg.Normalize.NormalizeVariables(vars, loaded)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, config.VariableLayer{Source: "flag " + name, Variables: vars})
+		cfg.FileValues = append(cfg.FileValues, loaded...)
+	}
+
+	merged, sources := config.MergeVariablesWithSources(layers...)
+	cfg.Variables = merged
+	for key, source := range sources {
+		if source != "" {
+			provenance["variables."+key] = source
 		}
 	}
 
 	// Apply format flags (flags take precedence over config file)
-	if disableBraces != nil {
+	if name, ok := set.any("custom-delimiters"); ok {
+		open, close, ok := strings.Cut(strings.TrimSpace(customDelims), " ")
+		close = strings.TrimSpace(close)
+		if !ok || open == "" || close == "" {
+			return nil, fmt.Errorf("invalid --custom-delimiters %q: expected '<open> <close>'", customDelims)
+		}
+		cfg.Formats.CustomOpen = open
+		cfg.Formats.CustomClose = close
+		provenance["formats.customOpen"] = "flag " + name
+		provenance["formats.customClose"] = "flag " + name
+	}
+	if name, ok := set.any("formats"); ok {
+		formats, err := parseFormats(formatList, cfg.Formats)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Formats = formats
+		for _, key := range []string{"enableBraces", "enableAngleBrackets", "enableUnderscores", "enablePercent", "customOpen", "customClose"} {
+			provenance["formats."+key] = "flag " + name
+		}
+	}
+	if name, ok := set.any("disable-braces"); ok {
 		cfg.Formats.EnableBraces = !*disableBraces
+		provenance["formats.enableBraces"] = "flag " + name
 	}
-	if disableAngleBrackets != nil {
+	if name, ok := set.any("disable-angle-brackets"); ok {
 		cfg.Formats.EnableAngleBrackets = !*disableAngleBrackets
+		provenance["formats.enableAngleBrackets"] = "flag " + name
 	}
-	if disableUnderscores != nil {
+	if name, ok := set.any("disable-underscores"); ok {
 		cfg.Formats.EnableUnderscores = !*disableUnderscores
+		provenance["formats.enableUnderscores"] = "flag " + name
 	}
-	if disablePercent != nil {
+	if name, ok := set.any("disable-percent"); ok {
 		cfg.Formats.EnablePercent = !*disablePercent
+		provenance["formats.enablePercent"] = "flag " + name
 	}
 
 	// Show which config was used
-	if configUsed {
-		fmt.Printf("Using config file: %s\n", configFile)
+	if configUsed && !explainConfig {
+		logger.Info("Using config file: "+configFile, "path", configFile)
 	}
 
 	return cfg, nil
 }
 
-func runInteractiveMode(gen *generator.Generator) error {
+// parseFormats enables exactly the formats named in a comma-separated list.
+// "custom" keeps the custom delimiter pair of current; any format not
+// listed is disabled.
+func parseFormats(list string, current config.FormatOptions) (config.FormatOptions, error) {
+	var formats config.FormatOptions
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "braces":
+			formats.EnableBraces = true
+		case "angle-brackets":
+			formats.EnableAngleBrackets = true
+		case "underscores":
+			formats.EnableUnderscores = true
+		case "percent":
+			formats.EnablePercent = true
+		case "custom":
+			if !current.CustomEnabled() {
+				return formats, fmt.Errorf("format 'custom' requires --custom-delimiters or formats.customOpen/customClose")
+			}
+			formats.CustomOpen = current.CustomOpen
+			formats.CustomClose = current.CustomClose
+		default:
+			return formats, fmt.Errorf("unknown format '%s' (expected braces, angle-brackets, underscores, percent or custom)", name)
+		}
+	}
+	return formats, nil
+}
+
+// printStats prints template statistics, as JSON with --json
+func printStats(gen *generator.Generator) error {
+	stats, err := gen.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to scan template: %w", err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Files:       %d (%d text, %d binary)\n", stats.Files, stats.TextFiles, stats.BinaryFiles)
+	fmt.Printf("Directories: %d\n", stats.Directories)
+	fmt.Printf("Variables:   %d\n", stats.Variables)
+	fmt.Printf("Total size:  %d bytes\n", stats.TotalBytes)
+	return nil
+}
+
+// runCheckVars lists the template's variables that have no value to out
+// and returns the exit code: 1 if any are missing or the scan failed, 0
+// otherwise
+func runCheckVars(gen *generator.Generator, out io.Writer) int {
+	missing, err := gen.MissingVariables()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error checking variables: %v", err), "error", err)
+		return 1
+	}
+
+	if jsonOutput {
+		if missing == nil {
+			missing = []string{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(struct {
+			Missing []string `json:"missing"`
+		}{missing})
+	} else {
+		for _, name := range missing {
+			fmt.Fprintln(out, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		if !jsonOutput {
+			logger.Warn(fmt.Sprintf("%d variable(s) without a value", len(missing)), "variables", len(missing))
+		}
+		return 1
+	}
+	return 0
+}
+
+// writePatch writes the git diff between the current output and what
+// generation would produce to path
+func writePatch(gen *generator.Generator, path string) error {
+	patch, err := gen.Patch()
+	printReport(gen)
+	if err != nil {
+		return fmt.Errorf("failed to compute patch: %w", err)
+	}
+	if err := os.WriteFile(path, patch, 0644); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+
+	if len(patch) == 0 {
+		fmt.Printf("No changes; wrote empty patch to %s\n", path)
+	} else {
+		fmt.Printf("Wrote patch to %s\n", path)
+	}
+	return nil
+}
+
+// runCheck lists the output files a generation run would change, like
+// gofmt -l, and returns the exit code: 1 if any would change or the check
+// failed, 0 otherwise
+func runCheck(gen *generator.Generator) int {
+	changed, err := gen.Check()
+	printReport(gen)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error checking project: %v", err), "error", err)
+		return 1
+	}
+
+	if jsonOutput {
+		if changed == nil {
+			changed = []string{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(struct {
+			Changed []string `json:"changed"`
+		}{changed})
+	} else {
+		for _, path := range changed {
+			fmt.Println(path)
+		}
+	}
+
+	if len(changed) > 0 {
+		if !jsonOutput {
+			logger.Warn(fmt.Sprintf("%d file(s) out of date with the template", len(changed)), "files", len(changed))
+		}
+		return 1
+	}
+	return 0
+}
+
+// runChangesOnly lists the output files a run would create, modify or
+// delete, leaving out files that are already up to date. The --json list
+// is written to out.
+func runChangesOnly(gen *generator.Generator, out io.Writer) int {
+	changes, err := gen.Changes()
+	printReport(gen)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err), "error", err)
+		return 1
+	}
+
+	if jsonOutput {
+		if changes == nil {
+			changes = []generator.FileChange{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(struct {
+			Changes []generator.FileChange `json:"changes"`
+		}{changes})
+		return 0
+	}
+
+	for _, change := range changes {
+		logger.Info(fmt.Sprintf("[DRY RUN] Would %s file: %s", change.Kind, change.Path),
+			"path", change.Path, "change", change.Kind)
+	}
+	if len(changes) == 0 {
+		logger.Info("[DRY RUN] No changes")
+	} else {
+		logger.Info(fmt.Sprintf("[DRY RUN] %d file(s) would change", len(changes)), "files", len(changes))
+	}
+	return 0
+}
+
+// printProgress prints a progress line for a completed file
+func printProgress(event generator.ProgressEvent) {
+	status := "ok"
+	if event.Err != nil {
+		status = "failed"
+	}
+	line := fmt.Sprintf("[%d/%d] %s (%s)", event.Index, event.Total, event.Path, status)
+	if event.ETA > 0 {
+		eta := event.ETA.Round(time.Second)
+		if eta == 0 {
+			eta = event.ETA.Round(time.Millisecond)
+		}
+		line += fmt.Sprintf(" - %.1f files/s, ETA %s", event.Rate, eta)
+	}
+	fmt.Println(line)
+}
+
+// printReport prints the consolidated warnings and errors of the last run
+func printReport(gen *generator.Generator) {
+	if summary := gen.Report().Summary(); summary != "" {
+		fmt.Fprint(os.Stderr, "\n"+summary)
+	}
+}
+
+// parseKeyValues parses a 'key1=value1,key2=value2' list. A comma inside a
+// value is written '\,', so list values read 'items=a\,b\,c'.
+func parseKeyValues(s string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range splitPairs(s) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return result
+}
+
+// splitPairs splits a key=value list on commas not escaped as '\,', and
+// unescapes those. Other backslashes are kept, so Windows paths need no
+// escaping.
+func splitPairs(s string) []string {
+	var pairs []string
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case s[i] == ',':
+			pairs = append(pairs, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+	return append(pairs, current.String())
+}
+
+// runInteractiveMode prompts for variable values and generates the project.
+// It reports whether generation ran, which is false when the user cancels.
+func runInteractiveMode(gen *generator.Generator) (bool, error) {
 	prompter := interactive.NewPrompter()
+	if bulkEdit && answersFile != "" {
+		return false, fmt.Errorf("--bulk-edit cannot be combined with --answers-file")
+	}
+	if answersFile != "" {
+		file, err := os.Open(answersFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to open answers file: %w", err)
+		}
+		defer file.Close()
+		prompter = interactive.NewPrompterWithReader(file)
+	}
+	prompter.SetValidator(gen.ValidateValue)
+	conditions, err := gen.PromptConditions()
+	if err != nil {
+		return false, err
+	}
+	prompter.SetConditions(conditions)
+	known := gen.ConfiguredVariables()
+	prompter.SetKnownValues(known)
+	help, err := gen.PromptHelp()
+	if err != nil {
+		return false, err
+	}
+	prompter.SetHelp(help)
 
 	fmt.Println("=== Stencil - Interactive Mode ===")
 	fmt.Println("Scanning template for variables...")
@@ -215,21 +869,33 @@ func runInteractiveMode(gen *generator.Generator) error {
 	// Extract variables from template
 	variables, err := gen.ExtractVariables()
 	if err != nil {
-		return fmt.Errorf("failed to extract variables: %w", err)
+		return false, fmt.Errorf("failed to extract variables: %w", err)
 	}
 
 	if len(variables) == 0 {
 		fmt.Println("No variables found in template.")
 		fmt.Println("Generating project...")
-		return gen.Generate()
+		return true, gen.Generate()
 	}
 
 	fmt.Printf("Found %d variables in template.\n", len(variables))
 
-	// Prompt for values
-	values, err := prompter.PromptForValues(variables)
+	// Prompt for values, or let the user edit them all at once
+	prompt := prompter.PromptForValues
+	if bulkEdit {
+		prompt = prompter.EditValues
+	}
+	values, err := prompt(variables)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	// Update generator with values, which may complete the output path.
+	// Answers override the configured values, which still apply to
+	// variables that were not asked for.
+	gen.SetVariables(config.MergeVariables(known, values))
+	if err := gen.ResolveConfigPaths(); err != nil {
+		return false, err
 	}
 
 	// Display summary
@@ -243,22 +909,80 @@ func runInteractiveMode(gen *generator.Generator) error {
 
 	// Confirmation
 	if !gen.SkipConfirm() {
-		confirmed, err := prompter.PromptForConfirmation("Proceed with generation?")
+		// Yes is the safe default unless existing files may be overwritten
+		confirmed, err := prompter.PromptForConfirmationDefault("Proceed with generation?", isEmptyDir(gen.OutputDir()))
 		if err != nil {
-			return err
+			return false, err
 		}
 		if !confirmed {
 			fmt.Println("Generation cancelled.")
-			return nil
+			return false, nil
 		}
 	}
 
-	// Update generator with values
-	gen.SetVariables(values)
-
 	// Generate
 	fmt.Println("\nGenerating project...")
-	return gen.Generate()
+	return true, gen.Generate()
+}
+
+// configCandidates lists the config file names detected automatically, in
+// order of priority
+var configCandidates = []string{"stencil.json", ".stencil.json", "stencil.config.json"}
+
+// findUpward returns the first of names found in the current directory or
+// its ancestors up to the file system root, or "" if none exists. A match in
+// the current directory keeps its relative name.
+func findUpward(names ...string) string {
+	if found := findAllUpward(names...); len(found) > 0 {
+		return found[0]
+	}
+	return ""
+}
+
+// findAllUpward returns every one of names found in the nearest directory,
+// starting from the current one, that contains any of them, in the order
+// of names
+func findAllUpward(names ...string) []string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+
+	for start := dir; ; {
+		var found []string
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				if dir == start {
+					path = name
+				}
+				found = append(found, path)
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// resolveRelative resolves a relative path against base
+func resolveRelative(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// isEmptyDir reports whether dir is missing or contains no entries
+func isEmptyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err != nil || len(entries) == 0
 }
 
 func printHelp() {
@@ -266,19 +990,76 @@ func printHelp() {
 
 USAGE:
   stencil [OPTIONS]
+  stencil reverse --from <dir> --values <values> [--to <dir>]
+  stencil diff -t <dir> --vars-a <vars> --vars-b <vars>
+  stencil upgrade --from <old-dir> -t <new-dir> [-o <project>]
+  stencil sign -t <dir> --key <private-key.pem>
+
+COMMANDS:
+  reverse                   Turn an existing project into a template
+  diff                      Show how two variable sets change the output
+  grep-var <name>           List the files and lines where a variable is used
+  info                      Describe a template's manifest and variables
+  serve                     Serve a directory of templates over HTTP
+  preview                   Preview a template in the browser as you edit its variables
+  upgrade                   Merge a newer template version into a generated project
+  sign                      Sign a template for verification with trustedKeys
 
 OPTIONS:
   -t, --template <dir>      Template directory path (default: ./template)
+                            or an oci://registry/repo:tag artifact
   -o, --output <dir>        Output directory path (default: ./output)
   -c, --config <file>       Configuration file path (JSON)
-  -v, --vars <vars>         Variables in format 'key1=value1,key2=value2'
+  --no-config               Ignore config files and use built-in defaults plus flags
+  --strict-config           Fail when several config files are detected instead
+                            of using the first
+  -v, --vars <vars>         Variables in format 'key1=value1,key2=value2';
+                            escape commas in values as '\,'
+                            (a value of '@path' reads the value from a file)
+  --var-file <file>         Read variables from a JSON file such as
+                            stencil.values.json
   -i, --interactive         Interactive mode
+  --answers-file <file>     Read interactive answers from a file, one per line
+                            in prompt order (variables are sorted by name)
+  --bulk-edit               Edit all interactive values at once as key=value
+                            lines in $VISUAL or $EDITOR
   --dry-run                 Dry run (show what would be generated)
+  --changes-only            With --dry-run, list only the files that would be
+                            created, modified or deleted
+  --trial                   Generate into a temporary directory and discard it,
+                            reporting errors a real run would hit
+  --check                   List output files generation would change and exit
+                            non-zero if there are any (for CI)
+  --check-vars              List variables that have no value and exit non-zero
+                            if there are any, without generating
+  --patch <file>            Write the changes generation would make to the
+                            output as a git diff, leaving the output as-is
   -y, --yes                 Skip confirmation in interactive mode
+  --no-path-replace         Keep file and directory names as-is
+  --no-content-replace      Keep file contents as-is
+  --no-binary-skip          Substitute variables in files detected as binary too
+  --concurrency <n>         Number of files to process in parallel (default: 1)
+  --backup                  Save existing files as <file>.bak before overwriting
+  --prune-output            Remove output files not produced by the template
+                            (patterns in <output>/.stencil-keep are kept)
+  --cleanup-on-error        Remove the files and directories a failed run
+                            created, e.g. when a validate command fails
   --disable-braces          Disable {{var}} format (default: enabled)
   --disable-angle-brackets  Disable <<var>> format (default: enabled)
   --disable-underscores     Disable __var__ format (default: enabled)
   --disable-percent         Disable %%var%% format (default: enabled)
+  --formats <list>          Enable only the listed formats (braces, angle-brackets,
+                            underscores, percent, custom); others are left untouched
+  --custom-delimiters <d>   Custom delimiter pair separated by a space, e.g. '[[ ]]'
+  --verbose                 Show progress for each generated file, with throughput and ETA,
+                            and debug messages
+  --stats                   Show template statistics without generating
+  --json                    Print machine-readable JSON output (with --stats or
+                            after generation)
+  --events                  Stream progress as JSON lines on stdout: start,
+                            file, warning, error and done events
+  -q, --quiet               Suppress the success and post-generation messages
+  --explain-config          Show where each configuration value came from
   --version                 Show version information
   -h, --help                Show this help message
 
@@ -288,7 +1069,9 @@ AUTO-DETECTION:
   - .stencil.json
   - stencil.config.json
 
-  Command-line flags override config file values.
+  When several of them are present, the first is used with a warning, or
+  the run fails with --strict-config. Command-line flags override config
+  file values. Use --no-config to skip detection entirely.
 
 EXAMPLES:
   # Auto-detect stencil.json and run
@@ -309,6 +1092,12 @@ EXAMPLES:
   # Dry run to preview changes
   stencil -t ./template -o ./output --dry-run
 
+  # Compare renders with different variable values
+  stencil diff -t ./template --vars-a "port=8080" --vars-b "port=9090"
+
+  # Turn an existing project into a template
+  stencil reverse --from ./myapp --to ./template --values "myapp=project_name"
+
 TEMPLATE SYNTAX:
   Variables can be specified in multiple formats (all enabled by default):
   - {{variable}}        Can be disabled with --disable-braces
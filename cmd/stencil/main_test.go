@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// writeTree creates files under dir from a map of slash-separated relative
+// paths to contents
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the regular files under dir by slash-separated relative
+// path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestParseKeyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{name: "empty", in: "", want: map[string]string{}},
+		{name: "pairs", in: "name=app, author = Jane ", want: map[string]string{"name": "app", "author": "Jane"}},
+		{name: "equals in value", in: "query=a=b", want: map[string]string{"query": "a=b"}},
+		{
+			name: "escaped commas",
+			in:   `entities=User\,Order\,Invoice,package_name=models`,
+			want: map[string]string{"entities": "User,Order,Invoice", "package_name": "models"},
+		},
+		{
+			// A plain comma starts a new pair; a piece without '=' is not
+			// appended to the previous value
+			name: "piece without equals ignored",
+			in:   "entities=User,Order,package_name=models",
+			want: map[string]string{"entities": "User", "package_name": "models"},
+		},
+		{name: "other backslashes kept", in: `dir=C:\work\app,x=1`, want: map[string]string{"dir": `C:\work\app`, "x": "1"}},
+		{name: "trailing backslash", in: `a=b\`, want: map[string]string{"a": `b\`}},
+		{name: "empty value", in: "a=,b=2", want: map[string]string{"a": "", "b": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKeyValues(tt.in); !maps.Equal(got, tt.want) {
+				t.Errorf("parseKeyValues(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	custom := config.FormatOptions{EnableBraces: true, EnablePercent: true, CustomOpen: "[[", CustomClose: "]]"}
+
+	tests := []struct {
+		name    string
+		list    string
+		current config.FormatOptions
+		want    config.FormatOptions
+		wantErr string
+	}{
+		{name: "exactly braces", list: "braces", current: custom, want: config.FormatOptions{EnableBraces: true}},
+		{name: "exactly custom", list: "custom", current: custom, want: config.FormatOptions{CustomOpen: "[[", CustomClose: "]]"}},
+		{
+			name: "several with spaces",
+			list: "angle-brackets, underscores ,percent",
+			want: config.FormatOptions{EnableAngleBrackets: true, EnableUnderscores: true, EnablePercent: true},
+		},
+		{name: "none", list: "", current: custom, want: config.FormatOptions{}},
+		{name: "custom without delimiters", list: "braces,custom", wantErr: "requires --custom-delimiters"},
+		{name: "unknown", list: "braces,brackets", wantErr: "unknown format 'brackets'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFormats(tt.list, tt.current)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseFormats(%q) error = %v, want %q", tt.list, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFormats(%q) failed: %v", tt.list, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseFormats(%q) = %+v, want %+v", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintSuccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		quiet   bool
+		json    bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "text",
+			want: []string{"✓ Project generated successfully!", "\nNext steps:\n  cd my-app && go run ./cmd/my-app\n"},
+		},
+		{
+			name:    "quiet",
+			quiet:   true,
+			notWant: []string{"Next steps", "successfully"},
+		},
+		{
+			name:    "json",
+			json:    true,
+			want:    []string{`"postMessage": "Next steps:\n  cd my-app && go run ./cmd/my-app\n"`},
+			notWant: []string{"successfully"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{
+				"main.go": "package main\n",
+				manifest.FileName: `{
+					"variables": [{"name": "name"}],
+					"postMessage": "Next steps:\n  cd {{name}} && go run ./cmd/__name__\n"
+				}`,
+			})
+
+			cfg := config.DefaultConfig()
+			cfg.TemplateDir, cfg.OutputDir = tmpl, out
+			cfg.Variables = map[string]string{"name": "my-app"}
+			gen := generator.NewGenerator(cfg)
+			gen.SetLogger(generator.NewConsoleLogger(io.Discard, io.Discard))
+			if err := gen.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			quiet, jsonOutput = tt.quiet, tt.json
+			t.Cleanup(func() { quiet, jsonOutput = false, false })
+			var buf bytes.Buffer
+			if err := printSuccess(&buf, gen, cfg); err != nil {
+				t.Fatalf("printSuccess failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, buf.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(buf.String(), notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, buf.String())
+				}
+			}
+		})
+	}
+}
+
+// TestLoadConfigNormalize checks that -v values are normalized like the
+// config file's own variables
+func TestLoadConfigNormalize(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeTree(t, dir, map[string]string{
+		"stencil.json": `{
+			"normalize": {"trimSpace": true, "lowercaseKeys": true, "transforms": {"name": "lower"}},
+			"variables": {"Name": " Config App ", "Region": " EU "}
+		}`,
+	})
+
+	parseFlags(t, "--config", filepath.Join(dir, "stencil.json"), "-v", " NAME = Flag App ,Owner=Jane ")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	want := map[string]string{"name": "flag app", "region": "EU", "owner": "Jane"}
+	if !maps.Equal(cfg.Variables, want) {
+		t.Errorf("variables = %q, want %q", cfg.Variables, want)
+	}
+}
+
+func TestLoadConfigSearchesUpward(t *testing.T) {
+	tests := []struct {
+		name         string
+		tree         map[string]string
+		cwd          string // relative to the root
+		wantTemplate string // relative to the root
+		wantOutput   string // relative to the root
+	}{
+		{
+			name: "config in a parent directory",
+			tree: map[string]string{
+				"project/stencil.json":        `{"templateDir": "tmpl", "outputDir": "out"}`,
+				"project/tmpl/README.md":      "# {{name}}\n",
+				"project/src/nested/deep.txt": "",
+			},
+			cwd:          "project/src/nested",
+			wantTemplate: "project/tmpl",
+			wantOutput:   "project/out",
+		},
+		{
+			name: "config in the current directory",
+			tree: map[string]string{
+				"project/stencil.json":   `{"templateDir": "tmpl", "outputDir": "out"}`,
+				"project/tmpl/README.md": "",
+			},
+			cwd:          "project",
+			wantTemplate: "project/tmpl",
+			wantOutput:   "project/out",
+		},
+		{
+			name: "nearest config wins",
+			tree: map[string]string{
+				"stencil.json":            `{"templateDir": "outer"}`,
+				"project/.stencil.json":   `{"templateDir": "inner"}`,
+				"project/sub/README.md":   "",
+				"project/inner/README.md": "",
+			},
+			cwd:          "project/sub",
+			wantTemplate: "project/inner",
+			wantOutput:   "project/output",
+		},
+		{
+			name: "template containing the current directory",
+			tree: map[string]string{
+				"tmpl/" + manifest.FileName: `{"name": "demo"}`,
+				"tmpl/cmd/{{name}}/main.go": "",
+			},
+			cwd:          "tmpl/cmd",
+			wantTemplate: "tmpl",
+			wantOutput:   "tmpl/cmd/output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeTree(t, root, tt.tree)
+			t.Chdir(filepath.Join(root, filepath.FromSlash(tt.cwd)))
+
+			parseFlags(t)
+			cfg, err := loadConfig()
+			if err != nil {
+				t.Fatalf("loadConfig failed: %v", err)
+			}
+			// Paths may stay relative to the current directory
+			for _, dir := range []struct{ name, got, want string }{
+				{"templateDir", cfg.TemplateDir, tt.wantTemplate},
+				{"outputDir", cfg.OutputDir, tt.wantOutput},
+			} {
+				got, err := filepath.Abs(dir.got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := filepath.Join(root, filepath.FromSlash(dir.want)); got != want {
+					t.Errorf("%s = %s, want %s", dir.name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigSeveralCandidates(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeTree(t, dir, map[string]string{
+		"stencil.config.json": `{"templateDir": "third"}`,
+		".stencil.json":       `{"templateDir": "second"}`,
+		"stencil.json":        `{"templateDir": "first"}`,
+	})
+
+	saved := logger
+	t.Cleanup(func() { logger = saved })
+	var log bytes.Buffer
+	logger = generator.NewConsoleLogger(&log, &log)
+
+	// The first candidate in detection order is used, whatever else exists
+	parseFlags(t)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if cfg.TemplateDir != "first" {
+		t.Errorf("templateDir = %s, want first", cfg.TemplateDir)
+	}
+	want := "Found several config files (stencil.json, .stencil.json, stencil.config.json); using stencil.json"
+	if !strings.Contains(log.String(), want) {
+		t.Errorf("log = %q, want it to contain %q", log.String(), want)
+	}
+
+	// A single candidate is used silently
+	if err := os.Remove("stencil.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove("stencil.config.json"); err != nil {
+		t.Fatal(err)
+	}
+	log.Reset()
+	parseFlags(t, "--strict-config")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if cfg.TemplateDir != "second" || strings.Contains(log.String(), "several") {
+		t.Errorf("templateDir = %s, log = %q, want second without a warning", cfg.TemplateDir, log.String())
+	}
+
+	writeTree(t, dir, map[string]string{"stencil.json": `{"templateDir": "first"}`})
+	parseFlags(t, "--strict-config")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "found several config files (stencil.json, .stencil.json)") {
+		t.Errorf("loadConfig error = %v, want several config files rejected", err)
+	}
+}
+
+func TestLoadConfigNoConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeTree(t, dir, map[string]string{
+		"stencil.json": `{"templateDir": "from-config", "outputDir": "from-config", "variables": {"name": "config"}}`,
+	})
+
+	parseFlags(t, "--no-config", "-o", "from-flag", "-v", "author=flag")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	defaults := config.DefaultConfig()
+	if cfg.TemplateDir != defaults.TemplateDir {
+		t.Errorf("templateDir = %s, want the default %s", cfg.TemplateDir, defaults.TemplateDir)
+	}
+	if cfg.OutputDir != "from-flag" {
+		t.Errorf("outputDir = %s, want from-flag", cfg.OutputDir)
+	}
+	if want := map[string]string{"author": "flag"}; !maps.Equal(cfg.Variables, want) {
+		t.Errorf("variables = %q, want %q", cfg.Variables, want)
+	}
+
+	parseFlags(t, "--no-config", "--config", "stencil.json")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("loadConfig error = %v, want --no-config and --config rejected", err)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// previewFile is one rendered file in a preview response. Binary files are
+// listed without their content.
+type previewFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content,omitempty"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// runPreview implements the preview subcommand, a local web page for
+// template authors that renders a template in memory as its variables are
+// edited. Nothing is ever written to disk.
+func runPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+
+	var tmplDir, addr string
+	var trustedKeys keyList
+	fs.StringVar(&tmplDir, "t", "./template", "Template directory path")
+	fs.StringVar(&tmplDir, "template", "./template", "Template directory path")
+	fs.StringVar(&addr, "addr", defaultAddr, "Address to listen on")
+	fs.Var(&trustedKeys, "trusted-key", "Public key the template must be signed with (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if info, err := os.Stat(tmplDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("template directory does not exist: %s", tmplDir)
+	}
+
+	fmt.Printf("Previewing %s on http://%s\n", tmplDir, addr)
+	return http.ListenAndServe(addr, newPreviewHandler(tmplDir, trustedKeys))
+}
+
+// newPreviewHandler returns the handler for the preview subcommand:
+//
+//	GET  /           the preview page
+//	GET  /variables  the template's variables as JSON
+//	POST /render     renders the template from posted variables,
+//	                 responding with the files as JSON
+//
+// The template is read again on every request, so edits to it show up on
+// the next render, and its signature is verified on every render when
+// trusted keys are given.
+func newPreviewHandler(templateDir string, trustedKeys []string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, previewPage)
+	})
+
+	mux.HandleFunc("GET /variables", func(w http.ResponseWriter, r *http.Request) {
+		variables, err := previewVariables(templateDir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(variables)
+	})
+
+	mux.HandleFunc("POST /render", func(w http.ResponseWriter, r *http.Request) {
+		var req generateRequest
+		body := http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		if err := json.NewDecoder(body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		rendered, err := renderTemplate(templateDir, req.Variables, trustedKeys)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		files := make([]previewFile, 0, len(rendered))
+		for _, f := range rendered {
+			file := previewFile{Path: f.Path, Binary: f.Binary}
+			if !f.Binary {
+				file.Content = string(f.Content)
+			}
+			files = append(files, file)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(files)
+	})
+
+	return mux
+}
+
+// previewVariables returns the manifest's variables followed by any other
+// variable the template uses, so the form covers every placeholder
+func previewVariables(templateDir string) ([]manifest.Variable, error) {
+	m, err := manifest.Load(templateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	variables := []manifest.Variable{}
+	declared := make(map[string]bool)
+	if m != nil {
+		for _, v := range m.Variables {
+			variables = append(variables, v)
+			declared[v.Name] = true
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.TemplateDir = templateDir
+	names, err := generator.NewGenerator(cfg).Variables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract variables: %w", err)
+	}
+	for _, name := range names {
+		if !declared[name] {
+			variables = append(variables, manifest.Variable{Name: name})
+		}
+	}
+	return variables, nil
+}
+
+// previewPage is the preview page. It builds a form from /variables and
+// re-renders shortly after each edit.
+const previewPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Stencil preview</title>
+<style>
+body { margin: 0; font: 14px sans-serif; display: flex; height: 100vh; }
+#form { width: 280px; padding: 12px; overflow: auto; border-right: 1px solid #ddd; }
+#form label { display: block; margin-top: 10px; font-weight: bold; }
+#form small { display: block; color: #666; font-weight: normal; }
+#form input { width: 100%; box-sizing: border-box; }
+#files { width: 260px; overflow: auto; border-right: 1px solid #ddd; margin: 0; padding: 12px; list-style: none; }
+#files li { cursor: pointer; padding: 2px 0; font-family: monospace; }
+#files li.selected { font-weight: bold; }
+#content { flex: 1; overflow: auto; margin: 0; padding: 12px; white-space: pre; font-family: monospace; }
+#error { color: #b00; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<form id="form" onsubmit="return false"><div id="error"></div></form>
+<ul id="files"></ul>
+<pre id="content"></pre>
+<script>
+const form = document.getElementById("form");
+const list = document.getElementById("files");
+const content = document.getElementById("content");
+const error = document.getElementById("error");
+let files = [], selected = "", timer;
+
+function values() {
+  const vars = {};
+  for (const input of form.querySelectorAll("input")) {
+    if (input.value !== "") vars[input.name] = input.value;
+  }
+  return vars;
+}
+
+async function render() {
+  const resp = await fetch("render", {method: "POST", body: JSON.stringify({variables: values()})});
+  if (!resp.ok) {
+    error.textContent = await resp.text();
+    return;
+  }
+  error.textContent = "";
+  files = await resp.json();
+  if (!files.some(f => f.path === selected)) selected = files.length ? files[0].path : "";
+  show();
+}
+
+function show() {
+  list.replaceChildren(...files.map(f => {
+    const li = document.createElement("li");
+    li.textContent = f.path;
+    li.className = f.path === selected ? "selected" : "";
+    li.onclick = () => { selected = f.path; show(); };
+    return li;
+  }));
+  const file = files.find(f => f.path === selected);
+  content.textContent = !file ? "" : file.binary ? "(binary file)" : file.content;
+}
+
+fetch("variables").then(r => r.json()).then(vars => {
+  for (const v of vars) {
+    const label = document.createElement("label");
+    label.textContent = v.name;
+    if (v.description) {
+      const help = document.createElement("small");
+      help.textContent = v.description;
+      label.append(help);
+    }
+    const input = document.createElement("input");
+    input.name = v.name;
+    input.placeholder = v.default || v.example || "";
+    input.type = v.secret ? "password" : "text";
+    input.oninput = () => { clearTimeout(timer); timer = setTimeout(render, 200); };
+    form.append(label, input);
+  }
+  render();
+});
+</script>
+</body>
+</html>
+`
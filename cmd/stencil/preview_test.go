@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// previewTemplate is the template the preview tests render
+var previewTemplate = map[string]string{
+	"README.md":             "# {{name}} by {{author}}\n",
+	"__name__/main.go":      "package main\n",
+	"logo.bin":              "\x00\x01{{name}}",
+	"stencil.manifest.json": `{"variables": [{"name": "name", "default": "app", "description": "Project name"}]}`,
+}
+
+// previewRequest sends a request to a preview handler for templateDir
+func previewRequest(t *testing.T, templateDir, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	newPreviewHandler(templateDir, nil).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPreviewRender(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       []previewFile
+		wantErr    string
+	}{
+		{
+			name:       "posted variables",
+			body:       `{"variables": {"name": "demo", "author": "Ada"}}`,
+			wantStatus: http.StatusOK,
+			want: []previewFile{
+				{Path: "README.md", Content: "# demo by Ada\n"},
+				{Path: "demo/main.go", Content: "package main\n"},
+				{Path: "logo.bin", Binary: true},
+			},
+		},
+		{
+			name:       "defaults and unresolved placeholders",
+			body:       `{"variables": {}}`,
+			wantStatus: http.StatusOK,
+			want: []previewFile{
+				{Path: "README.md", Content: "# app by {{author}}\n"},
+				{Path: "app/main.go", Content: "package main\n"},
+				{Path: "logo.bin", Binary: true},
+			},
+		},
+		{
+			name:       "empty body",
+			wantStatus: http.StatusOK,
+			want: []previewFile{
+				{Path: "README.md", Content: "# app by {{author}}\n"},
+				{Path: "app/main.go", Content: "package main\n"},
+				{Path: "logo.bin", Binary: true},
+			},
+		},
+		{
+			name:       "invalid body",
+			body:       `{"variables": [`,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "invalid request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateDir := t.TempDir()
+			writeTree(t, templateDir, previewTemplate)
+
+			rec := previewRequest(t, templateDir, "POST", "/render", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(rec.Body.String(), tt.wantErr) {
+					t.Errorf("body = %q, want it to contain %q", rec.Body, tt.wantErr)
+				}
+				return
+			}
+
+			var got []previewFile
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			slices.SortFunc(got, func(a, b previewFile) int { return strings.Compare(a.Path, b.Path) })
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPreviewRereadsTemplate checks that edits to the template show up on
+// the next render
+func TestPreviewRereadsTemplate(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTree(t, templateDir, map[string]string{"a.txt": "v1 {{name}}"})
+
+	for _, version := range []string{"v1", "v2"} {
+		writeTree(t, templateDir, map[string]string{"a.txt": version + " {{name}}"})
+		rec := previewRequest(t, templateDir, "POST", "/render", `{"variables": {"name": "x"}}`)
+		want := `[{"path":"a.txt","content":"` + version + ` x"}]`
+		if got := strings.TrimSpace(rec.Body.String()); got != want {
+			t.Errorf("render = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestPreviewVariables(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTree(t, templateDir, previewTemplate)
+
+	rec := previewRequest(t, templateDir, "GET", "/variables", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var got []manifest.Variable
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []manifest.Variable{
+		{Name: "name", Default: "app", Description: "Project name"},
+		{Name: "author"},
+	}
+	if len(got) != len(want) || got[0].Name != want[0].Name || got[0].Default != want[0].Default ||
+		got[0].Description != want[0].Description || got[1].Name != want[1].Name {
+		t.Errorf("variables = %+v, want %+v", got, want)
+	}
+}
+
+func TestPreviewPage(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTree(t, templateDir, previewTemplate)
+
+	tests := []struct {
+		method, path string
+		wantStatus   int
+	}{
+		{"GET", "/", http.StatusOK},
+		{"GET", "/other", http.StatusNotFound},
+		{"POST", "/", http.StatusMethodNotAllowed},
+		{"GET", "/render", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := previewRequest(t, templateDir, tt.method, tt.path, "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.Contains(rec.Body.String(), "<title>Stencil preview</title>") {
+				t.Errorf("body is not the preview page")
+			}
+		})
+	}
+}
+
+// TestPreviewMissingTemplate checks that an unknown template directory is
+// refused before listening
+func TestPreviewMissingTemplate(t *testing.T) {
+	err := runPreview([]string{"-t", filepath.Join(t.TempDir(), "missing")})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("runPreview error = %v, want a missing template error", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/linxux/stencil/internal/reverser"
+)
+
+// runReverse implements the reverse subcommand, which turns an existing
+// project into a template
+func runReverse(args []string) error {
+	fs := flag.NewFlagSet("reverse", flag.ContinueOnError)
+
+	var fromDir, toDir, values string
+	fs.StringVar(&fromDir, "from", "", "Existing project directory")
+	fs.StringVar(&toDir, "to", "./template", "Template directory to create")
+	fs.StringVar(&values, "values", "", "Literal values to templatize in format 'value1=var1,value2=var2'")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fromDir == "" {
+		return fmt.Errorf("--from is required")
+	}
+
+	literals := parseKeyValues(values)
+	if len(literals) == 0 {
+		return fmt.Errorf("--values is required")
+	}
+
+	rev := reverser.NewReverser(fromDir, toDir, literals)
+	if err := rev.Reverse(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Template created in %s\n", toDir)
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// maxRequestBytes limits the size of a generation request body
+const maxRequestBytes = 1 << 20
+
+// catalogEntry describes one template served by the serve subcommand
+type catalogEntry struct {
+	// ID is the template's directory name, used in request paths
+	ID string `json:"id"`
+	templateInfo
+}
+
+// generateRequest is the body of a generation request
+type generateRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// defaultAddr is where serve and preview listen by default: only on the
+// local machine, since neither has authentication
+const defaultAddr = "127.0.0.1:8080"
+
+// runServe implements the serve subcommand, an HTTP server that lists the
+// templates in a catalog directory and generates them as zip archives
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+
+	var catalogDir, addr string
+	var trustedKeys keyList
+	fs.StringVar(&catalogDir, "templates", "./templates", "Directory containing one template per subdirectory")
+	fs.StringVar(&addr, "addr", defaultAddr, "Address to listen on")
+	fs.Var(&trustedKeys, "trusted-key", "Public key every template must be signed with (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if info, err := os.Stat(catalogDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("templates directory does not exist: %s", catalogDir)
+	}
+
+	fmt.Printf("Serving templates from %s on %s\n", catalogDir, addr)
+	return http.ListenAndServe(addr, newServeHandler(catalogDir, trustedKeys))
+}
+
+// newServeHandler returns the handler for the serve subcommand:
+//
+//	GET  /templates       lists the templates as JSON
+//	POST /templates/{id}  generates a template from posted variables,
+//	                      responding with a zip archive
+//
+// With trusted keys given, a template is generated only if it is signed by
+// one of them.
+func newServeHandler(catalogDir string, trustedKeys []string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /templates", func(w http.ResponseWriter, r *http.Request) {
+		entries, err := loadCatalog(catalogDir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	})
+
+	mux.HandleFunc("POST /templates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		dir, ok := catalogTemplate(catalogDir, id)
+		if !ok {
+			http.Error(w, fmt.Sprintf("template not found: %s", id), http.StatusNotFound)
+			return
+		}
+
+		if err := verifyTemplate(dir, trustedKeys); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		var req generateRequest
+		body := http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		if err := json.NewDecoder(body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		archive, err := generateZip(dir, req.Variables)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".zip"))
+		w.Write(archive)
+	})
+
+	return mux
+}
+
+// loadCatalog describes every template directory in catalogDir, sorted by ID
+func loadCatalog(catalogDir string) ([]catalogEntry, error) {
+	dirs, err := os.ReadDir(catalogDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	entries := []catalogEntry{}
+	for _, d := range dirs {
+		if !d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			continue
+		}
+		cfg := config.DefaultConfig()
+		cfg.TemplateDir = filepath.Join(catalogDir, d.Name())
+		info, err := loadTemplateInfo(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", d.Name(), err)
+		}
+		entries = append(entries, catalogEntry{ID: d.Name(), templateInfo: *info})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// catalogTemplate returns the directory of the template with the given ID.
+// Only direct subdirectories of the catalog are served, so an ID can never
+// name a path outside it.
+func catalogTemplate(catalogDir, id string) (string, bool) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", false
+	}
+	dir := filepath.Join(catalogDir, id)
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+// generateZip renders a template in memory and returns the files as a zip
+// archive. Nothing is written on the server, and a template that would run
+// commands or place files outside the project is refused.
+func generateZip(templateDir string, variables map[string]string) ([]byte, error) {
+	m, err := manifest.Load(templateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	if err := checkServable(m); err != nil {
+		return nil, err
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.TemplateDir = templateDir
+	cfg.Variables = config.MergeVariables(variables)
+
+	gen := generator.NewGenerator(cfg)
+	files, err := gen.Render()
+	if gen.Report().HasErrors() {
+		return nil, fmt.Errorf("failed to generate project:\n%s", gen.Report().Summary())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate project: %w", err)
+	}
+	for _, file := range files {
+		if !filepath.IsLocal(file.Path) {
+			return nil, fmt.Errorf("template maps %s outside the project, which serve does not allow", file.Path)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeZip(&buf, files); err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// checkServable refuses templates that run commands during generation:
+// variables computed by a command and validate commands
+func checkServable(m *manifest.Manifest) error {
+	if m == nil {
+		return nil
+	}
+	if len(m.Validate) > 0 {
+		return fmt.Errorf("template declares validate commands, which serve does not run")
+	}
+	for _, v := range m.Variables {
+		if v.Command != "" {
+			return fmt.Errorf("variable %s is computed by a command, which serve does not run", v.Name)
+		}
+	}
+	return nil
+}
+
+// writeZip writes rendered files to w as a zip archive
+func writeZip(w io.Writer, files []generator.RenderedFile) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	for _, file := range files {
+		header := &zip.FileHeader{
+			Name:     filepath.ToSlash(file.Path),
+			Method:   zip.Deflate,
+			Modified: now,
+		}
+		header.SetMode(file.Mode.Perm())
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.Content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeGenerate(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   map[string]string
+		body       string
+		wantStatus int
+		wantFiles  map[string]string
+		wantErr    string
+	}{
+		{
+			name: "renders a zip",
+			template: map[string]string{
+				"README.md":             "# {{name}}\n",
+				"__name__/main.go":      "package main\n",
+				"stencil.manifest.json": `{"variables": [{"name": "name", "default": "app"}]}`,
+			},
+			body:       `{"variables": {"name": "demo"}}`,
+			wantStatus: http.StatusOK,
+			wantFiles: map[string]string{
+				"README.md":    "# demo\n",
+				"demo/main.go": "package main\n",
+			},
+		},
+		{
+			name: "validate commands refused",
+			template: map[string]string{
+				"a.txt":                 "x",
+				"stencil.manifest.json": `{"validate": ["touch pwned"]}`,
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErr:    "validate commands",
+		},
+		{
+			name: "variable commands refused",
+			template: map[string]string{
+				"a.txt":                 "{{user}}",
+				"stencil.manifest.json": `{"variables": [{"name": "user", "command": "whoami"}]}`,
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErr:    "computed by a command",
+		},
+		{
+			name: "output map escape refused",
+			template: map[string]string{
+				"shared/lib.go":         "package lib\n",
+				"stencil.manifest.json": `{"outputMap": {"shared/**": "../shared"}}`,
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErr:    "outside the project",
+		},
+		{
+			name: "invalid value refused",
+			template: map[string]string{
+				"a.go":                  "package {{pkg}}\n",
+				"stencil.manifest.json": `{"variables": [{"name": "pkg", "type": "identifier"}]}`,
+			},
+			body:       `{"variables": {"pkg": "not valid"}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErr:    "invalid value for variable pkg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := t.TempDir()
+			for rel, content := range tt.template {
+				path := filepath.Join(catalog, "tpl", filepath.FromSlash(rel))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Commands would run in the working directory
+			t.Chdir(t.TempDir())
+
+			req := httptest.NewRequest("POST", "/templates/tpl", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			newServeHandler(catalog, nil).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(rec.Body.String(), tt.wantErr) {
+					t.Errorf("body = %q, want it to contain %q", rec.Body, tt.wantErr)
+				}
+				if entries, _ := os.ReadDir("."); len(entries) != 0 {
+					t.Errorf("serve wrote %d entries to the working directory", len(entries))
+				}
+				return
+			}
+
+			got := unzip(t, rec.Body.Bytes())
+			if !maps.Equal(got, tt.wantFiles) {
+				t.Errorf("archive = %q, want %q", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+// unzip returns the files of a zip archive by name
+func unzip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+	"github.com/linxux/stencil/internal/source"
+)
+
+// runSign implements the sign subcommand, which writes a template's
+// signature file with an Ed25519 private key
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+
+	var tmplDir, keyFile string
+	fs.StringVar(&tmplDir, "t", "./template", "Template directory")
+	fs.StringVar(&tmplDir, "template", "./template", "Template directory")
+	fs.StringVar(&keyFile, "key", "", "PEM file holding the Ed25519 private key")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if keyFile == "" {
+		return fmt.Errorf("--key is required")
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	key, err := source.ParsePrivateKey(data)
+	if err != nil {
+		return fmt.Errorf("invalid key %s: %w", keyFile, err)
+	}
+	if err := source.Sign(tmplDir, key); err != nil {
+		return fmt.Errorf("failed to sign template: %w", err)
+	}
+
+	fmt.Printf("Signed %s; wrote %s\n", tmplDir, manifest.SignatureFileName)
+	return nil
+}
+
+// keyList is a repeatable --trusted-key flag
+type keyList []string
+
+func (k *keyList) String() string { return strings.Join(*k, ",") }
+
+func (k *keyList) Set(s string) error {
+	*k = append(*k, s)
+	return nil
+}
+
+// verifyTemplate checks the template in dir against its signature when
+// trusted keys are given, as generation does with trustedKeys set
+func verifyTemplate(dir string, trustedKeys []string) error {
+	if len(trustedKeys) == 0 {
+		return nil
+	}
+	if err := source.Verify(dir, trustedKeys); err != nil {
+		return fmt.Errorf("failed to verify template signature: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/diff"
+	"github.com/linxux/stencil/internal/generator"
+)
+
+// Labels of the two sides of an upgrade conflict
+const (
+	upgradeOursLabel   = "current"
+	upgradeTheirsLabel = "template"
+)
+
+// upgradeResult counts what an upgrade did to a project
+type upgradeResult struct {
+	updated, added, removed, conflicts int
+}
+
+// runUpgrade implements the upgrade subcommand, which moves a generated
+// project to a newer version of its template. The old and new templates are
+// both rendered with the project's recorded values, and the changes between
+// them are merged into the project's current files, keeping local edits.
+func runUpgrade(args []string) error {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+
+	var fromDir, tmplDir, outDir, valuesFile, vars string
+	var dryRun bool
+	var trustedKeys keyList
+	fs.StringVar(&fromDir, "from", "", "Template directory the project was generated from")
+	fs.StringVar(&tmplDir, "t", "", "New template directory")
+	fs.StringVar(&tmplDir, "template", "", "New template directory")
+	fs.StringVar(&outDir, "o", ".", "Generated project directory")
+	fs.StringVar(&outDir, "output", ".", "Generated project directory")
+	fs.StringVar(&valuesFile, "var-file", "", "Values file (default: "+generator.ValuesFileName+" in the project)")
+	fs.StringVar(&vars, "v", "", "Variables overriding the recorded values")
+	fs.StringVar(&vars, "vars", "", "Variables overriding the recorded values")
+	fs.BoolVar(&dryRun, "dry-run", false, "Show what would change without writing")
+	fs.Var(&trustedKeys, "trusted-key", "Public key both templates must be signed with (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fromDir == "" {
+		return fmt.Errorf("--from is required")
+	}
+	if tmplDir == "" {
+		return fmt.Errorf("-t is required")
+	}
+	if valuesFile == "" {
+		valuesFile = filepath.Join(outDir, generator.ValuesFileName)
+	}
+
+	values, err := config.LoadValues(valuesFile)
+	if err != nil {
+		return fmt.Errorf("failed to load values file '%s': %w", valuesFile, err)
+	}
+	values = config.MergeVariables(values, parseKeyValues(vars))
+
+	oldFiles, err := renderTemplate(fromDir, values, trustedKeys)
+	if err != nil {
+		return fmt.Errorf("failed to render old template: %w", err)
+	}
+	newFiles, err := renderTemplate(tmplDir, values, trustedKeys)
+	if err != nil {
+		return fmt.Errorf("failed to render new template: %w", err)
+	}
+
+	result, err := upgradeProject(outDir, renderedByPath(oldFiles), renderedByPath(newFiles), dryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nUpgrade: %d updated, %d added, %d removed, %d conflict(s)\n",
+		result.updated, result.added, result.removed, result.conflicts)
+	if result.conflicts > 0 {
+		return fmt.Errorf("upgrade finished with %d conflict(s); resolve the %s markers and review the files",
+			result.conflicts, diff.ConflictStart)
+	}
+	return nil
+}
+
+// renderTemplate renders a template in memory with the given values,
+// verifying its signature first when trusted keys are given
+func renderTemplate(templateDir string, values map[string]string, trustedKeys []string) ([]generator.RenderedFile, error) {
+	if err := verifyTemplate(templateDir, trustedKeys); err != nil {
+		return nil, err
+	}
+	cfg := config.DefaultConfig()
+	cfg.TemplateDir = templateDir
+	cfg.Variables = config.MergeVariables(cfg.Variables, values)
+	return generator.NewGenerator(cfg).Render()
+}
+
+// upgradeProject applies the changes from the old render to the new one to
+// the project in outDir. A file still matching the old render is replaced
+// or removed outright; a locally edited file is merged line by line, with
+// conflicting changes marked in place.
+func upgradeProject(outDir string, oldFiles, newFiles map[string]generator.RenderedFile, dryRun bool) (upgradeResult, error) {
+	var result upgradeResult
+
+	paths := make(map[string]bool)
+	for path := range oldFiles {
+		paths[path] = true
+	}
+	for path := range newFiles {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		oldFile, inOld := oldFiles[path]
+		newFile, inNew := newFiles[path]
+		target := filepath.Join(outDir, filepath.FromSlash(path))
+
+		current, err := os.ReadFile(target)
+		exists := err == nil
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		switch {
+		case !inNew:
+			// Removed from the template: delete it unless edited locally
+			if !exists {
+				continue
+			}
+			if !bytes.Equal(current, oldFile.Content) {
+				fmt.Printf("Kept %s: removed from the template but modified locally\n", path)
+				continue
+			}
+			fmt.Printf("Removed %s\n", path)
+			result.removed++
+			if !dryRun {
+				if err := os.Remove(target); err != nil {
+					return result, fmt.Errorf("failed to remove %s: %w", path, err)
+				}
+			}
+
+		case !exists:
+			// A file the user deleted stays deleted
+			if inOld {
+				continue
+			}
+			fmt.Printf("Added %s\n", path)
+			result.added++
+			if !dryRun {
+				if err := writeUpgraded(target, newFile.Content, newFile.Mode); err != nil {
+					return result, err
+				}
+			}
+
+		default:
+			if bytes.Equal(current, newFile.Content) || (inOld && bytes.Equal(oldFile.Content, newFile.Content)) {
+				continue
+			}
+
+			merged, conflicts := newFile.Content, 0
+			if !inOld || !bytes.Equal(current, oldFile.Content) {
+				if newFile.Binary || oldFile.Binary {
+					fmt.Printf("Conflict in %s: binary file changed both locally and in the template\n", path)
+					result.conflicts++
+					continue
+				}
+				merged, conflicts = diff.Merge3(oldFile.Content, current, newFile.Content,
+					upgradeOursLabel, upgradeTheirsLabel)
+			}
+
+			if conflicts > 0 {
+				fmt.Printf("Conflict in %s: %d conflicting change(s)\n", path, conflicts)
+				result.conflicts++
+			} else {
+				fmt.Printf("Updated %s\n", path)
+				result.updated++
+			}
+			if !dryRun {
+				info, err := os.Stat(target)
+				if err != nil {
+					return result, err
+				}
+				if err := writeUpgraded(target, merged, info.Mode()); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// writeUpgraded writes an upgraded project file, creating its directory.
+// The file is replaced atomically, so an interrupted upgrade never leaves
+// it truncated.
+func writeUpgraded(target string, content []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	err := generator.WriteFileAtomic(target, mode, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
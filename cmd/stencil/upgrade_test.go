@@ -0,0 +1,177 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteUpgraded(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // content of the target before the write, if any
+		dir      bool   // the target is a directory, so the write fails
+		mode     os.FileMode
+		wantErr  bool
+	}{
+		{name: "new file in a new directory", mode: 0644},
+		{name: "replaces a file", existing: "old content that is longer\n", mode: 0644},
+		{name: "executable", existing: "#!/bin/sh\n", mode: 0755},
+		{name: "target is a directory", dir: true, mode: 0644, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "sub")
+			target := filepath.Join(dir, "file.txt")
+			if tt.existing != "" || tt.dir {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.existing != "" {
+				if err := os.WriteFile(target, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.dir {
+				if err := os.Mkdir(target, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := writeUpgraded(target, []byte("new\n"), tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("writeUpgraded succeeded")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("writeUpgraded failed: %v", err)
+				}
+				content, err := os.ReadFile(target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != "new\n" {
+					t.Errorf("content = %q, want %q", content, "new\n")
+				}
+				info, err := os.Stat(target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != tt.mode {
+					t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.mode)
+				}
+			}
+
+			// The temp file is renamed or removed either way
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory holds %d entries, want only the target", len(entries))
+			}
+		})
+	}
+}
+
+func TestUpgradeProject(t *testing.T) {
+	oldTemplate := map[string]string{
+		"main.go":    "package main\n\nfunc main() {\n\tprintln(\"{{name}}\")\n}\n",
+		"README.md":  "# {{name}}\n",
+		"legacy.txt": "old\n",
+	}
+	newTemplate := map[string]string{
+		"main.go":   "package main\n\nfunc main() {\n\tprintln(\"{{name}} v2\")\n}\n",
+		"README.md": "# {{name}}\n",
+		"NEW.md":    "new in v2\n",
+	}
+
+	tests := []struct {
+		name    string
+		project map[string]string // project files, as generated then edited
+		dryRun  bool
+		want    upgradeResult
+		wantOut map[string]string
+	}{
+		{
+			name: "local edit survives",
+			project: map[string]string{
+				"main.go":    "// Copyright me\npackage main\n\nfunc main() {\n\tprintln(\"app\")\n}\n",
+				"README.md":  "# app\n",
+				"legacy.txt": "old\n",
+			},
+			want: upgradeResult{updated: 1, added: 1, removed: 1},
+			wantOut: map[string]string{
+				"main.go":   "// Copyright me\npackage main\n\nfunc main() {\n\tprintln(\"app v2\")\n}\n",
+				"README.md": "# app\n",
+				"NEW.md":    "new in v2\n",
+			},
+		},
+		{
+			name: "conflicting edit",
+			project: map[string]string{
+				"main.go":    "package main\n\nfunc main() {\n\tprintln(\"app, edited\")\n}\n",
+				"README.md":  "# app\n",
+				"legacy.txt": "old, edited\n",
+			},
+			want: upgradeResult{added: 1, conflicts: 1},
+			wantOut: map[string]string{
+				"main.go": "package main\n\nfunc main() {\n" +
+					"<<<<<<< current\n\tprintln(\"app, edited\")\n=======\n\tprintln(\"app v2\")\n>>>>>>> template\n}\n",
+				"README.md":  "# app\n",
+				"legacy.txt": "old, edited\n",
+				"NEW.md":     "new in v2\n",
+			},
+		},
+		{
+			name: "dry run writes nothing",
+			project: map[string]string{
+				"main.go":    "package main\n\nfunc main() {\n\tprintln(\"app\")\n}\n",
+				"README.md":  "# app\n",
+				"legacy.txt": "old\n",
+			},
+			dryRun: true,
+			want:   upgradeResult{updated: 1, added: 1, removed: 1},
+			wantOut: map[string]string{
+				"main.go":    "package main\n\nfunc main() {\n\tprintln(\"app\")\n}\n",
+				"README.md":  "# app\n",
+				"legacy.txt": "old\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			oldDir, newDir, outDir := filepath.Join(dir, "v1"), filepath.Join(dir, "v2"), filepath.Join(dir, "project")
+			writeTree(t, oldDir, oldTemplate)
+			writeTree(t, newDir, newTemplate)
+			writeTree(t, outDir, tt.project)
+
+			values := map[string]string{"name": "app"}
+			oldFiles, err := renderTemplate(oldDir, values, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newFiles, err := renderTemplate(newDir, values, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := upgradeProject(outDir, renderedByPath(oldFiles), renderedByPath(newFiles), tt.dryRun)
+			if err != nil {
+				t.Fatalf("upgradeProject failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+			if files := readTree(t, outDir); !maps.Equal(files, tt.wantOut) {
+				t.Errorf("project = %q, want %q", files, tt.wantOut)
+			}
+		})
+	}
+}
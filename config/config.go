@@ -2,8 +2,31 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+)
+
+// FileValuePrefix marks a variable value that is read from a file
+const FileValuePrefix = "@"
+
+// DefaultFetchRetries is the default number of retries of a failed remote
+// template request
+const DefaultFetchRetries = 2
+
+// Key normalization modes of Config.KeyNormalization
+const (
+	KeyNormalizationNone  = "none"
+	KeyNormalizationLower = "lower"
+)
+
+// Modes of Config.OnEmptyValue
+const (
+	EmptyValueInsert          = "insert"
+	EmptyValueKeepPlaceholder = "keep-placeholder"
+	EmptyValueError           = "error"
+	EmptyValueSkipLine        = "skip-line"
 )
 
 // FormatOptions controls which variable formats are enabled
@@ -16,6 +39,16 @@ type FormatOptions struct {
 	EnableUnderscores bool `json:"enableUnderscores"`
 	// EnablePercent enables %var% format
 	EnablePercent bool `json:"enablePercent"`
+
+	// CustomOpen and CustomClose define an additional delimiter pair, e.g.
+	// "[[" and "]]". The custom format is enabled when both are set.
+	CustomOpen  string `json:"customOpen,omitempty"`
+	CustomClose string `json:"customClose,omitempty"`
+}
+
+// CustomEnabled reports whether a custom delimiter pair is configured
+func (f FormatOptions) CustomEnabled() bool {
+	return f.CustomOpen != "" && f.CustomClose != ""
 }
 
 // Config represents the generator configuration
@@ -35,28 +68,241 @@ type Config struct {
 	// DryRun shows what would be generated without creating files
 	DryRun bool `json:"dryRun"`
 
+	// Trial generates into a temporary copy of the output directory and
+	// discards it, surfacing the errors a real run would hit without
+	// touching the output
+	Trial bool `json:"trial"`
+
+	// TempDir is where temporary directories, such as the copy used by
+	// Trial, are created. Empty uses the system default, os.TempDir().
+	TempDir string `json:"tempDir,omitempty"`
+
+	// FetchRetries is how many times a request for a remote template is
+	// retried after a network error or a temporary server error, with
+	// exponential backoff. Permanent errors, such as not found or
+	// unauthorized, are not retried.
+	FetchRetries int `json:"fetchRetries"`
+
+	// SkipUnsafeArchiveLinks drops symlinks in a remote template that point
+	// outside it. By default such a link fails the fetch.
+	SkipUnsafeArchiveLinks bool `json:"skipUnsafeArchiveLinks"`
+
+	// TrustedKeys, when set, requires the template to carry a signature
+	// from one of these Ed25519 public keys, given in base64 or as the path
+	// of a PEM file. Generation fails if verification fails.
+	TrustedKeys []string `json:"trustedKeys,omitempty"`
+
 	// SkipConfirm skips confirmation prompt in interactive mode
 	SkipConfirm bool `json:"skipConfirm"`
 
+	// Normalize controls the cleanup applied to variables as they are loaded
+	Normalize VariableNormalization `json:"normalize"`
+
 	// Formats controls which variable formats are enabled
 	Formats FormatOptions `json:"formats"`
+
+	// Concurrency is the number of files processed in parallel; values of 1
+	// or less process files serially
+	Concurrency int `json:"concurrency"`
+
+	// QueueSize is the number of files buffered for the workers; it defaults
+	// to Concurrency
+	QueueSize int `json:"queueSize"`
+
+	// Backup saves an existing output file to "<file>.bak" before
+	// overwriting it with different content
+	Backup bool `json:"backup"`
+
+	// Formatters maps an extension (".go") or glob ("cmd/*.go") to a command
+	// run on each matching generated file, e.g. {".go": "gofmt -w"}
+	Formatters map[string]string `json:"formatters"`
+
+	// ErrorOnUnusedVars fails generation when a supplied variable is used
+	// nowhere in the template, which catches misspelled names
+	ErrorOnUnusedVars bool `json:"errorOnUnusedVars"`
+
+	// StrictFormatters fails generation when a formatter fails instead of
+	// recording a warning
+	StrictFormatters bool `json:"strictFormatters"`
+
+	// PreserveTimestamps gives each generated file the access and
+	// modification times of its template file instead of the current time
+	PreserveTimestamps bool `json:"preserveTimestamps"`
+
+	// PruneOutput removes files in OutputDir not produced by the template
+	PruneOutput bool `json:"pruneOutput"`
+
+	// CleanupOnError removes the files and directories a run created when
+	// it fails, including when a validate command fails. Files that existed
+	// before the run are left as written.
+	CleanupOnError bool `json:"cleanupOnError"`
+
+	// RenderFileValues substitutes variables inside values loaded from files
+	RenderFileValues bool `json:"renderFileValues"`
+
+	// FileValues lists the variables whose values were loaded from files
+	FileValues []string `json:"-"`
+
+	// NormalizeFilenames normalizes generated paths after substitution:
+	// "" or "none" keeps them as-is, "lower" lowercases them
+	NormalizeFilenames string `json:"normalizeFilenames"`
+
+	// StripPrefix removes leading directories, e.g. "root" or "src/app",
+	// from every generated path after substitution. Paths outside the
+	// prefix are generated unchanged.
+	StripPrefix string `json:"stripPrefix,omitempty"`
+
+	// WriteValues records the resolved variable values, except secrets, in
+	// stencil.values.json in the output directory, for reuse with --var-file
+	WriteValues bool `json:"writeValues"`
+
+	// ForceTextReplacement treats every file as text, substituting variables
+	// even in files detected as binary, at the user's risk
+	ForceTextReplacement bool `json:"forceTextReplacement"`
+
+	// AllowedExtensions, when set, restricts the template files that are
+	// read to those with one of these extensions, e.g. ".go" or "md"; other
+	// files are skipped entirely rather than copied. An empty entry allows
+	// files without an extension.
+	AllowedExtensions []string `json:"allowedExtensions,omitempty"`
+
+	// NoScanGlobs lists globs of template directories, such as "vendor" or
+	// "node_modules", whose contents are copied as-is: their names and
+	// contents are neither substituted nor scanned for variables. Unlike
+	// ignored paths, they are still generated.
+	NoScanGlobs []string `json:"noScanGlobs,omitempty"`
+
+	// PortablePaths rejects generated file names that are invalid on
+	// Windows, such as "con" or names containing ':'. The check is always
+	// on when running on Windows.
+	PortablePaths bool `json:"portablePaths"`
+
+	// ReplaceInPaths substitutes variables in file and directory names
+	ReplaceInPaths bool `json:"replaceInPaths"`
+
+	// ReplaceInContent substitutes variables in file contents
+	ReplaceInContent bool `json:"replaceInContent"`
+
+	// CaseInsensitiveVars matches placeholder keys case-insensitively
+	CaseInsensitiveVars bool `json:"caseInsensitiveVars"`
+
+	// KeyNormalization controls how placeholder keys are matched against
+	// variable names: "lower" lowercases the key written in the template
+	// before looking it up; "" or "none" matches it as written. Variable
+	// names and values are not changed.
+	KeyNormalization string `json:"keyNormalization,omitempty"`
+
+	// OnEmptyValue controls placeholders whose variable has an empty value:
+	// "insert" or "" substitutes the empty string; "keep-placeholder" leaves
+	// the placeholder as written; "error" fails on the first one in file
+	// contents; "skip-line" substitutes it but drops lines left blank.
+	OnEmptyValue string `json:"onEmptyValue,omitempty"`
+
+	// TrimDelimiterSpaces ignores whitespace inside delimiters, so
+	// "{{ name }}" resolves like "{{name}}"
+	TrimDelimiterSpaces bool `json:"trimDelimiterSpaces"`
+
+	// MaxDepth limits how many levels below the template root the walk
+	// descends; a deeper path is an error. Zero means unlimited.
+	MaxDepth int `json:"maxDepth,omitempty"`
+
+	// FollowSymlinks descends into symlinked directories in the template
+	// instead of skipping them with a warning (default: false)
+	FollowSymlinks bool `json:"followSymlinks"`
+
+	// RequireCleanGit refuses to generate into a git working tree with
+	// uncommitted changes. The check is skipped when git is not installed.
+	RequireCleanGit bool `json:"requireCleanGit"`
+
+	// AllowCommandDefaults allows manifest variables to be computed by
+	// running their declared command
+	AllowCommandDefaults bool `json:"allowCommandDefaults"`
+
+	// SandboxRoot, when set, confines template reads: any template path
+	// whose symlinks resolve outside this directory is rejected
+	SandboxRoot string `json:"sandboxRoot"`
+
+	// ExpandValues substitutes variables referenced inside other variables'
+	// values before generation, e.g. "project_name={{org}}-svc". Values are
+	// literal by default.
+	ExpandValues bool `json:"expandValues"`
+
+	// DirectivePrefixes lists the line comment prefixes, e.g. "//" and "#",
+	// under which conditional directives such as "// stencil:if var" are
+	// recognized in file contents. Directives are disabled when empty.
+	DirectivePrefixes []string `json:"directivePrefixes"`
+
+	// IndentValues indents the lines of a multi-line value after the first
+	// to match the line of the placeholder it replaces, e.g. in YAML blocks
+	IndentValues bool `json:"indentValues"`
+
+	// TypedStructured substitutes a quoted placeholder that is an entire
+	// value in .json, .yaml and .yml files unquoted when its value is a
+	// number, boolean or null, e.g. "{{port}}" becomes 8080
+	TypedStructured bool `json:"typedStructured"`
 }
 
-// LoadConfig loads configuration from a JSON file
+// LoadConfig loads configuration from a JSON file. Comments and trailing
+// commas are allowed, as in JSONC.
 func LoadConfig(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, err
 	}
+	data = StripJSONC(data)
 
-	var cfg Config
+	// Fields missing from the file keep their default values
+	cfg := *DefaultConfig()
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
 
+	// File-backed values are relative to the config file
+	loaded, err := ResolveFileValues(cfg.Variables, filepath.Dir(configPath))
+	if err != nil {
+		return nil, err
+	}
+	cfg.FileValues = loaded
+
+	cfg.Variables, cfg.FileValues, err = cfg.Normalize.NormalizeVariables(cfg.Variables, cfg.FileValues)
+	if err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// ResolveFileValues replaces values of the form "@path" with the contents of
+// the referenced file, resolving relative paths against baseDir. A leading
+// "@@" escapes a literal "@". It returns the keys whose values were loaded.
+func ResolveFileValues(variables map[string]string, baseDir string) ([]string, error) {
+	var loaded []string
+	for key, value := range variables {
+		if !strings.HasPrefix(value, FileValuePrefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(value, FileValuePrefix)
+		if strings.HasPrefix(rest, FileValuePrefix) {
+			variables[key] = rest
+			continue
+		}
+
+		path := rest
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read value of variable '%s': %w", key, err)
+		}
+		variables[key] = string(data)
+		loaded = append(loaded, key)
+	}
+	return loaded, nil
+}
+
 // SaveConfig saves configuration to a JSON file
 func SaveConfig(configPath string, cfg *Config) error {
 	// Ensure directory exists
@@ -88,5 +334,8 @@ func DefaultConfig() *Config {
 			EnableUnderscores:   true,
 			EnablePercent:       true,
 		},
+		ReplaceInPaths:   true,
+		ReplaceInContent: true,
+		FetchRetries:     DefaultFetchRetries,
 	}
 }
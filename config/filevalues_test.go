@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveFileValues(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "snippets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "snippets", "LICENSE.tmpl"), []byte("MIT {{author}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	absolute := filepath.Join(t.TempDir(), "abs.txt")
+	if err := os.WriteFile(absolute, []byte("absolute"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		value      string
+		want       string
+		wantLoaded bool
+		wantErr    string
+	}{
+		{name: "plain value", value: "plain", want: "plain"},
+		{name: "relative path", value: "@./snippets/LICENSE.tmpl", want: "MIT {{author}}\n", wantLoaded: true},
+		{name: "relative without dot", value: "@snippets/LICENSE.tmpl", want: "MIT {{author}}\n", wantLoaded: true},
+		{name: "absolute path", value: "@" + absolute, want: "absolute", wantLoaded: true},
+		{name: "escaped at sign", value: "@@handle", want: "@handle"},
+		{name: "missing file", value: "@missing.txt", wantErr: "failed to read value of variable 'license'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := map[string]string{"license": tt.value}
+			loaded, err := ResolveFileValues(vars, base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveFileValues error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveFileValues failed: %v", err)
+			}
+			if vars["license"] != tt.want {
+				t.Errorf("value = %q, want %q", vars["license"], tt.want)
+			}
+			if got := slices.Contains(loaded, "license"); got != tt.wantLoaded {
+				t.Errorf("loaded = %q, want license listed: %v", loaded, tt.wantLoaded)
+			}
+		})
+	}
+}
+
+// TestLoadConfigFileValues checks that file-backed values are resolved
+// against the config file's directory, not the working directory
+func TestLoadConfigFileValues(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE.tmpl"), []byte("MIT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "stencil.json")
+	if err := os.WriteFile(configPath, []byte(`{"variables": {"license_text": "@./LICENSE.tmpl", "name": "app"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Variables["license_text"]; got != "MIT\n" {
+		t.Errorf("license_text = %q, want %q", got, "MIT\n")
+	}
+	if !slices.Equal(cfg.FileValues, []string{"license_text"}) {
+		t.Errorf("FileValues = %q, want [license_text]", cfg.FileValues)
+	}
+}
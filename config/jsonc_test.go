@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain JSON", in: `{"a": 1}`, want: `{"a": 1}`},
+		{name: "line comment", in: "{\"a\": 1 // one\n}", want: "{\"a\": 1       \n}"},
+		{name: "block comment", in: `{/* x */"a": 1}`, want: `{       "a": 1}`},
+		{name: "multi-line block comment", in: "{/* a\nb */\"a\": 1}", want: "{    \n    \"a\": 1}"},
+		{name: "trailing comma in object", in: `{"a": 1,}`, want: `{"a": 1 }`},
+		{name: "trailing comma in array", in: "[1, 2,\n]", want: "[1, 2 \n]"},
+		{name: "comma before a comment and a bracket", in: "[1, // last\n]", want: "[1         \n]"},
+		{name: "comment markers in strings", in: `{"url": "http://x/*y*/"}`, want: `{"url": "http://x/*y*/"}`},
+		{name: "escaped quote in string", in: `{"a": "\" // not a comment"}`, want: `{"a": "\" // not a comment"}`},
+		{name: "comma in string", in: `{"a": ",}"}`, want: `{"a": ",}"}`},
+		{name: "unterminated block comment", in: `{"a": 1} /* x`, want: `{"a": 1}     `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(StripJSONC([]byte(tt.in)))
+			if got != tt.want {
+				t.Errorf("StripJSONC(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if len(got) != len(tt.in) {
+				t.Errorf("StripJSONC changed the length from %d to %d", len(tt.in), len(got))
+			}
+		})
+	}
+}
+
+func TestLoadConfigJSONC(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+		// wantAt is the text the syntax error's offset must point just past
+		wantAt string
+	}{
+		{
+			name: "comments and trailing commas",
+			content: `{
+	// Where the template lives
+	"templateDir": "./tpl",
+	/* variables used by every project */
+	"variables": {
+		"author": "Jane", // the default author
+	},
+	"noScanGlobs": ["vendor/**",],
+}`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.TemplateDir != "./tpl" || cfg.Variables["author"] != "Jane" || len(cfg.NoScanGlobs) != 1 {
+					t.Errorf("config = %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "missing fields keep defaults",
+			content: `{"dryRun": true}`,
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.DryRun || cfg.OutputDir != DefaultConfig().OutputDir || !cfg.Formats.EnableBraces {
+					t.Errorf("config = %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "error position matches the original",
+			content: "{\n  // comment\n  \"dryRun\": yes\n}",
+			wantErr: "invalid character 'y'",
+			wantAt:  ": y",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stencil.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want one containing %q", err, tt.wantErr)
+				}
+				var syntaxErr *json.SyntaxError
+				if tt.wantAt != "" && errors.As(err, &syntaxErr) {
+					if want := int64(strings.Index(tt.content, tt.wantAt) + len(tt.wantAt)); syntaxErr.Offset != want {
+						t.Errorf("error offset = %d, want %d", syntaxErr.Offset, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
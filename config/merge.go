@@ -0,0 +1,36 @@
+package config
+
+// VariableLayer is one source of variables for MergeVariablesWithSources,
+// named by Source, e.g. "config file stencil.json" or "flag -v"
+type VariableLayer struct {
+	Source    string
+	Variables map[string]string
+}
+
+// MergeVariables merges layers of variables into a new map, later layers
+// overriding earlier ones. An empty value counts as set: it overrides a
+// non-empty value from an earlier layer, so a later layer can clear a
+// variable. Nil layers are skipped, and the layers are not modified.
+func MergeVariables(layers ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, layer := range layers {
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// MergeVariablesWithSources merges layers like MergeVariables and also
+// returns, for each variable, the Source of the layer its value came from
+func MergeVariablesWithSources(layers ...VariableLayer) (map[string]string, map[string]string) {
+	merged := make(map[string]string)
+	sources := make(map[string]string)
+	for _, layer := range layers {
+		for key, value := range layer.Variables {
+			merged[key] = value
+			sources[key] = layer.Source
+		}
+	}
+	return merged, sources
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestMergeVariables(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []map[string]string
+		want   map[string]string
+	}{
+		{name: "no layers", want: map[string]string{}},
+		{name: "nil layer", layers: []map[string]string{nil, {"a": "1"}}, want: map[string]string{"a": "1"}},
+		{
+			name:   "later layer wins",
+			layers: []map[string]string{{"a": "config", "b": "config"}, {"a": "file"}, {"a": "flag"}},
+			want:   map[string]string{"a": "flag", "b": "config"},
+		},
+		{
+			name:   "empty value overrides",
+			layers: []map[string]string{{"a": "config"}, {"a": ""}},
+			want:   map[string]string{"a": ""},
+		},
+		{
+			name:   "disjoint layers",
+			layers: []map[string]string{{"a": "1"}, {"b": "2"}},
+			want:   map[string]string{"a": "1", "b": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before []map[string]string
+			for _, layer := range tt.layers {
+				before = append(before, maps.Clone(layer))
+			}
+
+			got := MergeVariables(tt.layers...)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("MergeVariables = %q, want %q", got, tt.want)
+			}
+			for i, layer := range tt.layers {
+				if !maps.Equal(layer, before[i]) {
+					t.Errorf("layer %d was modified: %q", i, layer)
+				}
+			}
+
+			// The result is a new map
+			got["new"] = "x"
+			for i, layer := range tt.layers {
+				if _, ok := layer["new"]; ok {
+					t.Errorf("layer %d shares the result map", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeVariablesWithSources(t *testing.T) {
+	merged, sources := MergeVariablesWithSources(
+		VariableLayer{Variables: map[string]string{"a": "1", "b": "1"}},
+		VariableLayer{Source: "flag --var-file", Variables: map[string]string{"b": "2", "c": "2"}},
+		VariableLayer{Source: "flag -v", Variables: map[string]string{"c": ""}},
+	)
+	if want := map[string]string{"a": "1", "b": "2", "c": ""}; !maps.Equal(merged, want) {
+		t.Errorf("merged = %q, want %q", merged, want)
+	}
+	if want := map[string]string{"a": "", "b": "flag --var-file", "c": "flag -v"}; !maps.Equal(sources, want) {
+		t.Errorf("sources = %q, want %q", sources, want)
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VariableNormalization controls the cleanup applied to variables as they
+// are loaded. Everything is off by default so values are used verbatim.
+type VariableNormalization struct {
+	// TrimSpace trims surrounding whitespace from keys and values. Values
+	// loaded from files are never trimmed.
+	TrimSpace bool `json:"trimSpace"`
+
+	// LowercaseKeys lowercases variable names
+	LowercaseKeys bool `json:"lowercaseKeys"`
+
+	// Transforms maps a variable name to a transform applied to its value:
+	// "lower", "upper", "trim" or "slug", which may name its separator as
+	// in "slug:_"
+	Transforms map[string]string `json:"transforms"`
+}
+
+// enabled reports whether any normalization is configured
+func (n VariableNormalization) enabled() bool {
+	return n.TrimSpace || n.LowercaseKeys || len(n.Transforms) > 0
+}
+
+// NormalizeVariables applies the configured normalization to variables,
+// whose keys listed in fileValues were loaded from files, and returns the
+// normalized variables and file-value keys. Each call transforms the values
+// it is given, so callers normalize each source of variables exactly once.
+func (n VariableNormalization) NormalizeVariables(variables map[string]string, fileValues []string) (map[string]string, []string, error) {
+	if !n.enabled() {
+		return variables, fileValues, nil
+	}
+
+	fromFile := make(map[string]bool, len(fileValues))
+	for _, key := range fileValues {
+		fromFile[key] = true
+	}
+
+	result := make(map[string]string, len(variables))
+	var loaded []string
+	origin := make(map[string]string, len(variables))
+	for key, value := range variables {
+		name := n.normalizeKey(key)
+		if other, ok := origin[name]; ok {
+			return nil, nil, fmt.Errorf("variables '%s' and '%s' both normalize to '%s'", other, key, name)
+		}
+		origin[name] = key
+
+		if n.TrimSpace && !fromFile[key] {
+			value = strings.TrimSpace(value)
+		}
+		if transform, ok := n.Transforms[name]; ok {
+			var err error
+			if value, err = ApplyTransform(transform, value); err != nil {
+				return nil, nil, fmt.Errorf("variable '%s': %w", name, err)
+			}
+		}
+
+		result[name] = value
+		if fromFile[key] {
+			loaded = append(loaded, name)
+		}
+	}
+	return result, loaded, nil
+}
+
+// normalizeKey applies key normalization to a variable name
+func (n VariableNormalization) normalizeKey(key string) string {
+	if n.TrimSpace {
+		key = strings.TrimSpace(key)
+	}
+	if n.LowercaseKeys {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+// ApplyTransform applies a named value transform: "lower", "upper", "trim",
+// "slug" or "slug:<separator>"
+func ApplyTransform(transform, value string) (string, error) {
+	switch transform {
+	case "lower":
+		return strings.ToLower(value), nil
+	case "upper":
+		return strings.ToUpper(value), nil
+	case "trim":
+		return strings.TrimSpace(value), nil
+	case "slug":
+		return Slug(value, DefaultSlugSeparator), nil
+	}
+	if separator, ok := strings.CutPrefix(transform, "slug:"); ok {
+		return Slug(value, separator), nil
+	}
+	return "", fmt.Errorf("unknown transform '%s' (expected lower, upper, trim or slug)", transform)
+}
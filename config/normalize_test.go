@@ -0,0 +1,126 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeVariables(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalize  VariableNormalization
+		vars       map[string]string
+		fileValues []string
+		want       map[string]string
+		wantLoaded []string
+		wantErr    string
+	}{
+		{
+			name: "disabled",
+			vars: map[string]string{" Name ": " App "},
+			want: map[string]string{" Name ": " App "},
+		},
+		{
+			name:      "trim keys and values",
+			normalize: VariableNormalization{TrimSpace: true},
+			vars:      map[string]string{" name ": "  app\t", "Owner": " Jane Doe "},
+			want:      map[string]string{"name": "app", "Owner": "Jane Doe"},
+		},
+		{
+			name:      "lowercase keys",
+			normalize: VariableNormalization{LowercaseKeys: true},
+			vars:      map[string]string{"Project_Name": "App"},
+			want:      map[string]string{"project_name": "App"},
+		},
+		{
+			name:      "transform",
+			normalize: VariableNormalization{Transforms: map[string]string{"name": "lower", "slug": "slug:_"}},
+			vars:      map[string]string{"name": "My App", "slug": "My App", "other": "My App"},
+			want:      map[string]string{"name": "my app", "slug": "my_app", "other": "My App"},
+		},
+		{
+			name: "transform applies to the normalized key",
+			normalize: VariableNormalization{
+				TrimSpace:     true,
+				LowercaseKeys: true,
+				Transforms:    map[string]string{"name": "lower"},
+			},
+			vars: map[string]string{" NAME ": " My App "},
+			want: map[string]string{"name": "my app"},
+		},
+		{
+			name:       "file values are not trimmed",
+			normalize:  VariableNormalization{TrimSpace: true, LowercaseKeys: true},
+			vars:       map[string]string{"Token": "  secret\n", "name": " app "},
+			fileValues: []string{"Token"},
+			want:       map[string]string{"token": "  secret\n", "name": "app"},
+			wantLoaded: []string{"token"},
+		},
+		{
+			name:      "keys collide",
+			normalize: VariableNormalization{LowercaseKeys: true},
+			vars:      map[string]string{"Name": "a", "name": "b"},
+			wantErr:   "both normalize to 'name'",
+		},
+		{
+			name:      "unknown transform",
+			normalize: VariableNormalization{Transforms: map[string]string{"name": "reverse"}},
+			vars:      map[string]string{"name": "app"},
+			wantErr:   "variable 'name': unknown transform 'reverse'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, loaded, err := tt.normalize.NormalizeVariables(tt.vars, tt.fileValues)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NormalizeVariables error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeVariables failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("variables = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(loaded, tt.wantLoaded) {
+				t.Errorf("file values = %q, want %q", loaded, tt.wantLoaded)
+			}
+		})
+	}
+}
+
+// TestLoadConfigNormalize checks that LoadConfig normalizes the config's
+// variables once, leaving file-backed values verbatim
+func TestLoadConfigNormalize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token.txt"), []byte(" s3cret \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "stencil.json")
+	data := `{
+		"normalize": {"trimSpace": true, "lowercaseKeys": true, "transforms": {"name": "lower"}},
+		"variables": {" Name ": "  My App ", "Token": "@token.txt", "owner ": "Jane "}
+	}`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := map[string]string{"name": "my app", "token": " s3cret \n", "owner": "Jane"}
+	if !maps.Equal(cfg.Variables, want) {
+		t.Errorf("variables = %q, want %q", cfg.Variables, want)
+	}
+	if !slices.Equal(cfg.FileValues, []string{"token"}) {
+		t.Errorf("file values = %q, want [token]", cfg.FileValues)
+	}
+}
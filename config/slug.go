@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultSlugSeparator joins the words of a slug unless the transform
+// names another separator, as in "slug:_"
+const DefaultSlugSeparator = "-"
+
+// slugLetters transliterates Latin letters with diacritics and ligatures
+// to ASCII. Other non-ASCII characters are dropped from slugs.
+var slugLetters = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ș': "s", 'ß': "ss", 'ť': "t", 'ţ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Slug turns a value into a lowercase slug for file and directory names:
+// "My Café App!" becomes "my-cafe-app" with separator "-". Accented Latin
+// letters are transliterated, other characters outside a-z and 0-9 become
+// separators, and repeated, leading and trailing separators are removed.
+func Slug(value, separator string) string {
+	var b strings.Builder
+	pending := false
+	for _, r := range strings.ToLower(value) {
+		word := ""
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word = string(r)
+		case slugLetters[r] != "":
+			word = slugLetters[r]
+		case unicode.IsMark(r):
+			// Combining accents belong to the previous letter
+			continue
+		}
+		if word == "" {
+			pending = true
+			continue
+		}
+		if pending && b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		pending = false
+		b.WriteString(word)
+	}
+	return b.String()
+}
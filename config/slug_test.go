@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		separator string
+		want      string
+	}{
+		{name: "spaces", value: "My Cool App", separator: "-", want: "my-cool-app"},
+		{name: "repeated spaces", value: "My   Cool \t App", separator: "-", want: "my-cool-app"},
+		{name: "punctuation", value: "Hello, World! (v2.0)", separator: "-", want: "hello-world-v2-0"},
+		{name: "existing separators", value: "my--app__name", separator: "-", want: "my-app-name"},
+		{name: "accented letters", value: "Café Crème Brûlée", separator: "-", want: "cafe-creme-brulee"},
+		{name: "ligatures", value: "Straße Œuvre", separator: "-", want: "strasse-oeuvre"},
+		{name: "combining accents", value: "Cafe\u0301 Noe\u0308l", separator: "-", want: "cafe-noel"},
+		{name: "other scripts dropped", value: "app 日本 x", separator: "-", want: "app-x"},
+		{name: "leading and trailing separators", value: "--My App!--", separator: "-", want: "my-app"},
+		{name: "leading and trailing spaces", value: "  My App  ", separator: "-", want: "my-app"},
+		{name: "custom separator", value: "My Cool App", separator: "_", want: "my_cool_app"},
+		{name: "empty separator", value: "My Cool App", separator: "", want: "mycoolapp"},
+		{name: "nothing left", value: "!!! ???", separator: "-", want: ""},
+		{name: "empty", value: "", separator: "-", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slug(tt.value, tt.separator); got != tt.want {
+				t.Errorf("Slug(%q, %q) = %q, want %q", tt.value, tt.separator, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTransform(t *testing.T) {
+	tests := []struct {
+		transform string
+		value     string
+		want      string
+		wantErr   string
+	}{
+		{transform: "lower", value: "My App", want: "my app"},
+		{transform: "upper", value: "My App", want: "MY APP"},
+		{transform: "trim", value: "  My App \n", want: "My App"},
+		{transform: "slug", value: "My Café App!", want: "my-cafe-app"},
+		{transform: "slug:_", value: "My Café App!", want: "my_cafe_app"},
+		{transform: "slug:.", value: "My App", want: "my.app"},
+		{transform: "title", value: "my app", wantErr: "unknown transform 'title'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transform, func(t *testing.T) {
+			got, err := ApplyTransform(tt.transform, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyTransform error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyTransform failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyTransform(%q, %q) = %q, want %q", tt.transform, tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Validate checks a configuration built programmatically or loaded from a
+// file: required directories, enumerated settings and glob syntax. It
+// returns every problem found, joined into one error, or nil.
+func Validate(cfg *Config) error {
+	if cfg == nil {
+		return errors.New("config is nil")
+	}
+
+	var errs []error
+	if cfg.TemplateDir == "" {
+		errs = append(errs, errors.New("templateDir is required"))
+	}
+	if cfg.OutputDir == "" {
+		errs = append(errs, errors.New("outputDir is required"))
+	}
+
+	switch cfg.NormalizeFilenames {
+	case "", "none", "lower":
+	default:
+		errs = append(errs, fmt.Errorf("normalizeFilenames: unknown normalization %q (expected none or lower)", cfg.NormalizeFilenames))
+	}
+
+	switch cfg.KeyNormalization {
+	case "", KeyNormalizationNone, KeyNormalizationLower:
+	default:
+		errs = append(errs, fmt.Errorf("keyNormalization: unknown mode %q (expected none or lower)", cfg.KeyNormalization))
+	}
+
+	switch cfg.OnEmptyValue {
+	case "", EmptyValueInsert, EmptyValueKeepPlaceholder, EmptyValueError, EmptyValueSkipLine:
+	default:
+		errs = append(errs, fmt.Errorf("onEmptyValue: unknown mode %q (expected insert, keep-placeholder, error or skip-line)", cfg.OnEmptyValue))
+	}
+
+	if cfg.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("concurrency cannot be negative: %d", cfg.Concurrency))
+	}
+	if cfg.QueueSize < 0 {
+		errs = append(errs, fmt.Errorf("queueSize cannot be negative: %d", cfg.QueueSize))
+	}
+	if prefix := filepath.ToSlash(cfg.StripPrefix); strings.HasPrefix(prefix, "/") || filepath.IsAbs(cfg.StripPrefix) ||
+		slices.Contains(strings.Split(prefix, "/"), "..") {
+		errs = append(errs, fmt.Errorf("stripPrefix must be a relative path without '..': %q", cfg.StripPrefix))
+	}
+
+	if cfg.FetchRetries < 0 {
+		errs = append(errs, fmt.Errorf("fetchRetries cannot be negative: %d", cfg.FetchRetries))
+	}
+	if cfg.MaxDepth < 0 {
+		errs = append(errs, fmt.Errorf("maxDepth cannot be negative: %d", cfg.MaxDepth))
+	}
+
+	if (cfg.Formats.CustomOpen == "") != (cfg.Formats.CustomClose == "") {
+		errs = append(errs, errors.New("formats: customOpen and customClose must be set together"))
+	}
+
+	for _, name := range sortedKeys(cfg.Normalize.Transforms) {
+		if _, err := ApplyTransform(cfg.Normalize.Transforms[name], ""); err != nil {
+			errs = append(errs, fmt.Errorf("normalize.transforms[%s]: %w", name, err))
+		}
+	}
+
+	for _, pattern := range sortedKeys(cfg.Formatters) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("formatters: invalid pattern %q: %w", pattern, err))
+		}
+		if strings.TrimSpace(cfg.Formatters[pattern]) == "" {
+			errs = append(errs, fmt.Errorf("formatters[%s]: command is empty", pattern))
+		}
+	}
+
+	for _, pattern := range cfg.NoScanGlobs {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("noScanGlobs: invalid pattern %q: %w", pattern, err))
+		}
+	}
+
+	for _, prefix := range cfg.DirectivePrefixes {
+		if strings.TrimSpace(prefix) == "" {
+			errs = append(errs, errors.New("directivePrefixes: a prefix cannot be empty"))
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortedKeys returns the keys of m in sorted order, so errors are reported
+// in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
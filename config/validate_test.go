@@ -0,0 +1,129 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *Config)
+		wantErr []string
+	}{
+		{name: "defaults", change: func(cfg *Config) {}},
+		{
+			name: "valid settings",
+			change: func(cfg *Config) {
+				cfg.NormalizeFilenames = "lower"
+				cfg.KeyNormalization = KeyNormalizationLower
+				cfg.OnEmptyValue = EmptyValueSkipLine
+				cfg.StripPrefix = "src/app"
+				cfg.Formats.CustomOpen, cfg.Formats.CustomClose = "[[", "]]"
+				cfg.Normalize.Transforms = map[string]string{"name": "slug:_"}
+				cfg.Formatters = map[string]string{".go": "gofmt -w"}
+				cfg.NoScanGlobs = []string{"vendor/**"}
+				cfg.DirectivePrefixes = []string{"//", "#"}
+			},
+		},
+		{
+			name: "missing directories",
+			change: func(cfg *Config) {
+				cfg.TemplateDir, cfg.OutputDir = "", ""
+			},
+			wantErr: []string{"templateDir is required", "outputDir is required"},
+		},
+		{
+			name: "unknown modes",
+			change: func(cfg *Config) {
+				cfg.NormalizeFilenames = "upper"
+				cfg.KeyNormalization = "upper"
+				cfg.OnEmptyValue = "drop"
+			},
+			wantErr: []string{
+				`normalizeFilenames: unknown normalization "upper"`,
+				`keyNormalization: unknown mode "upper"`,
+				`onEmptyValue: unknown mode "drop"`,
+			},
+		},
+		{
+			name: "negative numbers",
+			change: func(cfg *Config) {
+				cfg.Concurrency, cfg.QueueSize, cfg.FetchRetries, cfg.MaxDepth = -1, -1, -1, -1
+			},
+			wantErr: []string{
+				"concurrency cannot be negative",
+				"queueSize cannot be negative",
+				"fetchRetries cannot be negative",
+				"maxDepth cannot be negative",
+			},
+		},
+		{
+			name:    "absolute strip prefix",
+			change:  func(cfg *Config) { cfg.StripPrefix = "/src" },
+			wantErr: []string{"stripPrefix must be a relative path"},
+		},
+		{
+			name:    "strip prefix leaving the template",
+			change:  func(cfg *Config) { cfg.StripPrefix = "src/../.." },
+			wantErr: []string{"stripPrefix must be a relative path"},
+		},
+		{
+			name:    "half a custom delimiter",
+			change:  func(cfg *Config) { cfg.Formats.CustomOpen = "[[" },
+			wantErr: []string{"customOpen and customClose must be set together"},
+		},
+		{
+			name:    "unknown transform",
+			change:  func(cfg *Config) { cfg.Normalize.Transforms = map[string]string{"name": "title"} },
+			wantErr: []string{"normalize.transforms[name]: unknown transform 'title'"},
+		},
+		{
+			name:    "bad formatter",
+			change:  func(cfg *Config) { cfg.Formatters = map[string]string{"[": "gofmt", ".go": " "} },
+			wantErr: []string{`formatters: invalid pattern "["`, "formatters[.go]: command is empty"},
+		},
+		{
+			name:    "bad no-scan glob",
+			change:  func(cfg *Config) { cfg.NoScanGlobs = []string{"vendor/["} },
+			wantErr: []string{`noScanGlobs: invalid pattern "vendor/["`},
+		},
+		{
+			name:    "empty directive prefix",
+			change:  func(cfg *Config) { cfg.DirectivePrefixes = []string{"//", " ", ""} },
+			wantErr: []string{"directivePrefixes: a prefix cannot be empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.change(cfg)
+
+			err := Validate(cfg)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate failed: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate succeeded")
+			}
+			// Every problem is reported, one per line
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.wantErr) {
+				t.Errorf("Validate reported %d problem(s), want %d: %v", len(lines), len(tt.wantErr), err)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error = %v, want one containing %q", err, want)
+				}
+			}
+		})
+	}
+
+	if err := Validate(nil); err == nil {
+		t.Error("Validate(nil) succeeded")
+	}
+}
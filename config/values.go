@@ -0,0 +1,23 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadValues reads variables from a JSON object of string values, such as
+// the stencil.values.json written by WriteValues. Values are used as-is: a
+// leading "@" does not load a file.
+func LoadValues(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid values file: %w", err)
+	}
+	return values, nil
+}
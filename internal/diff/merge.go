@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+)
+
+// Conflict markers written around the two sides of a conflicting change
+const (
+	ConflictStart = "<<<<<<<"
+	ConflictSep   = "======="
+	ConflictEnd   = ">>>>>>>"
+)
+
+// change replaces the base lines [start, end) with lines
+type change struct {
+	start, end int
+	lines      []string
+	ours       bool
+}
+
+// changes returns the edit script turning base into other as a list of
+// changes in base line coordinates
+func changes(base, other []string, ours bool) []change {
+	var result []change
+	i := 0
+	var current *change
+	for _, o := range editScript(base, other) {
+		if o.kind == opEqual {
+			if current != nil {
+				result = append(result, *current)
+				current = nil
+			}
+			i++
+			continue
+		}
+		if current == nil {
+			current = &change{start: i, end: i, ours: ours}
+		}
+		if o.kind == opDelete {
+			i++
+			current.end = i
+		} else {
+			current.lines = append(current.lines, o.line)
+		}
+	}
+	if current != nil {
+		result = append(result, *current)
+	}
+	return result
+}
+
+// Merge3 merges the changes from base to ours and from base to theirs line
+// by line. Changes to separate regions are both applied; overlapping
+// changes that differ are written between conflict markers labeled with
+// oursLabel and theirsLabel. It returns the merged content and the number
+// of conflicts.
+func Merge3(base, ours, theirs []byte, oursLabel, theirsLabel string) ([]byte, int) {
+	baseLines := splitLines(base)
+	all := append(changes(baseLines, splitLines(ours), true), changes(baseLines, splitLines(theirs), false)...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].start < all[j].start })
+
+	var out strings.Builder
+	conflicts := 0
+	pos := 0
+	for i := 0; i < len(all); {
+		// Group changes whose base ranges overlap or touch
+		lo, hi := all[i].start, all[i].end
+		j := i + 1
+		for j < len(all) && all[j].start <= hi {
+			hi = max(hi, all[j].end)
+			j++
+		}
+		group := all[i:j]
+		i = j
+
+		writeLines(&out, baseLines[pos:lo])
+		pos = hi
+
+		oursText, oursChanged := applyChanges(baseLines, lo, hi, group, true)
+		theirsText, theirsChanged := applyChanges(baseLines, lo, hi, group, false)
+		switch {
+		case !theirsChanged || oursText == theirsText:
+			out.WriteString(oursText)
+		case !oursChanged:
+			out.WriteString(theirsText)
+		default:
+			conflicts++
+			out.WriteString(ConflictStart + " " + oursLabel + "\n")
+			writeSide(&out, oursText)
+			out.WriteString(ConflictSep + "\n")
+			writeSide(&out, theirsText)
+			out.WriteString(ConflictEnd + " " + theirsLabel + "\n")
+		}
+	}
+	writeLines(&out, baseLines[pos:])
+	return []byte(out.String()), conflicts
+}
+
+// applyChanges returns the base lines [lo, hi) with one side's changes in
+// group applied, and whether that side changed anything
+func applyChanges(base []string, lo, hi int, group []change, ours bool) (string, bool) {
+	var out strings.Builder
+	pos, changed := lo, false
+	for _, c := range group {
+		if c.ours != ours {
+			continue
+		}
+		writeLines(&out, base[pos:c.start])
+		writeLines(&out, c.lines)
+		pos, changed = c.end, true
+	}
+	writeLines(&out, base[pos:hi])
+	return out.String(), changed
+}
+
+// writeLines writes lines, which keep their terminators
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// writeSide writes one side of a conflict, terminating its last line so
+// the following marker starts a line of its own
+func writeSide(out *strings.Builder, text string) {
+	out.WriteString(text)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		out.WriteByte('\n')
+	}
+}
@@ -0,0 +1,89 @@
+package diff
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		wantConflicts      int
+	}{
+		{
+			name: "unchanged",
+			base: "a\nb\n", ours: "a\nb\n", theirs: "a\nb\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "only ours changed",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "only theirs changed",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\n",
+			want: "a\nb\nC\n",
+		},
+		{
+			name: "separate regions",
+			base: "a\nb\nc\nd\ne\n", ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n",
+			want: "A\nb\nc\nd\nE\n",
+		},
+		{
+			name: "same change on both sides",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nB\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "ours deletes, theirs inserts elsewhere",
+			base: "a\nb\nc\nd\n", ours: "a\nc\nd\n", theirs: "a\nb\nc\nd\ne\n",
+			want: "a\nc\nd\ne\n",
+		},
+		{
+			name: "conflicting change",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nX\nc\n",
+			want:          "a\n<<<<<<< ours\nB\n=======\nX\n>>>>>>> theirs\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name: "conflicting insertions at the end",
+			base: "a\n", ours: "a\nb\n", theirs: "a\nc\n",
+			want:          "a\n<<<<<<< ours\nb\n=======\nc\n>>>>>>> theirs\n",
+			wantConflicts: 1,
+		},
+		{
+			name: "two conflicts",
+			base: "a\nb\nc\nd\ne\n", ours: "A\nb\nc\nd\nE\n", theirs: "1\nb\nc\nd\n5\n",
+			want: "<<<<<<< ours\nA\n=======\n1\n>>>>>>> theirs\nb\nc\nd\n" +
+				"<<<<<<< ours\nE\n=======\n5\n>>>>>>> theirs\n",
+			wantConflicts: 2,
+		},
+		{
+			name: "conflict without trailing newline",
+			base: "a", ours: "b", theirs: "c",
+			want:          "<<<<<<< ours\nb\n=======\nc\n>>>>>>> theirs\n",
+			wantConflicts: 1,
+		},
+		{
+			name: "delete against edit",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nB\nc\n",
+			want:          "a\n<<<<<<< ours\n=======\nB\n>>>>>>> theirs\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name: "empty base, same addition",
+			base: "", ours: "a\n", theirs: "a\n",
+			want: "a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge3([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs), "ours", "theirs")
+			if string(got) != tt.want || conflicts != tt.wantConflicts {
+				t.Errorf("Merge3 = %q with %d conflict(s), want %q with %d",
+					got, conflicts, tt.want, tt.wantConflicts)
+			}
+		})
+	}
+}
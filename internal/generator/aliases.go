@@ -0,0 +1,73 @@
+package generator
+
+import "github.com/linxux/stencil/internal/replacer"
+
+// setAliases installs the manifest's variable aliases and rebuilds the
+// replacer so aliased placeholders resolve
+func (g *Generator) setAliases(aliases map[string]string) {
+	if len(aliases) == 0 && len(g.aliases) == 0 {
+		return
+	}
+	g.aliases = aliases
+	g.replacer = g.newReplacer(g.cfg.Variables)
+}
+
+// loadAliases reads the variable aliases declared by the template manifest
+func (g *Generator) loadAliases() error {
+	m, err := g.loadManifest()
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		g.setAliases(m.Aliases)
+	}
+	return nil
+}
+
+// canonicalName returns the variable an alias stands for, or name itself
+func (g *Generator) canonicalName(name string) string {
+	if canonical, ok := g.aliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// SetCanonicalizer registers a hook mapping each variable name extracted
+// from the template to the name it is reported under, after whitespace
+// trimming and alias resolution. Names mapping to the same result are
+// reported once; an empty result drops the name. The default is identity.
+func (g *Generator) SetCanonicalizer(fn replacer.Canonicalizer) {
+	g.canonicalizer = fn
+}
+
+// canonicalize maps an extracted placeholder name to the variable it refers
+// to
+func (g *Generator) canonicalize(name string) string {
+	name = g.canonicalName(g.normalizeKey(name))
+	if g.canonicalizer != nil && name != "" {
+		name = g.canonicalizer(name)
+	}
+	return name
+}
+
+// withAliases returns variables with each alias filled from its canonical
+// variable, unless the alias was given a value of its own
+func (g *Generator) withAliases(variables map[string]string) map[string]string {
+	if len(g.aliases) == 0 {
+		return variables
+	}
+
+	result := make(map[string]string, len(variables)+len(g.aliases))
+	for key, value := range variables {
+		result[key] = value
+	}
+	for alias, canonical := range g.aliases {
+		if _, ok := result[alias]; ok {
+			continue
+		}
+		if value, ok := variables[canonical]; ok {
+			result[alias] = value
+		}
+	}
+	return result
+}
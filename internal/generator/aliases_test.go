@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	template := map[string]string{
+		manifestFile: `{"variables": [{"name": "app_name"}],
+			"aliases": {"appName": "app_name", "application": "app_name"}}`,
+		"README.md":           "# {{app_name}}\n",
+		"main.go":             "const Name = \"{{appName}}\"\n",
+		"{{application}}.txt": "{{application}}\n",
+	}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "canonical value fills every alias",
+			vars: map[string]string{"app_name": "demo"},
+			want: map[string]string{
+				"README.md": "# demo\n",
+				"main.go":   "const Name = \"demo\"\n",
+				"demo.txt":  "demo\n",
+			},
+		},
+		{
+			name: "value given under an alias",
+			vars: map[string]string{"appName": "demo"},
+			want: map[string]string{
+				"README.md": "# demo\n",
+				"main.go":   "const Name = \"demo\"\n",
+				"demo.txt":  "demo\n",
+			},
+		},
+		{
+			name:    "conflicting alias value",
+			vars:    map[string]string{"app_name": "demo", "application": "other"},
+			wantErr: `conflicting values for variable "app_name"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			g := newTestGenerator(testConfig(tmpl, out, tt.vars))
+			err := g.Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("extraction asks for the canonical variable", func(t *testing.T) {
+		tmpl := t.TempDir()
+		writeTree(t, tmpl, template)
+
+		g := newTestGenerator(testConfig(tmpl, t.TempDir(), nil))
+		vars, err := g.ExtractVariables()
+		if err != nil {
+			t.Fatalf("ExtractVariables failed: %v", err)
+		}
+		if want := map[string]string{"app_name": ""}; !maps.Equal(vars, want) {
+			t.Errorf("variables = %q, want %q", vars, want)
+		}
+	})
+}
+
+func TestSetCanonicalizer(t *testing.T) {
+	tmpl := t.TempDir()
+	writeTree(t, tmpl, map[string]string{
+		"README.md":         "# {{ProjectName}} {{project-name}}\n",
+		"__projectName__/x": "{{internal}}\n",
+	})
+
+	g := newTestGenerator(testConfig(tmpl, t.TempDir(), nil))
+	vars, err := g.ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	want := map[string]string{"ProjectName": "", "project-name": "", "projectName": "", "internal": ""}
+	if !maps.Equal(vars, want) {
+		t.Errorf("default variables = %q, want %q", vars, want)
+	}
+
+	g.SetCanonicalizer(func(name string) string {
+		if name == "internal" {
+			return ""
+		}
+		return strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	})
+	vars, err = g.ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if want := map[string]string{"projectname": ""}; !maps.Equal(vars, want) {
+		t.Errorf("canonical variables = %q, want %q", vars, want)
+	}
+}
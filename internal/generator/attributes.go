@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/linxux/stencil/config"
+)
+
+// AttributesFileName is the name of the per-path format override file in the
+// template root
+const AttributesFileName = ".stencilattributes"
+
+// attributeRule enables or disables formats for paths matching a pattern
+type attributeRule struct {
+	pattern string
+	formats map[string]bool // format name -> enabled
+}
+
+// loadAttributes reads the template's attributes file, if present. Each line
+// holds a glob pattern followed by format names to enable, or to disable
+// when prefixed with '-', gitattributes style:
+//
+//	*.tf  percent
+//	*.go  -percent -angle-brackets
+//
+// Patterns without a '/' match the base name. When several lines match a
+// file, later lines override earlier ones.
+func (g *Generator) loadAttributes() error {
+	g.attributes = nil
+
+	file, err := g.openTemplateFile(filepath.Join(g.cfg.TemplateDir, AttributesFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		rule := attributeRule{pattern: fields[0], formats: make(map[string]bool)}
+		if _, err := filepath.Match(rule.pattern, ""); err != nil {
+			return fmt.Errorf("%s:%d: invalid pattern %q", AttributesFileName, lineNum, rule.pattern)
+		}
+		for _, attr := range fields[1:] {
+			name := strings.TrimPrefix(attr, "-")
+			switch name {
+			case "braces", "angle-brackets", "underscores", "percent", "custom":
+				rule.formats[name] = !strings.HasPrefix(attr, "-")
+			default:
+				return fmt.Errorf("%s:%d: unknown format %q", AttributesFileName, lineNum, name)
+			}
+		}
+		g.attributes = append(g.attributes, rule)
+	}
+	return scanner.Err()
+}
+
+// formatsFor returns the formats in effect for a template file, starting
+// from the global formats and applying matching attribute rules in order
+func (g *Generator) formatsFor(relPath string) config.FormatOptions {
+	formats := g.cfg.Formats
+	for _, rule := range g.attributes {
+		if !matchesAny([]string{rule.pattern}, relPath) {
+			continue
+		}
+		for name, enabled := range rule.formats {
+			switch name {
+			case "braces":
+				formats.EnableBraces = enabled
+			case "angle-brackets":
+				formats.EnableAngleBrackets = enabled
+			case "underscores":
+				formats.EnableUnderscores = enabled
+			case "percent":
+				formats.EnablePercent = enabled
+			case "custom":
+				formats.CustomOpen, formats.CustomClose = "", ""
+				if enabled {
+					formats.CustomOpen, formats.CustomClose = g.cfg.Formats.CustomOpen, g.cfg.Formats.CustomClose
+				}
+			}
+		}
+	}
+	return formats
+}
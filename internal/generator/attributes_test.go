@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		template   map[string]string
+		want       map[string]string
+		wantErr    string
+	}{
+		{
+			name:       "per-glob formats",
+			attributes: "*.tf percent -braces\n*.go -percent\n",
+			template: map[string]string{
+				"main.tf":    "name = \"%name%\" {{name}}",
+				"main.go":    "fmt.Sprintf(\"%name%\") // {{name}}",
+				"README.md":  "%name% {{name}}",
+				"sub/app.tf": "%name%",
+			},
+			want: map[string]string{
+				"main.tf":    "name = \"app\" {{name}}",
+				"main.go":    "fmt.Sprintf(\"%name%\") // app",
+				"README.md":  "app app",
+				"sub/app.tf": "app",
+			},
+		},
+		{
+			name:       "later lines win",
+			attributes: "# comment\n\n*.go -percent\nspecial.go percent\n",
+			template:   map[string]string{"a.go": "%name%", "special.go": "%name%"},
+			want:       map[string]string{"a.go": "%name%", "special.go": "app"},
+		},
+		{
+			name:       "pattern with a slash matches the path",
+			attributes: "gen/*.go -braces\n",
+			template:   map[string]string{"gen/a.go": "{{name}}", "a.go": "{{name}}"},
+			want:       map[string]string{"gen/a.go": "{{name}}", "a.go": "app"},
+		},
+		{
+			name:       "unknown format",
+			attributes: "*.go -percent\n*.tf dollars\n",
+			template:   map[string]string{"a.go": ""},
+			wantErr:    ".stencilattributes:2: unknown format \"dollars\"",
+		},
+		{
+			name:       "invalid pattern",
+			attributes: "[*.go braces\n",
+			template:   map[string]string{"a.go": ""},
+			wantErr:    "invalid pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			template := maps.Clone(tt.template)
+			template[AttributesFileName] = tt.attributes
+			writeTree(t, tmpl, template)
+
+			err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app"})).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
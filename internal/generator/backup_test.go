@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	tests := []struct {
+		name        string
+		backup      bool
+		prune       bool
+		wantOut     map[string]string
+		wantBackups []string
+	}{
+		{
+			name:   "only changed files",
+			backup: true,
+			wantOut: map[string]string{
+				"changed.txt":     "app v2\n",
+				"changed.txt.bak": "edited by hand\n",
+				"same.txt":        "app\n",
+				"new.txt":         "new\n",
+				"logo.bin":        "\x00v2",
+				"logo.bin.bak":    "\x00v1",
+				"stale.txt":       "stale\n",
+			},
+			wantBackups: []string{"changed.txt.bak", "logo.bin.bak"},
+		},
+		{
+			name:   "kept by prune",
+			backup: true,
+			prune:  true,
+			wantOut: map[string]string{
+				"changed.txt":     "app v2\n",
+				"changed.txt.bak": "edited by hand\n",
+				"same.txt":        "app\n",
+				"new.txt":         "new\n",
+				"logo.bin":        "\x00v2",
+				"logo.bin.bak":    "\x00v1",
+			},
+			wantBackups: []string{"changed.txt.bak", "logo.bin.bak"},
+		},
+		{
+			name: "disabled",
+			wantOut: map[string]string{
+				"changed.txt": "app v2\n",
+				"same.txt":    "app\n",
+				"new.txt":     "new\n",
+				"logo.bin":    "\x00v2",
+				"stale.txt":   "stale\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{
+				"changed.txt": "{{name}} v2\n",
+				"same.txt":    "{{name}}\n",
+				"new.txt":     "new\n",
+				"logo.bin":    "\x00v2",
+			})
+			writeTree(t, out, map[string]string{
+				"changed.txt": "edited by hand\n",
+				"same.txt":    "app\n",
+				"logo.bin":    "\x00v1",
+				"stale.txt":   "stale\n",
+			})
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.Backup = tt.backup
+			cfg.PruneOutput = tt.prune
+			g := newTestGenerator(cfg)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			if got := readTree(t, out); !maps.Equal(got, tt.wantOut) {
+				t.Errorf("output = %q, want %q", got, tt.wantOut)
+			}
+			var backups []string
+			for _, path := range g.Report().Backups {
+				rel, err := filepath.Rel(out, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				backups = append(backups, filepath.ToSlash(rel))
+			}
+			slices.Sort(backups)
+			if !slices.Equal(backups, tt.wantBackups) {
+				t.Errorf("backups = %q, want %q", backups, tt.wantBackups)
+			}
+		})
+	}
+}
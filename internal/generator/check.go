@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Kinds of FileChange
+const (
+	ChangeCreate = "create"
+	ChangeModify = "modify"
+	ChangeDelete = "delete"
+)
+
+// FileChange is an output file a run would create, modify or delete
+type FileChange struct {
+	Path string `json:"path"`
+	Kind string `json:"change"`
+}
+
+// Check generates into a temporary copy of the output directory, and of any
+// outputMap directories outside it, and returns
+// the output paths a real run would create, modify or prune, sorted. The
+// output directory itself is never written, so CI can verify that committed
+// output is up to date with its template.
+func (g *Generator) Check() ([]string, error) {
+	changes, err := g.Changes()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, change := range changes {
+		changed = append(changed, change.Path)
+	}
+	return changed, nil
+}
+
+// Changes works like Check but also reports how each path would change.
+// Files left as they are, are left out; deletions come from pruning. Files
+// under outputMap directories outside the output directory are included.
+func (g *Generator) Changes() ([]FileChange, error) {
+	// Backups would show up as new files
+	backup := g.cfg.Backup
+	g.cfg.Backup = false
+	defer func() { g.cfg.Backup = backup }()
+
+	var changes []FileChange
+	err := g.generateScratch("stencil-check-*", func(dirs []scratchDir) error {
+		for _, dir := range dirs {
+			dirChanges, err := changedFiles(dir.real, dir.scratch)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, dirChanges...)
+		}
+		return nil
+	})
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, err
+}
+
+// changedFiles compares the files under the output directory before and
+// after generation and returns the output paths that differ: added,
+// modified, or removed, sorted by path. Git metadata is ignored.
+func changedFiles(before, after string) ([]FileChange, error) {
+	beforeFiles, err := treeFiles(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFiles, err := treeFiles(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for rel, content := range afterFiles {
+		old, ok := beforeFiles[rel]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{filepath.Join(before, rel), ChangeCreate})
+		case !bytes.Equal(old, content):
+			changes = append(changes, FileChange{filepath.Join(before, rel), ChangeModify})
+		}
+	}
+	for rel := range beforeFiles {
+		if _, ok := afterFiles[rel]; !ok {
+			changes = append(changes, FileChange{filepath.Join(before, rel), ChangeDelete})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// treeFiles returns the content of every file under root keyed by relative
+// path; a symlink's content is its target. A missing root has no files.
+func treeFiles(root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == gitDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			files[relPath] = []byte(link)
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[relPath] = content
+		return nil
+	})
+	return files, err
+}
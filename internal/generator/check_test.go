@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	const mapped = `{"outputMap": {"frontend/**": "../web"}}`
+
+	tests := []struct {
+		name     string
+		template map[string]string
+		existing map[string]string // under the parent of the output directory
+		prune    bool
+		want     []FileChange // paths relative to the parent
+	}{
+		{
+			name:     "new output",
+			template: map[string]string{"README.md": "# {{name}}\n", "src/a.go": "package a\n"},
+			want: []FileChange{
+				{"out/README.md", ChangeCreate},
+				{"out/src/a.go", ChangeCreate},
+			},
+		},
+		{
+			name:     "up to date",
+			template: map[string]string{"README.md": "# {{name}}\n"},
+			existing: map[string]string{"out/README.md": "# app\n"},
+		},
+		{
+			name:     "modified",
+			template: map[string]string{"README.md": "# {{name}}\n"},
+			existing: map[string]string{"out/README.md": "# old\n"},
+			want:     []FileChange{{"out/README.md", ChangeModify}},
+		},
+		{
+			name:     "pruned",
+			template: map[string]string{"README.md": "# {{name}}\n"},
+			existing: map[string]string{"out/README.md": "# app\n", "out/stale.txt": "x"},
+			prune:    true,
+			want:     []FileChange{{"out/stale.txt", ChangeDelete}},
+		},
+		{
+			name: "mapped outside the output",
+			template: map[string]string{
+				"README.md":             "# {{name}}\n",
+				"frontend/app.js":       "// {{name}}\n",
+				"frontend/new.js":       "",
+				"stencil.manifest.json": mapped,
+			},
+			existing: map[string]string{"out/README.md": "# app\n", "web/app.js": "// old\n"},
+			want: []FileChange{
+				{"web/app.js", ChangeModify},
+				{"web/new.js", ChangeCreate},
+			},
+		},
+		{
+			name: "mapped outside the output, up to date",
+			template: map[string]string{
+				"frontend/app.js":       "// {{name}}\n",
+				"stencil.manifest.json": mapped,
+			},
+			existing: map[string]string{"web/app.js": "// app\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateDir, parent := t.TempDir(), t.TempDir()
+			writeTree(t, templateDir, tt.template)
+			writeTree(t, parent, tt.existing)
+			outputDir := filepath.Join(parent, "out")
+
+			cfg := testConfig(templateDir, outputDir, map[string]string{"name": "app"})
+			cfg.PruneOutput = tt.prune
+			changes, err := newTestGenerator(cfg).Changes()
+			if err != nil {
+				t.Fatalf("Changes failed: %v", err)
+			}
+
+			var got []FileChange
+			for _, change := range changes {
+				rel, err := filepath.Rel(parent, change.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, FileChange{filepath.ToSlash(rel), change.Kind})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Changes = %v, want %v", got, tt.want)
+			}
+
+			if got := readTree(t, parent); !maps.Equal(got, tt.existing) {
+				t.Errorf("Changes wrote files: %q", got)
+			}
+		})
+	}
+}
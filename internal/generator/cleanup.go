@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// missingTargets returns the planned output paths, and the directories
+// above them, that do not exist yet, which CleanupOnError removes again
+// when the run fails
+func (g *Generator) missingTargets(entries []planEntry) []string {
+	targets := make([]string, 0, len(entries)+1)
+	for _, entry := range entries {
+		targets = append(targets, filepath.Join(g.cfg.OutputDir, entry.targetRel))
+	}
+	if g.cfg.WriteValues {
+		targets = append(targets, filepath.Join(g.cfg.OutputDir, ValuesFileName))
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, path := range targets {
+		// Mapped outputs may need parents outside the output directory
+		for !seen[path] {
+			seen[path] = true
+			if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+				break
+			}
+			missing = append(missing, path)
+			path = filepath.Dir(path)
+		}
+	}
+	return missing
+}
+
+// removeCreated removes the paths a failed run created, deepest first, so
+// that files go before their directories. Directories that still hold
+// other files are kept; files that existed before the run are never in
+// paths and keep what the run wrote.
+func (g *Generator) removeCreated(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		return len(paths[i]) > len(paths[j])
+	})
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			if !info.IsDir() {
+				g.logger.Warn("Failed to remove "+path+": "+err.Error(), "path", path, "error", err)
+			}
+			continue
+		}
+		g.logger.Debug("Removed after failure: "+path, "path", path)
+	}
+}
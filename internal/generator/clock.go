@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Clock provides the current time to built-in variables
+type Clock interface {
+	Now() time.Time
+}
+
+// Rand provides random bytes to built-in variables
+type Rand interface {
+	Read(p []byte) (n int, err error)
+}
+
+// systemClock is the default Clock, backed by the system time
+type systemClock struct{}
+
+// Now returns the current local time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock replaces the generator's clock, e.g. with a fixed time for
+// reproducible output. A nil clock restores the system clock.
+func (g *Generator) SetClock(clock Clock) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	g.clock = clock
+}
+
+// SetRand replaces the generator's source of randomness, e.g. with a seeded
+// source for reproducible output. A nil source restores crypto/rand. It must
+// be called before generating.
+func (g *Generator) SetRand(r Rand) {
+	if r == nil {
+		r = rand.Reader
+	}
+	g.rand = r
+}
+
+// now returns the current time according to the generator's clock
+func (g *Generator) now() time.Time {
+	return g.clock.Now()
+}
+
+// randomBytes returns n bytes from the generator's source of randomness
+func (g *Generator) randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(g.rand, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// runUUID returns a random version 4 UUID, drawn once per generator. It is
+// empty if the source of randomness fails.
+func (g *Generator) runUUID() string {
+	g.uuidOnce.Do(func() {
+		b, err := g.randomBytes(16)
+		if err != nil {
+			return
+		}
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		g.uuid = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	})
+	return g.uuid
+}
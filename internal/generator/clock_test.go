@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"bytes"
+	"maps"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fixedClock always reports the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// TestDeterministicBuiltins checks that a fixed clock and a deterministic
+// source of randomness make the time and random built-ins reproducible
+func TestDeterministicBuiltins(t *testing.T) {
+	template := map[string]string{
+		"main.go":   "package main\n",
+		"id.txt":    "{{id}} {{since}}\n",
+		"other.txt": "{{id}}\n",
+		manifestFile: `{
+			"variables": [
+				{"name": "id", "default": "{{__uuid__}}"},
+				{"name": "since", "default": "{{__year__}}"}
+			],
+			"header": {"text": "Copyright {{year}} Acme", "files": ["*.go"]}
+		}`,
+	}
+	want := map[string]string{
+		"main.go":   "// Copyright 2031 Acme\n\npackage main\n",
+		"id.txt":    "00010203-0405-4607-8809-0a0b0c0d0e0f 2031\n",
+		"other.txt": "00010203-0405-4607-8809-0a0b0c0d0e0f\n",
+	}
+
+	tmpl := t.TempDir()
+	writeTree(t, tmpl, template)
+	for range 2 {
+		out := filepath.Join(t.TempDir(), "output")
+		g := newTestGenerator(testConfig(tmpl, out, nil))
+		g.SetClock(fixedClock(time.Date(2031, 6, 1, 12, 0, 0, 0, time.UTC)))
+		g.SetRand(bytes.NewReader([]byte("\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f")))
+		if err := g.Generate(); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if got := readTree(t, out); !maps.Equal(got, want) {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSystemDefaults(t *testing.T) {
+	g := newTestGenerator(testConfig(t.TempDir(), t.TempDir(), nil))
+	if got := g.now(); time.Since(got).Abs() > time.Minute {
+		t.Errorf("now() = %v, want the current time", got)
+	}
+
+	first, second := g.runUUID(), g.runUUID()
+	if len(first) != 36 || first[14] != '4' {
+		t.Errorf("runUUID() = %q, want a version 4 UUID", first)
+	}
+	if first != second {
+		t.Errorf("runUUID() changed within a run: %q then %q", first, second)
+	}
+	if other := newTestGenerator(testConfig(t.TempDir(), t.TempDir(), nil)).runUUID(); other == first {
+		t.Errorf("two generators drew the same UUID %q", first)
+	}
+
+	// Nil restores the defaults
+	g.SetClock(nil)
+	g.SetRand(nil)
+	if _, err := g.randomBytes(8); err != nil {
+		t.Errorf("randomBytes failed: %v", err)
+	}
+}
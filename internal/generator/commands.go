@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// applyCommandDefaults computes manifest variables that declare a command
+// and have not been provided, in manifest order, so that a command sees the
+// values computed before it. It reports whether any variable was set.
+func (g *Generator) applyCommandDefaults(m *manifest.Manifest) (bool, error) {
+	changed := false
+	for _, v := range m.Variables {
+		if v.Command == "" {
+			continue
+		}
+		if _, ok := g.cfg.Variables[v.Name]; ok {
+			continue
+		}
+
+		value, err := g.runVariableCommand(v.Command)
+		if err != nil {
+			return changed, fmt.Errorf("failed to compute variable '%s': %w", v.Name, err)
+		}
+		g.cfg.Variables[v.Name] = value
+		changed = true
+	}
+	return changed, nil
+}
+
+// commandArgs splits a configured command on whitespace and substitutes
+// variables in each argument separately. No shell is involved and values
+// are never re-split, so a value holding spaces or shell metacharacters
+// stays one argument and cannot add arguments or commands.
+func (g *Generator) commandArgs(command string) []string {
+	args := strings.Fields(command)
+	for i, arg := range args {
+		args[i] = g.replacer.ReplaceInPath(arg)
+	}
+	return args
+}
+
+// runVariableCommand runs a variable command with the current variables as
+// JSON on stdin and returns its output without the trailing newline
+func (g *Generator) runVariableCommand(command string) (string, error) {
+	if g.fsys != nil {
+		return "", fmt.Errorf("commands require a template directory on disk")
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+
+	input, err := json.Marshal(g.cfg.Variables)
+	if err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = g.cfg.TemplateDir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("command %q failed: %w", args[0], err)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
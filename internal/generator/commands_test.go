@@ -0,0 +1,241 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+func TestCommandArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		value   string
+		want    []string
+	}{
+		{
+			name:    "plain value",
+			command: "git remote add origin {{value}}",
+			value:   "https://example.com/app.git",
+			want:    []string{"git", "remote", "add", "origin", "https://example.com/app.git"},
+		},
+		{
+			name:    "shell metacharacters",
+			command: "echo {{value}}",
+			value:   "x; rm -rf / $(id)",
+			want:    []string{"echo", "x; rm -rf / $(id)"},
+		},
+		{
+			name:    "quotes and newlines",
+			command: "echo {{value}}",
+			value:   "a \"b\" 'c'\nd",
+			want:    []string{"echo", "a \"b\" 'c'\nd"},
+		},
+		{
+			name:    "inside an argument",
+			command: "gofmt -w --label={{value}} main.go",
+			value:   "a b",
+			want:    []string{"gofmt", "-w", "--label=a b", "main.go"},
+		},
+		{
+			name:    "empty value keeps its argument",
+			command: "echo {{value}} end",
+			value:   "",
+			want:    []string{"echo", "", "end"},
+		},
+		{
+			name:    "empty command",
+			command: "   ",
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(testConfig(t.TempDir(), t.TempDir(), map[string]string{"value": tt.value}))
+			if got := g.commandArgs(tt.command); !slices.Equal(got, tt.want) {
+				t.Errorf("commandArgs(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+// helperArgsEnv names the file TestHelperProcess writes its arguments to
+const helperArgsEnv = "STENCIL_HELPER_ARGS"
+
+// TestHelperProcess is not a real test: run as a command by
+// TestCommandArgv, it records the arguments after "--" as JSON
+func TestHelperProcess(t *testing.T) {
+	path := os.Getenv(helperArgsEnv)
+	if path == "" {
+		return
+	}
+	args := os.Args[slices.Index(os.Args, "--")+1:]
+	data, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCommandArgv checks that a command receives each substituted argument
+// as one argv entry, however many spaces or shell metacharacters it holds
+func TestCommandArgv(t *testing.T) {
+	self, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	argsFile := filepath.Join(t.TempDir(), "args.json")
+	t.Setenv(helperArgsEnv, argsFile)
+
+	command := self + " -test.run=^TestHelperProcess$ -- {{repo_url}} --name={{name}} {{name}}"
+	validate, err := json.Marshal([]string{command})
+	if err != nil {
+		t.Fatal(err)
+	}
+	templateDir := t.TempDir()
+	writeTree(t, templateDir, map[string]string{
+		"a.txt":      "a",
+		manifestFile: `{"validate": ` + string(validate) + `}`,
+	})
+
+	vars := map[string]string{"repo_url": "x; rm -rf / $(id)", "name": "my app && reboot"}
+	if err := newTestGenerator(testConfig(templateDir, t.TempDir(), vars)).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("command did not run: %v", err)
+	}
+	var got []string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"x; rm -rf / $(id)", "--name=my app && reboot", "my app && reboot"}
+	if !slices.Equal(got, want) {
+		t.Errorf("argv = %q, want %q", got, want)
+	}
+}
+
+// variableHelperEnv makes TestVariableCommandProcess act as a variable
+// command
+const variableHelperEnv = "STENCIL_VARIABLE_HELPER"
+
+// TestVariableCommandProcess is not a real test: run as a variable command
+// by TestCommandDefaults, it reads the variables as JSON on stdin and prints
+// the slug of the one named by its first argument after "--", or fails
+// when that argument is "fail"
+func TestVariableCommandProcess(t *testing.T) {
+	if os.Getenv(variableHelperEnv) == "" {
+		return
+	}
+	name := os.Args[slices.Index(os.Args, "--")+1]
+	if name == "fail" {
+		fmt.Fprintln(os.Stderr, "no network")
+		os.Exit(2)
+	}
+	var vars map[string]string
+	if err := json.NewDecoder(os.Stdin).Decode(&vars); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(strings.ReplaceAll(strings.ToLower(vars[name]), " ", "-"))
+	os.Exit(0)
+}
+
+func TestCommandDefaults(t *testing.T) {
+	self, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(variableHelperEnv, "1")
+	slugOf := func(name string) string {
+		return self + " -test.run=^TestVariableCommandProcess$ -- " + name
+	}
+	variables := func(vars ...manifest.Variable) string {
+		data, err := json.Marshal(manifest.Manifest{Variables: vars})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := []struct {
+		name     string
+		manifest string
+		allow    bool
+		vars     map[string]string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "computed from the other variables",
+			manifest: variables(manifest.Variable{Name: "slug", Command: slugOf("title")}),
+			allow:    true,
+			vars:     map[string]string{"title": "My Cool App"},
+			want:     "my-cool-app",
+		},
+		{
+			name: "sees values computed before it",
+			manifest: variables(
+				manifest.Variable{Name: "title", Command: slugOf("name")},
+				manifest.Variable{Name: "slug", Command: slugOf("title")},
+			),
+			allow: true,
+			vars:  map[string]string{"name": "Big App"},
+			want:  "big-app",
+		},
+		{
+			name:     "provided value wins",
+			manifest: variables(manifest.Variable{Name: "slug", Command: slugOf("fail")}),
+			allow:    true,
+			vars:     map[string]string{"slug": "given"},
+			want:     "given",
+		},
+		{
+			name:     "not run unless allowed",
+			manifest: variables(manifest.Variable{Name: "slug", Command: slugOf("title")}),
+			vars:     map[string]string{"title": "My Cool App"},
+			want:     "{{slug}}",
+		},
+		{
+			name:     "failure",
+			manifest: variables(manifest.Variable{Name: "slug", Command: slugOf("fail")}),
+			allow:    true,
+			wantErr:  "failed to compute variable 'slug'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"slug.txt": "{{slug}}", manifestFile: tt.manifest})
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.AllowCommandDefaults = tt.allow
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "no network") {
+					t.Fatalf("Generate error = %v, want %q with the command's stderr", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out)["slug.txt"]; got != tt.want {
+				t.Errorf("slug.txt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
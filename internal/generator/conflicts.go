@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// checkVariableConflicts fails when two variables that name the same
+// canonical variable, through an alias or case-insensitive matching, were
+// given different values, rather than letting one of them win arbitrarily
+func (g *Generator) checkVariableConflicts() error {
+	keys := make([]string, 0, len(g.cfg.Variables))
+	for key := range g.cfg.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// first maps each canonical identity to the first key setting it
+	first := make(map[string]string, len(keys))
+	for _, key := range keys {
+		canonical := g.canonicalName(key)
+		identity := canonical
+		if g.cfg.CaseInsensitiveVars {
+			identity = strings.ToLower(canonical)
+		}
+
+		prev, ok := first[identity]
+		if !ok {
+			first[identity] = key
+			continue
+		}
+		if g.cfg.Variables[prev] != g.cfg.Variables[key] {
+			return fmt.Errorf("conflicting values for variable %q: %s=%q and %s=%q",
+				canonical, prev, g.cfg.Variables[prev], key, g.cfg.Variables[key])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVariableConflicts(t *testing.T) {
+	tests := []struct {
+		name            string
+		aliases         string
+		caseInsensitive bool
+		vars            map[string]string
+		wantErr         string
+	}{
+		{
+			name:    "alias conflict",
+			aliases: `{"appName": "app_name"}`,
+			vars:    map[string]string{"app_name": "demo", "appName": "other"},
+			wantErr: `conflicting values for variable "app_name": appName="other" and app_name="demo"`,
+		},
+		{
+			name:    "aliases of one variable conflict",
+			aliases: `{"appName": "app_name", "application": "app_name"}`,
+			vars:    map[string]string{"appName": "demo", "application": "other"},
+			wantErr: `conflicting values for variable "app_name": appName="demo" and application="other"`,
+		},
+		{
+			name:            "case conflict",
+			caseInsensitive: true,
+			vars:            map[string]string{"Name": "demo", "name": "other"},
+			wantErr:         `conflicting values for variable "name": Name="demo" and name="other"`,
+		},
+		{
+			name:            "case and alias conflict",
+			aliases:         `{"AppName": "app_name"}`,
+			caseInsensitive: true,
+			vars:            map[string]string{"APP_NAME": "demo", "AppName": "other"},
+			wantErr:         `conflicting values for variable "app_name"`,
+		},
+		{
+			name:    "alias agrees",
+			aliases: `{"appName": "app_name"}`,
+			vars:    map[string]string{"app_name": "demo", "appName": "demo"},
+		},
+		{
+			name:            "case agrees",
+			caseInsensitive: true,
+			vars:            map[string]string{"Name": "demo", "name": "demo", "app_name": "x"},
+		},
+		{
+			name: "case sensitive keys are distinct",
+			vars: map[string]string{"Name": "demo", "name": "other", "app_name": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			template := map[string]string{"README.md": "# {{app_name}}\n"}
+			if tt.aliases != "" {
+				template[manifestFile] = `{"aliases": ` + tt.aliases + `}`
+			}
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.CaseInsensitiveVars = tt.caseInsensitive
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				if files := readTree(t, out); len(files) != 0 {
+					t.Errorf("output = %q, want nothing written", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+		})
+	}
+}
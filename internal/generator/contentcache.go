@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Limits of the content cache: files larger than maxCachedFileBytes are
+// never cached, and nothing more is added once the cache holds
+// maxCachedBytes. Variables so tests can turn the cache off.
+var (
+	maxCachedFileBytes int64 = 1 << 20
+	maxCachedBytes     int64 = 32 << 20
+)
+
+// contentCache keeps the template files read during one run, so the
+// variable scan and generation share a single read of each file. Entries
+// are keyed by path and only served while the file's modification time
+// and size are unchanged.
+type contentCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedContent
+	size    int64
+}
+
+// cachedContent is a cached file and the state it was read in
+type cachedContent struct {
+	modTime time.Time
+	size    int64
+	content []byte
+}
+
+// get returns a copy of the cached content of path, if info still matches it
+func (c *contentCache) get(path string, info os.FileInfo) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[path]
+	if !ok || !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+		return nil, false
+	}
+	return append([]byte(nil), entry.content...), true
+}
+
+// put caches a copy of the content of path, read while it matched info,
+// unless the file or the cache is too large
+func (c *contentCache) put(path string, info os.FileInfo, content []byte) {
+	size := int64(len(content))
+	if size != info.Size() || size > maxCachedFileBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedContent)
+	}
+	if old, ok := c.entries[path]; ok {
+		c.size -= old.size
+		delete(c.entries, path)
+	}
+	if c.size+size > maxCachedBytes {
+		return
+	}
+	c.entries[path] = cachedContent{modTime: info.ModTime(), size: size, content: append([]byte(nil), content...)}
+	c.size += size
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withoutContentCache turns the content cache off until the test ends
+func withoutContentCache(tb testing.TB) {
+	tb.Helper()
+	old := maxCachedBytes
+	maxCachedBytes = 0
+	tb.Cleanup(func() { maxCachedBytes = old })
+}
+
+// extractAndGenerate scans the template for variables, then generates it,
+// as interactive mode does, and returns the variables and the output
+func extractAndGenerate(tb testing.TB, templateDir, outputDir string) (map[string]string, *Generator) {
+	tb.Helper()
+	g := NewGenerator(testConfig(templateDir, outputDir, map[string]string{"name": "app", "org": "acme"}))
+	g.SetLogger(NewConsoleLogger(io.Discard, io.Discard))
+	variables, err := g.ExtractVariables()
+	if err != nil {
+		tb.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if err := g.Generate(); err != nil {
+		tb.Fatalf("Generate failed: %v", err)
+	}
+	return variables, g
+}
+
+func TestContentCacheMatchesUncached(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTree(t, templateDir, map[string]string{
+		"README.md":            "# {{name}} by {{org}}\n",
+		"__name__/main.go":     "package main // <<name>>\n",
+		"docs/%org%.txt":       "{{missing}}",
+		"bin/data.bin":         "\x00\x01{{name}}",
+		"large.txt":            strings.Repeat("{{name}} ", 200000),
+		manifestFile:           `{"variables": [{"name": "name", "default": "x"}]}`,
+		"nested/deep/file.txt": "{{org}}/{{name}}",
+	})
+
+	cachedDir, uncachedDir := t.TempDir(), t.TempDir()
+	cachedVars, g := extractAndGenerate(t, templateDir, cachedDir)
+	if len(g.contents.entries) == 0 {
+		t.Fatal("nothing was cached")
+	}
+	if _, ok := g.contents.entries[filepath.Join(templateDir, "large.txt")]; ok {
+		t.Error("a file over the size limit was cached")
+	}
+
+	withoutContentCache(t)
+	uncachedVars, g := extractAndGenerate(t, templateDir, uncachedDir)
+	if len(g.contents.entries) != 0 {
+		t.Fatalf("%d files cached with the cache off", len(g.contents.entries))
+	}
+
+	if !maps.Equal(cachedVars, uncachedVars) {
+		t.Errorf("variables with cache = %q, without = %q", cachedVars, uncachedVars)
+	}
+	if cached, uncached := readTree(t, cachedDir), readTree(t, uncachedDir); !maps.Equal(cached, uncached) {
+		t.Errorf("output with cache differs from output without it")
+	}
+}
+
+// TestContentCacheStale checks that a file changed between the scan and
+// generation is read again
+func TestContentCacheStale(t *testing.T) {
+	templateDir, outputDir := t.TempDir(), t.TempDir()
+	writeTree(t, templateDir, map[string]string{"a.txt": "old {{name}}"})
+
+	g := newTestGenerator(testConfig(templateDir, outputDir, map[string]string{"name": "app"}))
+	if _, err := g.ExtractVariables(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(templateDir, "a.txt")
+	if err := os.WriteFile(path, []byte("new {{name}}!"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTree(t, outputDir)["a.txt"]; got != "new app!" {
+		t.Errorf("a.txt = %q, want the changed file", got)
+	}
+}
+
+func BenchmarkExtractAndGenerate(b *testing.B) {
+	templateDir := b.TempDir()
+	files := make(map[string]string)
+	for i := range 200 {
+		files[fmt.Sprintf("pkg%d/file%d.go", i%20, i)] = strings.Repeat("// {{name}} by {{org}}\nfunc f() {}\n", 200)
+	}
+	writeTree(b, templateDir, files)
+
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+		b.Run(name, func(b *testing.B) {
+			if !cached {
+				withoutContentCache(b)
+			}
+			for b.Loop() {
+				extractAndGenerate(b, templateDir, b.TempDir())
+			}
+		})
+	}
+}
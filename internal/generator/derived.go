@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// applyDerived sets the variables the manifest derives from other
+// variables, unless they were given values of their own. A variable whose
+// source has no value is left unset. It reports whether any variable was
+// set.
+func (g *Generator) applyDerived(m *manifest.Manifest) (bool, error) {
+	names := make([]string, 0, len(m.Derived))
+	for name := range m.Derived {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	changed := false
+	for _, name := range names {
+		source, transform, err := parseDerived(m.Derived[name])
+		if err != nil {
+			return false, fmt.Errorf("derived variable %s: %w", name, err)
+		}
+		if _, ok := g.cfg.Variables[name]; ok {
+			continue
+		}
+		value, ok := g.cfg.Variables[g.canonicalName(source)]
+		if !ok {
+			continue
+		}
+		if value, err = config.ApplyTransform(transform, value); err != nil {
+			return false, fmt.Errorf("derived variable %s: %w", name, err)
+		}
+		g.cfg.Variables[name] = value
+		changed = true
+	}
+	return changed, nil
+}
+
+// parseDerived splits a derived variable's "variable|transform" spec
+func parseDerived(spec string) (source, transform string, err error) {
+	source, transform, ok := strings.Cut(spec, "|")
+	source, transform = strings.TrimSpace(source), strings.TrimSpace(transform)
+	if !ok || source == "" || transform == "" {
+		return "", "", fmt.Errorf("expected \"variable|transform\", got %q", spec)
+	}
+	return source, transform, nil
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestDerivedVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		derived string
+		vars    map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "value and slug side by side",
+			derived: `{"project_slug": "project_name|slug"}`,
+			vars:    map[string]string{"project_name": "My Café App"},
+			want:    map[string]string{"my-cafe-app/README.md": "# My Café App (my-cafe-app)\n"},
+		},
+		{
+			name:    "separator",
+			derived: `{"project_slug": "project_name | slug:_"}`,
+			vars:    map[string]string{"project_name": "My Café App"},
+			want:    map[string]string{"my_cafe_app/README.md": "# My Café App (my_cafe_app)\n"},
+		},
+		{
+			name:    "given value wins",
+			derived: `{"project_slug": "project_name|slug"}`,
+			vars:    map[string]string{"project_name": "My App", "project_slug": "custom"},
+			want:    map[string]string{"custom/README.md": "# My App (custom)\n"},
+		},
+		{
+			name:    "source from an alias",
+			derived: `{"project_slug": "project_name|slug"}, "aliases": {"appName": "project_name"}`,
+			vars:    map[string]string{"appName": "My App"},
+			want:    map[string]string{"my-app/README.md": "# My App (my-app)\n"},
+		},
+		{
+			name:    "missing separator",
+			derived: `{"project_slug": "project_name"}`,
+			vars:    map[string]string{"project_name": "My App"},
+			wantErr: `derived variable project_slug: expected "variable|transform"`,
+		},
+		{
+			name:    "unknown transform",
+			derived: `{"project_slug": "project_name|title"}`,
+			vars:    map[string]string{"project_name": "My App"},
+			wantErr: "derived variable project_slug: unknown transform 'title'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateDir := t.TempDir()
+			writeTree(t, templateDir, map[string]string{
+				"__project_slug__/README.md": "# {{project_name}} ({{project_slug}})\n",
+				manifestFile:                 `{"derived": ` + tt.derived + `}`,
+			})
+
+			files, err := newTestGenerator(testConfig(templateDir, t.TempDir(), tt.vars)).Render()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, f := range files {
+				got[f.Path] = string(f.Content)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractDerivedVariables checks that a derived variable is not asked
+// for, while its source is even when only the derived one is used
+func TestExtractDerivedVariables(t *testing.T) {
+	templateDir := t.TempDir()
+	writeTree(t, templateDir, map[string]string{
+		"__project_slug__/main.go": "package main // {{author}}\n",
+		manifestFile: `{
+			"variables": [{"name": "project_name", "default": "My App"}],
+			"derived": {"project_slug": "project_name|slug"}
+		}`,
+	})
+
+	got, err := newTestGenerator(testConfig(templateDir, t.TempDir(), nil)).ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	want := map[string]string{"project_name": "My App", "author": ""}
+	if !maps.Equal(got, want) {
+		t.Errorf("ExtractVariables = %q, want %q", got, want)
+	}
+}
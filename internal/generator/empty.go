@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// applyEmptyValues handles placeholders for empty variables in content
+// before substitution, as configured by OnEmptyValue: "error" fails on the
+// first one and "skip-line" drops the lines they would leave blank.
+// "keep-placeholder" is applied when replacers are built, see
+// withoutEmptyValues.
+func (g *Generator) applyEmptyValues(r *replacer.Replacer, content []byte) ([]byte, error) {
+	switch g.cfg.OnEmptyValue {
+	case config.EmptyValueError:
+		for _, rep := range r.FindReplacements(content) {
+			if rep.Value == "" {
+				line := bytes.Count(content[:rep.Start], []byte("\n")) + 1
+				return nil, fmt.Errorf("line %d: variable %s has an empty value", line, rep.Name)
+			}
+		}
+	case config.EmptyValueSkipLine:
+		return r.DropEmptyLines(content), nil
+	}
+	return content, nil
+}
+
+// withoutEmptyValues returns variables without the ones whose value is
+// empty, so their placeholders are left in place
+func withoutEmptyValues(variables map[string]string) map[string]string {
+	result := make(map[string]string, len(variables))
+	for key, value := range variables {
+		if value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+)
+
+func TestOnEmptyValue(t *testing.T) {
+	template := map[string]string{
+		"config.yaml":    "name: {{name}}\n{{description}}\nurl: https://{{host}}/x\n",
+		"__suffix__.txt": "x",
+	}
+	vars := map[string]string{"name": "app", "description": "", "host": "", "suffix": ""}
+
+	tests := []struct {
+		mode    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			mode: "",
+			want: map[string]string{
+				"config.yaml": "name: app\n\nurl: https:///x\n",
+				".txt":        "x",
+			},
+		},
+		{
+			mode: config.EmptyValueInsert,
+			want: map[string]string{
+				"config.yaml": "name: app\n\nurl: https:///x\n",
+				".txt":        "x",
+			},
+		},
+		{
+			mode: config.EmptyValueKeepPlaceholder,
+			want: map[string]string{
+				"config.yaml":    "name: app\n{{description}}\nurl: https://{{host}}/x\n",
+				"__suffix__.txt": "x",
+			},
+		},
+		{
+			mode: config.EmptyValueSkipLine,
+			want: map[string]string{
+				"config.yaml": "name: app\nurl: https:///x\n",
+				".txt":        "x",
+			},
+		},
+		{
+			mode:    config.EmptyValueError,
+			wantErr: "line 2: variable description has an empty value",
+		},
+		{
+			mode:    "bogus",
+			wantErr: "unknown mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			templateDir, outputDir := t.TempDir(), t.TempDir()
+			writeTree(t, templateDir, template)
+			cfg := testConfig(templateDir, outputDir, vars)
+			cfg.OnEmptyValue = tt.mode
+
+			g := newTestGenerator(cfg)
+			err := g.Generate()
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("Generate succeeded")
+				}
+				// Per-file errors are reported rather than returned
+				messages := err.Error()
+				if g.Report() != nil {
+					for _, issue := range g.Report().Errors() {
+						messages += "\n" + issue.String()
+					}
+				}
+				if !strings.Contains(messages, tt.wantErr) {
+					t.Fatalf("Generate error = %v, want one containing %q", messages, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, outputDir); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
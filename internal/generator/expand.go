@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// expandValues substitutes variables referenced by other variables' values,
+// so "project_name={{org}}-svc" becomes "acme-svc". Referenced variables are
+// expanded first; a reference cycle is an error.
+func (g *Generator) expandValues() error {
+	names := make([]string, 0, len(g.cfg.Variables))
+	for name := range g.cfg.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	expanded := make(map[string]string, len(names))
+	visiting := make(map[string]bool)
+
+	var expand func(name string, chain []string) error
+	expand = func(name string, chain []string) error {
+		if _, ok := expanded[name]; ok {
+			return nil
+		}
+		chain = append(chain, name)
+		if visiting[name] {
+			return fmt.Errorf("variable reference cycle: %s", strings.Join(chain, " -> "))
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		value := g.cfg.Variables[name]
+		refs := make(map[string]string)
+		for _, ref := range replacer.ExtractVariablesFromFile([]byte(value), g.cfg.Formats) {
+			target, ok := g.variableName(ref)
+			if !ok {
+				continue
+			}
+			if err := expand(target, chain); err != nil {
+				return err
+			}
+			refs[target] = expanded[target]
+		}
+
+		if len(refs) > 0 {
+			value = string(g.newReplacer(refs).ReplaceInContent([]byte(value)))
+		}
+		expanded[name] = value
+		return nil
+	}
+
+	for _, name := range names {
+		if err := expand(name, nil); err != nil {
+			return err
+		}
+	}
+
+	g.cfg.Variables = expanded
+	g.replacer = g.newReplacer(expanded)
+	return nil
+}
+
+// variableName returns the defined variable a placeholder key refers to,
+// honoring key normalization and case-insensitive matching
+func (g *Generator) variableName(key string) (string, bool) {
+	key = g.normalizeKey(key)
+	if _, ok := g.cfg.Variables[key]; ok {
+		return key, true
+	}
+	if !g.cfg.CaseInsensitiveVars {
+		return "", false
+	}
+
+	var match string
+	for name := range g.cfg.Variables {
+		if strings.EqualFold(name, key) && (match == "" || name < match) {
+			match = name
+		}
+	}
+	return match, match != ""
+}
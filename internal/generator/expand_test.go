@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		expand  bool
+		vars    map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "literal by default",
+			vars: map[string]string{"org": "acme", "project_name": "{{org}}-svc"},
+			want: "{{org}}-svc",
+		},
+		{
+			name:   "reference expanded",
+			expand: true,
+			vars:   map[string]string{"org": "acme", "project_name": "{{org}}-svc"},
+			want:   "acme-svc",
+		},
+		{
+			name:   "chained references",
+			expand: true,
+			vars:   map[string]string{"org": "acme", "team": "<<org>>-core", "project_name": "%team%-svc"},
+			want:   "acme-core-svc",
+		},
+		{
+			name:   "unknown reference kept",
+			expand: true,
+			vars:   map[string]string{"project_name": "{{nope}}-svc"},
+			want:   "{{nope}}-svc",
+		},
+		{
+			name:    "two-variable cycle",
+			expand:  true,
+			vars:    map[string]string{"a": "{{b}}", "b": "{{a}}", "project_name": "x"},
+			wantErr: "variable reference cycle: a -> b -> a",
+		},
+		{
+			name:    "self reference",
+			expand:  true,
+			vars:    map[string]string{"project_name": "{{project_name}}-svc"},
+			wantErr: "variable reference cycle: project_name -> project_name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"name.txt": "{{project_name}}"})
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.ExpandValues = tt.expand
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out)["name.txt"]; got != tt.want {
+				t.Errorf("name.txt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runFormatters runs every configured formatter whose pattern matches the
+// generated file. A key starting with '.' and containing no glob characters
+// matches that extension; any other key is a glob as in .stencil-keep. The
+// command is split on whitespace and receives the file path as its last
+// argument. Variables are substituted per argument, as for validate
+// commands.
+func (g *Generator) runFormatters(targetPath, relPath string) error {
+	patterns := make([]string, 0, len(g.cfg.Formatters))
+	for pattern := range g.cfg.Formatters {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if !formatterMatches(pattern, relPath) {
+			continue
+		}
+
+		args := g.commandArgs(g.cfg.Formatters[pattern])
+		if len(args) == 0 {
+			continue
+		}
+
+		cmd := exec.Command(args[0], append(args[1:], targetPath)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			err = fmt.Errorf("formatter %q failed: %w", args[0], err)
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			if g.cfg.StrictFormatters {
+				return err
+			}
+			g.report.Warn(relPath, "%v", err)
+		}
+	}
+	return nil
+}
+
+// formatterMatches reports whether a formatter pattern applies to a path
+func formatterMatches(pattern, relPath string) bool {
+	if strings.HasPrefix(pattern, ".") && !strings.ContainsAny(pattern, "*?[") {
+		return filepath.Ext(relPath) == pattern
+	}
+	return matchesAny([]string{pattern}, relPath)
+}
@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// formatterHelperEnv makes TestFormatterProcess act as a formatter
+const formatterHelperEnv = "STENCIL_FORMATTER_HELPER"
+
+// TestFormatterProcess is not a real test: run as a formatter by the tests
+// below, it uppercases the file named by its last argument, or fails when
+// its first argument is "fail"
+func TestFormatterProcess(t *testing.T) {
+	if os.Getenv(formatterHelperEnv) == "" {
+		return
+	}
+	args := os.Args[slices.Index(os.Args, "--")+1:]
+	if args[0] == "fail" {
+		fmt.Fprintln(os.Stderr, "syntax error")
+		os.Exit(1)
+	}
+	path := args[len(args)-1]
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = os.WriteFile(path, bytes.ToUpper(data), 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestFormatters(t *testing.T) {
+	self, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(formatterHelperEnv, "1")
+	upper := self + " -test.run=^TestFormatterProcess$ -- upper"
+	fail := self + " -test.run=^TestFormatterProcess$ -- fail"
+
+	template := map[string]string{
+		"main.go":        "package {{name}}\n",
+		"cmd/tool.go":    "package main\n",
+		"web/app.ts":     "let x = 1\n",
+		"README.md":      "# {{name}}\n",
+		"docs/notes.txt": "notes\n",
+	}
+
+	tests := []struct {
+		name        string
+		formatters  map[string]string
+		strict      bool
+		dryRun      bool
+		want        map[string]string
+		wantWarning string
+		wantErr     string
+	}{
+		{
+			name:       "extension",
+			formatters: map[string]string{".go": upper},
+			want: map[string]string{
+				"main.go":        "PACKAGE APP\n",
+				"cmd/tool.go":    "PACKAGE MAIN\n",
+				"web/app.ts":     "let x = 1\n",
+				"README.md":      "# app\n",
+				"docs/notes.txt": "notes\n",
+			},
+		},
+		{
+			name:       "glob",
+			formatters: map[string]string{"cmd/*.go": upper, "*.ts": upper},
+			want: map[string]string{
+				"main.go":        "package app\n",
+				"cmd/tool.go":    "PACKAGE MAIN\n",
+				"web/app.ts":     "LET X = 1\n",
+				"README.md":      "# app\n",
+				"docs/notes.txt": "notes\n",
+			},
+		},
+		{
+			name:       "failure warns",
+			formatters: map[string]string{".md": fail},
+			want: map[string]string{
+				"main.go":        "package app\n",
+				"cmd/tool.go":    "package main\n",
+				"web/app.ts":     "let x = 1\n",
+				"README.md":      "# app\n",
+				"docs/notes.txt": "notes\n",
+			},
+			wantWarning: "README.md: formatter",
+		},
+		{
+			name:       "failure is fatal when strict",
+			formatters: map[string]string{".md": fail},
+			strict:     true,
+			wantErr:    "README.md: formatter",
+		},
+		{
+			name:       "skipped in dry run",
+			formatters: map[string]string{".go": fail},
+			dryRun:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.Formatters = tt.formatters
+			cfg.StrictFormatters = tt.strict
+			cfg.DryRun = tt.dryRun
+			g := newTestGenerator(cfg)
+			err := g.Generate()
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("Generate succeeded, want an error")
+				}
+				errs := g.Report().Errors()
+				if len(errs) != 1 || !strings.HasPrefix(errs[0].String(), tt.wantErr) || !strings.Contains(errs[0].String(), "syntax error") {
+					t.Errorf("errors = %q, want one starting with %q", errs, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			if tt.want != nil {
+				if got := readTree(t, out); !maps.Equal(got, tt.want) {
+					t.Errorf("output = %q, want %q", got, tt.want)
+				}
+			}
+			var warnings []string
+			for _, w := range g.Report().Warnings() {
+				warnings = append(warnings, w.String())
+			}
+			if tt.wantWarning == "" && len(warnings) > 0 {
+				t.Errorf("unexpected warnings %q", warnings)
+			}
+			if tt.wantWarning != "" && !slices.ContainsFunc(warnings, func(w string) bool { return strings.HasPrefix(w, tt.wantWarning) }) {
+				t.Errorf("no warning %q in %q", tt.wantWarning, warnings)
+			}
+		})
+	}
+}
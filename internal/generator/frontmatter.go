@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// frontMatterCache holds the variables declared in the front matter of a
+// template's files, collected once per template directory
+type frontMatterCache struct {
+	dir       string
+	variables []manifest.Variable
+	err       error
+}
+
+// frontMatterVariables returns the variables declared in the front matter
+// of the template's files, in walk order. Only the first declaration of a
+// name counts.
+func (g *Generator) frontMatterVariables() ([]manifest.Variable, error) {
+	g.frontMatterMu.Lock()
+	defer g.frontMatterMu.Unlock()
+
+	if g.frontMatter != nil && g.frontMatter.dir == g.cfg.TemplateDir {
+		return g.frontMatter.variables, g.frontMatter.err
+	}
+	// A missing template is reported by the caller
+	if _, err := g.statTemplate(g.cfg.TemplateDir); err != nil {
+		return nil, nil
+	}
+
+	// Ignored and no-scan files declare nothing, and the walk may run
+	// before the rest of the template rules are loaded
+	if err := g.loadIgnore(); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", IgnoreFileName, err)
+	}
+
+	var variables []manifest.Variable
+	declared := make(map[string]bool)
+	err := g.walkTemplate(func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == gitDirName {
+			return filepath.SkipDir
+		}
+		relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if skip, err := g.skipIgnored(relPath, info); skip {
+			return err
+		}
+		if skip, err := g.skipUnscanned(relPath, info); skip {
+			return err
+		}
+		if info.IsDir() || isControlFile(relPath) || !g.hasFrontMatterStart(path) {
+			return nil
+		}
+
+		content, err := g.readTemplateFile(path)
+		if err != nil {
+			return err
+		}
+		found, _, ok := manifest.ParseFrontMatter(content)
+		if !ok {
+			return nil
+		}
+		for _, v := range found {
+			if !declared[v.Name] {
+				declared[v.Name] = true
+				variables = append(variables, v)
+			}
+		}
+		return nil
+	})
+
+	g.frontMatter = &frontMatterCache{dir: g.cfg.TemplateDir, variables: variables, err: err}
+	return variables, err
+}
+
+// hasFrontMatterStart reports whether a template file starts with the
+// front-matter delimiter, reading only its first bytes
+func (g *Generator) hasFrontMatterStart(path string) bool {
+	file, err := g.openTemplateFile(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	start := make([]byte, len(manifest.FrontMatterDelimiter))
+	if _, err := io.ReadFull(file, start); err != nil {
+		return false
+	}
+	return bytes.Equal(start, []byte(manifest.FrontMatterDelimiter))
+}
+
+// withFrontMatter adds the variables declared in front matter to a
+// manifest, creating one if the template has none. Declarations in the
+// manifest file take precedence.
+func (g *Generator) withFrontMatter(m *manifest.Manifest) (*manifest.Manifest, error) {
+	variables, err := g.frontMatterVariables()
+	if err != nil || len(variables) == 0 {
+		return m, err
+	}
+	if m == nil {
+		m = &manifest.Manifest{}
+	}
+	for _, v := range variables {
+		if _, ok := m.Variable(v.Name); !ok {
+			m.Variables = append(m.Variables, v)
+		}
+	}
+	return m, nil
+}
+
+// stripFrontMatter removes a valid front-matter block from the start of
+// template content
+func stripFrontMatter(content []byte) []byte {
+	if _, body, ok := manifest.ParseFrontMatter(content); ok {
+		return body
+	}
+	return content
+}
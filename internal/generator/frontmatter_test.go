@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+// TestFrontMatterSkipsExcludedFiles checks that only files the generator
+// processes contribute front-matter declarations
+func TestFrontMatterSkipsExcludedFiles(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		IgnoreFileName:        "drafts\n",
+		"README.md":           "---\nvariables:\n  name: app\n---\n# {{name}}\n",
+		"drafts/a/b/notes.md": "---\nvariables:\n  draft: x\n---\n{{draft}}\n",
+		"vendor/lib.go":       "---\nvariables:\n  vendored: x\n---\n",
+		"docs/{{name}}.md":    "{{name}}\n",
+	})
+
+	cfg := testConfig(tmpl, out, nil)
+	cfg.NoScanGlobs = []string{"vendor"}
+	cfg.MaxDepth = 2
+	g := newTestGenerator(cfg)
+
+	// The first read may come before any other template rules are loaded
+	declared, err := g.frontMatterVariables()
+	if err != nil {
+		t.Fatalf("frontMatterVariables failed: %v", err)
+	}
+	if len(declared) != 1 || declared[0].Name != "name" {
+		t.Errorf("declared = %+v, want only name", declared)
+	}
+
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := map[string]string{
+		"README.md":     "# app\n",
+		"vendor/lib.go": "---\nvariables:\n  vendored: x\n---\n",
+		"docs/app.md":   "app\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
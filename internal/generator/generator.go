@@ -1,91 +1,381 @@
 package generator
 
 import (
+	"bytes"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/manifest"
 	"github.com/linxux/stencil/internal/replacer"
 )
 
+// BackupSuffix is appended to an output file's name to form its backup path
+const BackupSuffix = ".bak"
+
 // Generator handles the template generation process
 type Generator struct {
 	cfg      *config.Config
 	replacer *replacer.Replacer
+	report   *GenerationReport
+
+	// logger receives dry-run and status messages
+	logger Logger
+
+	// progress is called as each file completes
+	progress     func(ProgressEvent)
+	progressMu   sync.Mutex
+	progressDone int
+	progressETA  *etaEstimator
+
+	// attributes holds per-path format overrides for the current run
+	attributes []attributeRule
+
+	// ignore holds the template's ignore rules for the current run
+	ignore []ignoreRule
+
+	// aliases maps alias variable names to their canonical names
+	aliases map[string]string
+
+	// canonicalizer, when set, further maps extracted variable names after
+	// whitespace trimming and alias resolution
+	canonicalizer replacer.Canonicalizer
+
+	// fsys, when set, is the file system the template is read from instead
+	// of the OS
+	fsys fs.FS
+
+	// skipValidation skips the manifest's validate commands, for scratch
+	// runs that only compare output
+	skipValidation bool
+
+	// pathVariables records the variables substituted into the template and
+	// output directory settings
+	pathVariables map[string]bool
+
+	// frontMatter holds the variables declared in the front matter of the
+	// template's files
+	frontMatter   *frontMatterCache
+	frontMatterMu sync.Mutex
+
+	// contents caches template file reads for the lifetime of the generator
+	contents contentCache
+
+	// clock and rand are consulted by built-ins that depend on the time or
+	// randomness, so tests can make them deterministic
+	clock Clock
+	rand  Rand
+
+	// uuid is the run's {{__uuid__}}, drawn from rand on first use
+	uuidOnce sync.Once
+	uuid     string
 }
 
 // NewGenerator creates a new Generator instance
 func NewGenerator(cfg *config.Config) *Generator {
-	return &Generator{
-		cfg:      cfg,
-		replacer: replacer.NewReplacer(cfg.Variables, cfg.Formats),
+	g := &Generator{cfg: cfg, report: &GenerationReport{}, logger: defaultLogger(), clock: systemClock{}, rand: rand.Reader}
+	g.replacer = g.newReplacer(cfg.Variables)
+	if cfg.RenderFileValues && len(cfg.FileValues) > 0 {
+		g.renderFileValues()
 	}
+	return g
 }
 
-// Generate generates the project from template
-func (g *Generator) Generate() error {
+// renderFileValues substitutes the other variables into values that were
+// loaded from files, so shared snippets can themselves be templates
+func (g *Generator) renderFileValues() {
+	for _, key := range g.cfg.FileValues {
+		if value, ok := g.cfg.Variables[key]; ok {
+			g.cfg.Variables[key] = string(g.replacer.ReplaceInContent([]byte(value)))
+		}
+	}
+	g.replacer = g.newReplacer(g.cfg.Variables)
+}
+
+// newReplacer creates a Replacer for the given variables using the
+// generator's configuration
+func (g *Generator) newReplacer(variables map[string]string) *replacer.Replacer {
+	return g.newReplacerWithFormats(variables, g.cfg.Formats)
+}
+
+// newReplacerWithFormats creates a Replacer with format options overriding
+// the configured ones
+func (g *Generator) newReplacerWithFormats(variables map[string]string, formats config.FormatOptions) *replacer.Replacer {
+	variables = g.withAliases(variables)
+	if g.cfg.OnEmptyValue == config.EmptyValueKeepPlaceholder {
+		variables = withoutEmptyValues(variables)
+	}
+	r := replacer.NewReplacer(variables, formats)
+	r.SetCaseInsensitive(g.cfg.CaseInsensitiveVars)
+	r.SetTrimSpaces(g.cfg.TrimDelimiterSpaces)
+	r.SetLowercaseKeys(g.cfg.KeyNormalization == config.KeyNormalizationLower)
+	r.SetIndentValues(g.cfg.IndentValues)
+	return r
+}
+
+// prepare runs the checks shared by Generate and Render before anything is
+// planned: it validates the configuration, the template and output
+// directories and the variables, and fills in the template's defaults
+func (g *Generator) prepare() error {
+	if err := config.Validate(g.cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := g.ResolveConfigPaths(); err != nil {
+		return err
+	}
+
 	// Validate template directory
-	if _, err := os.Stat(g.cfg.TemplateDir); os.IsNotExist(err) {
+	if _, err := g.statTemplate(g.cfg.TemplateDir); errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("template directory does not exist: %s", g.cfg.TemplateDir)
 	}
 
+	if err := g.checkTemplateSandbox(); err != nil {
+		return err
+	}
+	if err := g.checkDirOverlap(); err != nil {
+		return err
+	}
+
+	// Spellings of one variable must agree before defaults fill any gaps
+	if err := g.loadAliases(); err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	if err := g.checkVariableConflicts(); err != nil {
+		return err
+	}
+
+	// Fill in defaults declared by the template manifest
+	supplied := g.suppliedVariables()
+	if err := g.applyManifestDefaults(); err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	if g.cfg.ExpandValues {
+		if err := g.expandValues(); err != nil {
+			return err
+		}
+	}
+
+	if err := g.validateVariables(); err != nil {
+		return err
+	}
+
+	if g.cfg.ErrorOnUnusedVars {
+		if err := g.checkUnusedVariables(supplied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate generates the project from template
+func (g *Generator) Generate() error {
+	if err := g.prepare(); err != nil {
+		return err
+	}
+
+	if g.cfg.Trial {
+		return g.trial()
+	}
+
+	if g.cfg.RequireCleanGit && !g.cfg.DryRun {
+		if err := checkCleanGit(g.cfg.OutputDir); err != nil {
+			return err
+		}
+	}
+
+	// Paths this run creates, removed again if it fails
+	cleanup := g.cfg.CleanupOnError && !g.cfg.DryRun
+	var created []string
+	if _, err := os.Lstat(g.cfg.OutputDir); cleanup && errors.Is(err, fs.ErrNotExist) {
+		created = append(created, g.cfg.OutputDir)
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(g.cfg.OutputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Walk through template directory
-	return filepath.Walk(g.cfg.TemplateDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	g.report = &GenerationReport{}
+
+	// Plan the walk, recording per-file problems in the report so that every
+	// issue is surfaced in one run
+	entries, err := g.plan()
+	if err == nil {
+		if cleanup {
+			created = append(created, g.missingTargets(entries)...)
 		}
+		err = g.write(entries)
+	}
+	if err != nil && cleanup {
+		g.removeCreated(created)
+	}
+	return err
+}
 
-		// Get relative path from template directory
-		relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
-		if err != nil {
+// write generates the planned entries into the output directory, then
+// writes the values file, prunes and validates
+func (g *Generator) write(entries []planEntry) error {
+	// Create directories first, in walk order, so that file workers never
+	// race on directory creation
+	var files []planEntry
+	for _, entry := range entries {
+		if !entry.info.IsDir() {
+			files = append(files, entry)
+			continue
+		}
+
+		targetPath := filepath.Join(g.cfg.OutputDir, entry.targetRel)
+		if g.cfg.DryRun {
+			g.logger.Info("[DRY RUN] Would create directory: "+targetPath, "path", targetPath)
+			continue
+		}
+		if err := os.MkdirAll(targetPath, entry.info.Mode()); err != nil {
+			g.report.Error(entry.relPath, err)
+			continue
+		}
+		g.logger.Debug("Created directory: "+targetPath, "path", targetPath)
+	}
+
+	g.processFiles(files)
+
+	if g.cfg.WriteValues && !g.report.HasErrors() {
+		if err := g.writeValues(); err != nil {
 			return err
 		}
+	}
 
-		// Skip the template directory itself
-		if relPath == "." {
-			return nil
+	// Remove stale files so the output mirrors the template. A file that
+	// failed to generate is missing from the report, so pruning after
+	// errors would delete its previous output.
+	if g.cfg.PruneOutput && !g.report.HasErrors() {
+		if err := g.prune(); err != nil {
+			return fmt.Errorf("failed to prune output directory: %w", err)
 		}
+	}
 
-		// Replace variables in path
-		targetPath := filepath.Join(g.cfg.OutputDir, g.replacer.ReplaceInPath(relPath))
+	if g.report.HasErrors() {
+		return fmt.Errorf("generation finished with %d error(s)", len(g.report.Errors()))
+	}
 
-		if info.IsDir() {
-			// Create directory
+	// The generated project must pass the template's own checks
+	if !g.cfg.DryRun && !g.skipValidation {
+		if err := g.runValidateCommands(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processFiles processes the planned files using the configured number of
+// workers. Results are recorded in plan order regardless of concurrency.
+func (g *Generator) processFiles(files []planEntry) {
+	errs := make([]error, len(files))
+	skipped := make([]bool, len(files))
+	g.resetProgress()
+	process := func(i int) {
+		targetPath := filepath.Join(g.cfg.OutputDir, files[i].targetRel)
+		if files[i].once && fileExists(targetPath) {
+			skipped[i] = true
 			if g.cfg.DryRun {
-				fmt.Printf("[DRY RUN] Would create directory: %s\n", targetPath)
-				return nil
+				g.logger.Info("[DRY RUN] Would keep existing file: "+targetPath, "path", targetPath)
 			}
-			return os.MkdirAll(targetPath, info.Mode())
+			g.notifyProgress(targetPath, len(files), nil)
+			return
+		}
+		if files[i].merge {
+			errs[i] = g.mergeFile(files[i], targetPath)
+		} else {
+			errs[i] = g.processFile(files[i], targetPath)
+		}
+		if errs[i] == nil && !g.cfg.DryRun && len(g.cfg.Formatters) > 0 {
+			errs[i] = g.runFormatters(targetPath, files[i].targetRel)
 		}
+		// Timestamps are set last so formatters don't change them
+		if errs[i] == nil && !g.cfg.DryRun && g.cfg.PreserveTimestamps {
+			errs[i] = preserveTimestamps(targetPath, files[i].info)
+		}
+		if errs[i] == nil && !g.cfg.DryRun {
+			g.logger.Debug("Generated file: "+targetPath, "path", targetPath)
+		}
+		g.notifyProgress(targetPath, len(files), errs[i])
+	}
 
-		// Process file
-		return g.processFile(path, targetPath, info)
-	})
+	workers := g.cfg.Concurrency
+	if workers <= 1 {
+		// Serial mode keeps output deterministic for debugging
+		for i := range files {
+			process(i)
+		}
+	} else {
+		queueSize := g.cfg.QueueSize
+		if queueSize <= 0 {
+			queueSize = workers
+		}
+
+		queue := make(chan int, queueSize)
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					process(i)
+				}
+			}()
+		}
+		for i := range files {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+	}
+
+	for i, entry := range files {
+		if errs[i] != nil {
+			g.report.Error(entry.relPath, errs[i])
+			continue
+		}
+		targetPath := filepath.Join(g.cfg.OutputDir, entry.targetRel)
+		g.report.Files = append(g.report.Files, targetPath)
+		if skipped[i] {
+			g.report.Skipped = append(g.report.Skipped, targetPath)
+		}
+	}
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
 }
 
 // processFile processes a single template file
-func (g *Generator) processFile(sourcePath, targetPath string, info os.FileInfo) error {
-	// Read source file
-	sourceFile, err := os.Open(sourcePath)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer sourceFile.Close()
+func (g *Generator) processFile(entry planEntry, targetPath string) error {
+	sourcePath, info := entry.sourcePath, entry.info
 
 	// Check if file is binary
-	isBinary := replacer.IsBinaryFile(sourcePath)
+	isBinary := g.isBinaryTemplateFile(sourcePath)
 
-	if isBinary {
-		// Copy binary file as-is
+	// Files in no-scan directories are copied as-is like binary files
+	if isBinary || entry.verbatim {
 		if g.cfg.DryRun {
-			fmt.Printf("[DRY RUN] Would copy binary file: %s -> %s\n", sourcePath, targetPath)
+			kind := "binary file"
+			if entry.verbatim {
+				kind = "unscanned file"
+			}
+			g.logger.Info(fmt.Sprintf("[DRY RUN] Would copy %s: %s -> %s", kind, sourcePath, targetPath),
+				"source", sourcePath, "path", targetPath)
 			return nil
 		}
 
@@ -94,23 +384,30 @@ func (g *Generator) processFile(sourcePath, targetPath string, info os.FileInfo)
 			return err
 		}
 
-		return g.copyFile(sourcePath, targetPath)
+		if g.cfg.Backup {
+			content, err := g.readTemplateFile(sourcePath)
+			if err != nil {
+				return err
+			}
+			if err := g.backupIfChanged(targetPath, content); err != nil {
+				return err
+			}
+		}
+
+		return g.copyFile(sourcePath, targetPath, info.Mode())
 	}
 
-	// Read content
-	content, err := io.ReadAll(sourceFile)
+	// Read content and replace variables
+	newContent, err := g.renderText(sourcePath, entry.variables)
 	if err != nil {
-		return fmt.Errorf("failed to read file content: %w", err)
+		return err
 	}
 
-	// Replace variables in content
-	newContent := g.replacer.ReplaceInContent(content)
-
 	// Write target file
 	if g.cfg.DryRun {
-		fmt.Printf("[DRY RUN] Would create file: %s\n", targetPath)
-		fmt.Printf("[DRY RUN] Content preview (first 200 chars): %s\n",
-			truncateString(string(newContent), 200))
+		preview := truncateString(string(newContent), 200)
+		g.logger.Info(fmt.Sprintf("[DRY RUN] Would create file: %s\n[DRY RUN] Content preview (first 200 chars): %s", targetPath, preview),
+			"path", targetPath, "preview", preview)
 		return nil
 	}
 
@@ -119,13 +416,16 @@ func (g *Generator) processFile(sourcePath, targetPath string, info os.FileInfo)
 		return err
 	}
 
-	targetFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
-	if err != nil {
-		return fmt.Errorf("failed to create target file: %w", err)
+	if g.cfg.Backup {
+		if err := g.backupIfChanged(targetPath, newContent); err != nil {
+			return err
+		}
 	}
-	defer targetFile.Close()
 
-	_, err = targetFile.Write(newContent)
+	err = WriteFileAtomic(targetPath, info.Mode(), func(w io.Writer) error {
+		_, err := w.Write(newContent)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to write target file: %w", err)
 	}
@@ -133,87 +433,374 @@ func (g *Generator) processFile(sourcePath, targetPath string, info os.FileInfo)
 	return nil
 }
 
-// copyFile copies a file from source to destination
-func (g *Generator) copyFile(source, destination string) error {
-	src, err := os.Open(source)
+// renderText reads a text template file and returns its content with
+// variables replaced. Non-nil variables replace the configured ones.
+func (g *Generator) renderText(sourcePath string, variables map[string]string) ([]byte, error) {
+	content, err := g.readTemplateFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+	content = stripFrontMatter(content)
+
+	relPath, err := filepath.Rel(g.cfg.TemplateDir, sourcePath)
+	if err != nil {
+		relPath = sourcePath
+	}
+
+	// Per-path attributes may override the global formats
+	formats := g.formatsFor(relPath)
+	if !g.cfg.ReplaceInContent {
+		return g.addHeader(relPath, content, variables, formats), nil
+	}
+	r := g.replacerFor(variables)
+	if formats != g.cfg.Formats {
+		if variables == nil {
+			variables = g.cfg.Variables
+		}
+		r = g.newReplacerWithFormats(variables, formats)
+	}
+
+	// Author comments are dropped first, and conditional directives are
+	// resolved before substitution so that excluded lines are never checked
+	// for placeholders
+	content = replacer.StripComments(content, formats)
+	content, err = r.ProcessDirectives(content, g.cfg.DirectivePrefixes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directive: %w", err)
+	}
+
+	content, err = g.applyEmptyValues(r, content)
+	if err != nil {
+		return nil, err
+	}
+
+	newContent := content
+	if g.cfg.TypedStructured && isStructuredFile(relPath) {
+		newContent = r.ReplaceTyped(newContent)
+	}
+	newContent = r.ReplaceInContent(newContent)
+	g.checkContent(relPath, formats, content, newContent)
+	return g.addHeader(relPath, newContent, variables, formats), nil
+}
+
+// isStructuredFile reports whether a path is a JSON or YAML file, where
+// typed substitution applies
+func isStructuredFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// checkContent records warnings for malformed and unresolved placeholders
+func (g *Generator) checkContent(relPath string, formats config.FormatOptions, content, newContent []byte) {
+	for _, line := range replacer.UnterminatedLines(content, formats) {
+		g.report.Warn(relPath, "line %d: unterminated placeholder", line)
+	}
+
+	for _, v := range replacer.ExtractVariablesFromFile(newContent, formats) {
+		g.report.Warn(relPath, "unresolved variable: %s", g.normalizeKey(v))
+	}
+}
+
+// backupIfChanged copies an existing target to its backup path when the new
+// content differs from it
+func (g *Generator) backupIfChanged(targetPath string, newContent []byte) error {
+	existing, err := os.ReadFile(targetPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read existing file for backup: %w", err)
+	}
+	if bytes.Equal(existing, newContent) {
+		return nil
+	}
+
+	info, err := os.Stat(targetPath)
+	if err != nil {
+		return err
+	}
+
+	backupPath := targetPath + BackupSuffix
+	err = WriteFileAtomic(backupPath, info.Mode(), func(w io.Writer) error {
+		_, err := w.Write(existing)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	g.report.addBackup(backupPath)
+	return nil
+}
+
+// copyFile copies a file from source to destination with the given mode
+func (g *Generator) copyFile(source, destination string, mode os.FileMode) error {
+	src, err := g.openTemplateFile(source)
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 
-	dst, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	return WriteFileAtomic(destination, mode, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// WriteFileAtomic writes a file via a sibling temp file that is renamed into
+// place on success, so an existing target is never left truncated
+func WriteFileAtomic(path string, mode os.FileMode, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".stencil-*")
 	if err != nil {
 		return err
 	}
-	defer dst.Close()
+	tmpPath := tmp.Name()
 
-	_, err = io.Copy(dst, src)
-	return err
+	// Remove the temp file unless it was renamed into place
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode.Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// RenderFile renders a single template file, given by its path relative to
+// the template directory, and returns the result without writing anything.
+// Binary files are returned unchanged.
+func (g *Generator) RenderFile(relPath string) ([]byte, error) {
+	if !filepath.IsLocal(relPath) {
+		return nil, fmt.Errorf("path is outside the template: %s", relPath)
+	}
+	sourcePath := filepath.Join(g.cfg.TemplateDir, relPath)
+
+	if err := g.loadRules(); err != nil {
+		return nil, err
+	}
+
+	info, err := g.statTemplate(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat template file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("not a file: %s", relPath)
+	}
+
+	if g.isBinaryTemplateFile(sourcePath) {
+		return g.readTemplateFile(sourcePath)
+	}
+
+	return g.renderText(sourcePath, nil)
 }
 
 // ExtractVariables extracts all variables from the template
 func (g *Generator) ExtractVariables() (map[string]string, error) {
-	variables := make(map[string]bool)
+	variables, _, err := g.scanTemplate()
+	if err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(g.cfg.TemplateDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	m, err := g.loadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	defaults := g.manifestDefaults(m)
 
-		if info.IsDir() {
-			// Extract variables from directory names
-			relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
-			if err != nil {
-				return err
+	// Convert to map with manifest defaults (or empty values)
+	result := make(map[string]string)
+	for _, v := range variables {
+		result[v] = defaults[v]
+	}
+
+	// Derived variables are computed, so their source is asked for instead
+	if m != nil {
+		for name, spec := range m.Derived {
+			if _, ok := result[name]; !ok {
+				continue
 			}
-			if relPath != "." {
-				for _, v := range replacer.ExtractVariablesFromPath(relPath, g.cfg.Formats) {
-					variables[v] = true
+			delete(result, name)
+			if source, _, err := parseDerived(spec); err == nil {
+				if _, ok := result[source]; !ok {
+					result[source] = defaults[source]
 				}
 			}
-			return nil
 		}
+	}
 
-		// Extract variables from file names
-		relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
-		if err != nil {
-			return err
+	return result, nil
+}
+
+// normalizeKey applies the configured key normalization to an extracted
+// placeholder key
+func (g *Generator) normalizeKey(key string) string {
+	if g.cfg.TrimDelimiterSpaces {
+		key = strings.TrimSpace(key)
+	}
+	if g.cfg.KeyNormalization == config.KeyNormalizationLower {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+// ConfiguredVariables returns a copy of the generator's variables: those
+// from config files, values files and -v, before any prompting
+func (g *Generator) ConfiguredVariables() map[string]string {
+	return maps.Clone(g.cfg.Variables)
+}
+
+// SetVariables updates the generator's variables
+func (g *Generator) SetVariables(variables map[string]string) {
+	g.cfg.Variables = variables
+	g.replacer = g.newReplacer(variables)
+}
+
+// BuiltinVariables returns the variables the generator derives from its own
+// paths, clock and source of randomness, which manifest defaults may
+// reference:
+//
+//	{{__output_basename__}}  base name of the output directory
+//	{{__template_name__}}    manifest name, or base name of the template directory
+//	{{__year__}}             current year
+//	{{__uuid__}}             random UUID, the same for the whole run
+func (g *Generator) BuiltinVariables() map[string]string {
+	builtins := map[string]string{
+		"__output_basename__": absBase(g.cfg.OutputDir),
+		"__template_name__":   absBase(g.cfg.TemplateDir),
+		"__year__":            strconv.Itoa(g.now().Year()),
+	}
+	if uuid := g.runUUID(); uuid != "" {
+		builtins["__uuid__"] = uuid
+	}
+	if m, err := g.loadManifest(); err == nil && m != nil && m.Name != "" {
+		builtins["__template_name__"] = m.Name
+	}
+	return builtins
+}
+
+// manifestDefaults returns the manifest's declared defaults with built-in
+// variables substituted and environment references expanded
+func (g *Generator) manifestDefaults(m *manifest.Manifest) map[string]string {
+	defaults := m.Defaults()
+	if len(defaults) == 0 {
+		return defaults
+	}
+
+	r := g.newReplacer(g.BuiltinVariables())
+	for name, value := range defaults {
+		defaults[name] = expandEnvRefs(string(r.ReplaceInContent([]byte(value))))
+	}
+	return defaults
+}
+
+// envRefPattern matches an environment variable reference, "${NAME}"
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvRefs replaces "${NAME}" references in a default value with the
+// value of the environment variable, or nothing when it is unset. A bare
+// "$NAME" is left alone, so defaults containing '$' need no escaping.
+func expandEnvRefs(value string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	return envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		return os.Getenv(envRefPattern.FindStringSubmatch(ref)[1])
+	})
+}
+
+// absBase returns the base name of a path after making it absolute, so that
+// "." resolves to the current directory's name
+func absBase(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Base(path)
+}
+
+// applyManifestDefaults fills in variables declared in the template manifest
+// that have not been provided
+func (g *Generator) applyManifestDefaults() error {
+	m, err := g.loadManifest()
+	if err != nil || m == nil {
+		return err
+	}
+
+	if g.cfg.Variables == nil {
+		g.cfg.Variables = make(map[string]string)
+	}
+
+	// A value given under an alias answers its canonical variable
+	changed := len(m.Aliases) > 0 || len(g.aliases) > 0
+	g.aliases = m.Aliases
+	for alias, canonical := range m.Aliases {
+		if _, ok := g.cfg.Variables[canonical]; ok {
+			continue
 		}
-		for _, v := range replacer.ExtractVariablesFromPath(relPath, g.cfg.Formats) {
-			variables[v] = true
+		if value, ok := g.cfg.Variables[alias]; ok {
+			g.cfg.Variables[canonical] = value
 		}
+	}
 
-		// Extract variables from file content
-		if !replacer.IsBinaryFile(path) {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			for _, v := range replacer.ExtractVariablesFromFile(content, g.cfg.Formats) {
-				variables[v] = true
-			}
+	for name, value := range g.manifestDefaults(m) {
+		if _, ok := g.cfg.Variables[name]; !ok {
+			g.cfg.Variables[name] = value
+			changed = true
 		}
+	}
 
-		return nil
-	})
+	if g.cfg.AllowCommandDefaults {
+		computed, err := g.applyCommandDefaults(m)
+		if err != nil {
+			return err
+		}
+		changed = changed || computed
+	}
 
+	derived, err := g.applyDerived(m)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	changed = changed || derived
 
-	// Convert to map with empty values
-	result := make(map[string]string)
-	for v := range variables {
-		result[v] = ""
+	if changed {
+		g.replacer = g.newReplacer(g.cfg.Variables)
 	}
+	return nil
+}
 
-	return result, nil
+// PostMessage returns the manifest's post-generation message with variables
+// substituted, or an empty string if the template declares none
+func (g *Generator) PostMessage() (string, error) {
+	m, err := g.loadManifest()
+	if err != nil || m == nil || m.PostMessage == "" {
+		return "", err
+	}
+	return string(g.replacer.ReplaceInContent([]byte(m.PostMessage))), nil
 }
 
-// SetVariables updates the generator's variables
-func (g *Generator) SetVariables(variables map[string]string) {
-	g.cfg.Variables = variables
-	g.replacer = replacer.NewReplacer(variables, g.cfg.Formats)
+// Report returns the report of the most recent generation run
+func (g *Generator) Report() *GenerationReport {
+	return g.report
 }
 
 // TemplateDir returns the template directory path
@@ -0,0 +1,807 @@
+package generator
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+)
+
+// writeTree creates files under dir from a map of slash-separated relative
+// paths to contents
+func writeTree(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the regular files under dir keyed by slash-separated
+// relative path. A missing dir has no files.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// testConfig returns a default configuration generating templateDir into
+// outputDir with vars
+func testConfig(templateDir, outputDir string, vars map[string]string) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.TemplateDir = templateDir
+	cfg.OutputDir = outputDir
+	for key, value := range vars {
+		cfg.Variables[key] = value
+	}
+	return cfg
+}
+
+// newTestGenerator returns a generator for cfg that logs nothing
+func newTestGenerator(cfg *config.Config) *Generator {
+	g := NewGenerator(cfg)
+	g.SetLogger(NewConsoleLogger(io.Discard, io.Discard))
+	return g
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	errWrite := errors.New("disk full")
+
+	tests := []struct {
+		name     string
+		existing string // content already at the path, if any
+		write    func(w io.Writer) error
+		wantErr  error
+		want     string
+		wantFile bool
+	}{
+		{
+			name:     "new file",
+			write:    writeString("new"),
+			want:     "new",
+			wantFile: true,
+		},
+		{
+			name:     "replaces existing",
+			existing: "old",
+			write:    writeString("new"),
+			want:     "new",
+			wantFile: true,
+		},
+		{
+			name:     "failed write keeps existing",
+			existing: "old",
+			write: func(w io.Writer) error {
+				io.WriteString(w, "partial")
+				return errWrite
+			},
+			wantErr:  errWrite,
+			want:     "old",
+			wantFile: true,
+		},
+		{
+			name: "failed write creates nothing",
+			write: func(w io.Writer) error {
+				io.WriteString(w, "partial")
+				return errWrite
+			},
+			wantErr: errWrite,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.existing != "" {
+				writeTree(t, dir, map[string]string{"a.txt": tt.existing})
+			}
+
+			err := WriteFileAtomic(filepath.Join(dir, "a.txt"), 0644, tt.write)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteFileAtomic error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := map[string]string{}
+			if tt.wantFile {
+				want["a.txt"] = tt.want
+			}
+			// No temp file is left behind
+			if got := readTree(t, dir); !maps.Equal(got, want) {
+				t.Errorf("directory = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// writeString returns a write function writing s
+func writeString(s string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+// TestGenerateKeepsMode checks that generated text and binary files keep
+// the template file's mode bits
+func TestGenerateKeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not preserved on Windows")
+	}
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	files := map[string]os.FileMode{
+		"run.sh":     0755,
+		"bin/tool":   0755,
+		"README.md":  0644,
+		"secret.txt": 0600,
+	}
+	writeTree(t, tmpl, map[string]string{
+		"run.sh":     "#!/bin/sh\necho {{name}}\n",
+		"bin/tool":   "\x7fELF\x00\x01",
+		"README.md":  "# {{name}}\n",
+		"secret.txt": "{{name}}",
+	})
+	for rel, mode := range files {
+		if err := os.Chmod(filepath.Join(tmpl, filepath.FromSlash(rel)), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app"})).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for rel, want := range files {
+		info, err := os.Stat(filepath.Join(out, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestCaseInsensitiveVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "mixed spellings resolve",
+			vars: map[string]string{"projectname": "app"},
+			want: map[string]string{"a.txt": "app", "b.txt": "app app", "app/c.txt": "app"},
+		},
+		{
+			name: "agreeing variants",
+			vars: map[string]string{"projectname": "app", "ProjectName": "app"},
+			want: map[string]string{"a.txt": "app", "b.txt": "app app", "app/c.txt": "app"},
+		},
+		{
+			name:    "conflicting variants",
+			vars:    map[string]string{"projectname": "app", "ProjectName": "other"},
+			wantErr: "conflicting values for variable",
+		},
+	}
+
+	template := map[string]string{
+		"a.txt":                 "{{ProjectName}}",
+		"b.txt":                 "{{PROJECTNAME}} %projectName%",
+		"__projectname__/c.txt": "<<projectname>>",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.CaseInsensitiveVars = true
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCaseInsensitiveExtract checks that case variants of one variable are
+// reported once, under the first spelling seen
+func TestCaseInsensitiveExtract(t *testing.T) {
+	tmpl := t.TempDir()
+	writeTree(t, tmpl, map[string]string{
+		"a.txt": "{{ProjectName}} {{author}}",
+		"b.txt": "{{PROJECTNAME}} {{projectname}} {{Author}}",
+	})
+
+	for _, tt := range []struct {
+		insensitive bool
+		want        []string
+	}{
+		{insensitive: true, want: []string{"ProjectName", "author"}},
+		{insensitive: false, want: []string{"Author", "PROJECTNAME", "ProjectName", "author", "projectname"}},
+	} {
+		cfg := testConfig(tmpl, t.TempDir(), nil)
+		cfg.CaseInsensitiveVars = tt.insensitive
+		vars, err := newTestGenerator(cfg).ExtractVariables()
+		if err != nil {
+			t.Fatalf("ExtractVariables failed: %v", err)
+		}
+		if got := slices.Sorted(maps.Keys(vars)); !slices.Equal(got, tt.want) {
+			t.Errorf("insensitive=%v: variables = %q, want %q", tt.insensitive, got, tt.want)
+		}
+	}
+}
+
+func TestFileValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		render bool
+		want   string
+	}{
+		{name: "verbatim", want: "// Copyright {{author}}\npackage main\n"},
+		{name: "rendered", render: true, want: "// Copyright Ada\npackage main\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"main.go": "{{header}}package main\n"})
+			writeTree(t, dir, map[string]string{"snippets/header.tmpl": "// Copyright {{author}}\n"})
+
+			vars := map[string]string{"header": "@snippets/header.tmpl", "author": "Ada"}
+			loaded, err := config.ResolveFileValues(vars, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := testConfig(tmpl, out, vars)
+			cfg.FileValues = loaded
+			cfg.RenderFileValues = tt.render
+			if err := newTestGenerator(cfg).Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out)["main.go"]; got != tt.want {
+				t.Errorf("main.go = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimDelimiterSpaces(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{"a.txt": "{{ name }} {{name}} {{  name}}\n"})
+
+	cfg := testConfig(tmpl, out, nil)
+	cfg.TrimDelimiterSpaces = true
+	vars, err := newTestGenerator(cfg).ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if got := slices.Sorted(maps.Keys(vars)); !slices.Equal(got, []string{"name"}) {
+		t.Errorf("variables = %q, want [name]", got)
+	}
+
+	cfg = testConfig(tmpl, out, map[string]string{"name": "app"})
+	cfg.TrimDelimiterSpaces = true
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got := readTree(t, out)["a.txt"]; got != "app app app\n" {
+		t.Errorf("a.txt = %q, want %q", got, "app app app\n")
+	}
+}
+
+func TestKeyNormalization(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{"__ProjectName__/a.txt": "{{ProjectName}} {{PROJECTNAME}}\n"})
+
+	cfg := testConfig(tmpl, out, nil)
+	cfg.KeyNormalization = config.KeyNormalizationLower
+	vars, err := newTestGenerator(cfg).ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if got := slices.Sorted(maps.Keys(vars)); !slices.Equal(got, []string{"projectname"}) {
+		t.Errorf("variables = %q, want [projectname]", got)
+	}
+
+	// Only the keys are lowercased; the value keeps its case
+	cfg = testConfig(tmpl, out, map[string]string{"projectname": "MyApp"})
+	cfg.KeyNormalization = config.KeyNormalizationLower
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got, want := readTree(t, out), map[string]string{"MyApp/a.txt": "MyApp MyApp\n"}; !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestBuiltinPathDefaults(t *testing.T) {
+	variables := `"variables": [
+		{"name": "project_name", "default": "{{__output_basename__}}"},
+		{"name": "module", "default": "example.com/<<__output_basename__>>"},
+		{"name": "origin", "default": "from {{__template_name__}}"}
+	]`
+
+	tests := []struct {
+		name     string
+		manifest string
+		output   string // relative to the working directory
+		want     map[string]string
+	}{
+		{
+			name:     "directory names",
+			manifest: "{" + variables + "}",
+			output:   "my-service",
+			want:     map[string]string{"project_name": "my-service", "module": "example.com/my-service", "origin": "from go-template"},
+		},
+		{
+			name:     "relative output directory",
+			manifest: "{" + variables + "}",
+			output:   "nested/../other-service/.",
+			want:     map[string]string{"project_name": "other-service", "module": "example.com/other-service", "origin": "from go-template"},
+		},
+		{
+			name:     "manifest name",
+			manifest: `{"name": "Go Service", ` + variables + "}",
+			output:   "my-service",
+			want:     map[string]string{"project_name": "my-service", "module": "example.com/my-service", "origin": "from Go Service"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			tmpl := filepath.Join(dir, "go-template")
+			writeTree(t, tmpl, map[string]string{
+				"README.md":  "# {{project_name}} {{module}} {{origin}}\n",
+				manifestFile: tt.manifest,
+			})
+
+			got, err := newTestGenerator(testConfig(tmpl, tt.output, nil)).ExtractVariables()
+			if err != nil {
+				t.Fatalf("ExtractVariables failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("suggested defaults = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceToggles(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    bool
+		content  bool
+		want     map[string]string
+		wantVars []string
+	}{
+		{
+			name:     "both",
+			paths:    true,
+			content:  true,
+			want:     map[string]string{"app/app.txt": "hello app"},
+			wantVars: []string{"greeting", "name"},
+		},
+		{
+			name:     "paths only",
+			paths:    true,
+			want:     map[string]string{"app/app.txt": "{{greeting}} {{name}}"},
+			wantVars: []string{"name"},
+		},
+		{
+			name:     "content only",
+			content:  true,
+			want:     map[string]string{"__name__/{{name}}.txt": "hello app"},
+			wantVars: []string{"greeting", "name"},
+		},
+		{
+			name:     "neither",
+			want:     map[string]string{"__name__/{{name}}.txt": "{{greeting}} {{name}}"},
+			wantVars: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"__name__/{{name}}.txt": "{{greeting}} {{name}}"})
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app", "greeting": "hello"})
+			cfg.ReplaceInPaths = tt.paths
+			cfg.ReplaceInContent = tt.content
+			g := newTestGenerator(cfg)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+
+			vars, err := g.ExtractVariables()
+			if err != nil {
+				t.Fatalf("ExtractVariables failed: %v", err)
+			}
+			if got := slices.Sorted(maps.Keys(vars)); !slices.Equal(got, tt.wantVars) {
+				t.Errorf("variables = %q, want %q", got, tt.wantVars)
+			}
+		})
+	}
+}
+
+// TestNestedTemplate checks that a meta-template's second-stage placeholders
+// survive verbatim when only one delimiter set is enabled, and are neither
+// extracted nor warned about
+func TestNestedTemplate(t *testing.T) {
+	template := map[string]string{
+		"__name__/[[module]].go.tmpl": "package {{name}}\n\n// [[module]] by [[author]]\nvar x = \"[[unclosed\"\n",
+		"README.md":                   "# {{name}}\nRun stencil -v module=[[module]]\n",
+	}
+
+	tests := []struct {
+		name     string
+		formats  config.FormatOptions
+		vars     map[string]string
+		want     map[string]string
+		wantVars []string
+	}{
+		{
+			name:    "braces only",
+			formats: config.FormatOptions{EnableBraces: true},
+			vars:    map[string]string{"name": "app"},
+			want: map[string]string{
+				"__name__/[[module]].go.tmpl": "package app\n\n// [[module]] by [[author]]\nvar x = \"[[unclosed\"\n",
+				"README.md":                   "# app\nRun stencil -v module=[[module]]\n",
+			},
+			wantVars: []string{"name"},
+		},
+		{
+			name:    "custom brackets only",
+			formats: config.FormatOptions{CustomOpen: "[[", CustomClose: "]]"},
+			vars:    map[string]string{"module": "core", "author": "Ada"},
+			want: map[string]string{
+				"__name__/core.go.tmpl": "package {{name}}\n\n// core by Ada\nvar x = \"[[unclosed\"\n",
+				"README.md":             "# {{name}}\nRun stencil -v module=core\n",
+			},
+			wantVars: []string{"author", "module"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.Formats = tt.formats
+			g := newTestGenerator(cfg)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			if issues := g.Report().Issues; len(issues) > 0 {
+				t.Errorf("unexpected issues %q", issues)
+			}
+
+			vars, err := g.ExtractVariables()
+			if err != nil {
+				t.Fatalf("ExtractVariables failed: %v", err)
+			}
+			if got := slices.Sorted(maps.Keys(vars)); !slices.Equal(got, tt.wantVars) {
+				t.Errorf("variables = %q, want %q", got, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestTypedStructured(t *testing.T) {
+	template := map[string]string{
+		"config.json": `{"name": "{{name}}", "port": "{{port}}", "debug": "{{debug}}"}` + "\n",
+		"config.yaml": "port: \"{{port}}\"\ndebug: '{{debug}}'\n",
+		"notes.txt":   `port "{{port}}"` + "\n",
+	}
+
+	tests := []struct {
+		name  string
+		typed bool
+		want  map[string]string
+	}{
+		{
+			name:  "typed",
+			typed: true,
+			want: map[string]string{
+				"config.json": `{"name": "app", "port": 8080, "debug": true}` + "\n",
+				"config.yaml": "port: 8080\ndebug: true\n",
+				"notes.txt":   `port "8080"` + "\n",
+			},
+		},
+		{
+			name: "off by default",
+			want: map[string]string{
+				"config.json": `{"name": "app", "port": "8080", "debug": "true"}` + "\n",
+				"config.yaml": "port: \"8080\"\ndebug: 'true'\n",
+				"notes.txt":   `port "8080"` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app", "port": "8080", "debug": "true"})
+			cfg.TypedStructured = tt.typed
+			if err := newTestGenerator(cfg).Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorComments(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		"go.mod": "{{!-- Keep in sync with the CI matrix.\n     {{go_version}} comes from {{ci_file}}. --}}\nmodule {{name}}\n\ngo {{go_version}} {{!-- minimum --}}\n",
+	})
+
+	g := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app", "go_version": "1.22"}))
+	vars, err := g.ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if got, want := slices.Sorted(maps.Keys(vars)), []string{"go_version", "name"}; !slices.Equal(got, want) {
+		t.Errorf("variables = %q, want %q", got, want)
+	}
+
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := map[string]string{"go.mod": "module app\n\ngo 1.22 \n"}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestForceTextReplacement(t *testing.T) {
+	const content = "\x00header {{name}}\n"
+
+	tests := []struct {
+		name  string
+		force bool
+		want  string
+	}{
+		{name: "binary copied as-is", want: content},
+		{name: "forced text replacement", force: true, want: "\x00header app\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"data.txt": content, "{{name}}.bin": "\x00"})
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.ForceTextReplacement = tt.force
+			if err := newTestGenerator(cfg).Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			want := map[string]string{"data.txt": tt.want, "app.bin": "\x00"}
+			if got := readTree(t, out); !maps.Equal(got, want) {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAllowedExtensions(t *testing.T) {
+	template := map[string]string{
+		"main.go":        "package {{name}}\n",
+		"README.MD":      "# {{name}}\n",
+		"Makefile":       "build: {{name}}\n",
+		"deploy.sh":      "rm -rf {{target}}\n",
+		"docs/notes.txt": "{{author}}\n",
+	}
+
+	tests := []struct {
+		name     string
+		allowed  []string
+		want     map[string]string
+		wantVars []string
+	}{
+		{
+			name:    "allowlist",
+			allowed: []string{".go", "md", ""},
+			want: map[string]string{
+				"main.go":   "package app\n",
+				"README.MD": "# app\n",
+				"Makefile":  "build: app\n",
+			},
+			wantVars: []string{"name"},
+		},
+		{
+			name:     "no extensionless files",
+			allowed:  []string{".go"},
+			want:     map[string]string{"main.go": "package app\n"},
+			wantVars: []string{"name"},
+		},
+		{
+			name: "everything by default",
+			want: map[string]string{
+				"main.go":        "package app\n",
+				"README.MD":      "# app\n",
+				"Makefile":       "build: app\n",
+				"deploy.sh":      "rm -rf out\n",
+				"docs/notes.txt": "me\n",
+			},
+			wantVars: []string{"author", "name", "target"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app", "target": "out", "author": "me"})
+			cfg.AllowedExtensions = tt.allowed
+			g := newTestGenerator(cfg)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+
+			// Skipped files are not read for variables either
+			vars, err := g.ExtractVariables()
+			if err != nil {
+				t.Fatalf("ExtractVariables failed: %v", err)
+			}
+			if got := slices.Sorted(maps.Keys(vars)); !slices.Equal(got, tt.wantVars) {
+				t.Errorf("variables = %q, want %q", got, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestEnvDefaults(t *testing.T) {
+	t.Setenv("STENCIL_TEST_USER", "ada")
+	t.Setenv("STENCIL_TEST_UNSET", "")
+	os.Unsetenv("STENCIL_TEST_UNSET")
+
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		manifestFile: `{"variables": [
+			{"name": "author", "default": "${STENCIL_TEST_USER}"},
+			{"name": "email", "default": "${STENCIL_TEST_UNSET}"},
+			{"name": "home", "default": "${STENCIL_TEST_USER}@${STENCIL_TEST_UNSET}example.com"},
+			{"name": "price", "default": "$STENCIL_TEST_USER costs $5"}
+		]}`,
+		"AUTHORS": "{{author}} <{{email}}> {{home}} {{price}}\n",
+	})
+
+	// Prompt defaults are expanded
+	g := newTestGenerator(testConfig(tmpl, out, nil))
+	vars, err := g.ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	want := map[string]string{
+		"author": "ada",
+		"email":  "",
+		"home":   "ada@example.com",
+		"price":  "$STENCIL_TEST_USER costs $5",
+	}
+	if !maps.Equal(vars, want) {
+		t.Errorf("variables = %q, want %q", vars, want)
+	}
+
+	// So are defaults applied without prompting
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got, want := readTree(t, out)["AUTHORS"], "ada <> ada@example.com $STENCIL_TEST_USER costs $5\n"; got != want {
+		t.Errorf("AUTHORS = %q, want %q", got, want)
+	}
+}
+
+func TestNoScanGlobs(t *testing.T) {
+	template := map[string]string{
+		"main.go":                                 "package {{name}}\n",
+		"vendor/lib/lib.go":                       "// {{vendored}}\n",
+		"web/node_modules/pkg/{{index}}.js":       "module.exports = '{{npm}}'\n",
+		"{{name}}/node_modules/__dep__/README.md": "{{dep}}\n",
+		"web/app.js":                              "const app = '{{name}}'\n",
+	}
+
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, template)
+
+	cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+	cfg.NoScanGlobs = []string{"vendor", "node_modules"}
+	g := newTestGenerator(cfg)
+
+	// Nothing inside a no-scan directory is prompted for
+	vars, err := g.ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if got, want := slices.Sorted(maps.Keys(vars)), []string{"name"}; !slices.Equal(got, want) {
+		t.Errorf("variables = %q, want %q", got, want)
+	}
+
+	// Their files are copied unchanged, under substituted parent directories
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := map[string]string{
+		"main.go":                            "package app\n",
+		"vendor/lib/lib.go":                  "// {{vendored}}\n",
+		"web/node_modules/pkg/{{index}}.js":  "module.exports = '{{npm}}'\n",
+		"app/node_modules/__dep__/README.md": "{{dep}}\n",
+		"web/app.js":                         "const app = 'app'\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
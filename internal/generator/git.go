@@ -0,0 +1,38 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gitDirName is the git metadata directory, which is never read from the
+// template nor touched in the output
+const gitDirName = ".git"
+
+// checkCleanGit returns an error if dir is inside a git working tree with
+// uncommitted changes. It is best-effort: a missing output directory, a
+// missing git binary or a directory outside a repository pass the check.
+func checkCleanGit(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+
+	inside, err := exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Output()
+	if err != nil || strings.TrimSpace(string(inside)) != "true" {
+		return nil
+	}
+
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if err != nil {
+		return fmt.Errorf("failed to check git status of %s: %w", dir, err)
+	}
+	if len(strings.TrimSpace(string(status))) > 0 {
+		return fmt.Errorf("output directory %s has uncommitted changes; commit or stash them first", dir)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initRepo creates a git repository in dir holding files in one commit
+func initRepo(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	writeTree(t, dir, files)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(cmd.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+}
+
+func TestRequireCleanGit(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    bool
+		output  map[string]string // written after the commit
+		noGit   bool
+		wantErr string
+	}{
+		{name: "clean tree", repo: true},
+		{
+			name:    "modified file",
+			repo:    true,
+			output:  map[string]string{"a.txt": "changed"},
+			wantErr: "uncommitted changes",
+		},
+		{
+			name:    "untracked file",
+			repo:    true,
+			output:  map[string]string{"new.txt": "new"},
+			wantErr: "uncommitted changes",
+		},
+		{name: "not a repository", output: map[string]string{"a.txt": "changed"}},
+		{name: "git not installed", repo: true, output: map[string]string{"a.txt": "changed"}, noGit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"a.txt": "{{name}}"})
+			if tt.repo {
+				initRepo(t, out, map[string]string{"a.txt": "old"})
+			}
+			writeTree(t, out, tt.output)
+			if tt.noGit {
+				t.Setenv("PATH", "")
+			}
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "new"})
+			cfg.RequireCleanGit = true
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want one containing %q", err, tt.wantErr)
+				}
+				if got := readTree(t, out)["a.txt"]; got == "new" {
+					t.Error("Generate wrote to a dirty tree")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+		})
+	}
+}
+
+// TestGitMetadataUntouched checks that a template's .git directory is not
+// copied and the output's .git is left alone
+func TestGitMetadataUntouched(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{".git/HEAD": "ref: refs/heads/{{name}}\n", "a.txt": "{{name}}"})
+	writeTree(t, out, map[string]string{".git/HEAD": "ref: refs/heads/main\n"})
+
+	if err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app"})).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := map[string]string{".git/HEAD": "ref: refs/heads/main\n", "a.txt": "app"}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
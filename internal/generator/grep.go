@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// VariableMatch is a place in the template where a variable is used
+type VariableMatch struct {
+	// Path is the template path relative to the template directory
+	Path string `json:"path"`
+	// Line is the 1-based line number, or 0 for a match in the path itself
+	Line int `json:"line"`
+	// Text is the matching line, or the matching path component
+	Text string `json:"text"`
+}
+
+// FindVariable returns every place a variable appears in the template, in any
+// enabled format, in walk order: file and directory names first, then lines
+// of text files
+func (g *Generator) FindVariable(name string) ([]VariableMatch, error) {
+	var matches []VariableMatch
+
+	if err := g.loadRules(); err != nil {
+		return nil, err
+	}
+
+	err := g.walkTemplate(func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." || isControlFile(relPath) {
+			return nil
+		}
+		if info.IsDir() && info.Name() == gitDirName {
+			return filepath.SkipDir
+		}
+		if skip, err := g.skipIgnored(relPath, info); skip {
+			return err
+		}
+		if skip, err := g.skipUnscanned(relPath, info); skip {
+			return err
+		}
+
+		if g.cfg.ReplaceInPaths && g.containsVariable(name, replacer.ExtractVariablesFromPath(info.Name(), g.cfg.Formats)) {
+			matches = append(matches, VariableMatch{Path: relPath, Text: info.Name()})
+		}
+
+		if info.IsDir() || !g.cfg.ReplaceInContent || g.isBinaryTemplateFile(path) {
+			return nil
+		}
+
+		content, err := g.readTemplateFile(path)
+		if err != nil {
+			return err
+		}
+		// Comments are masked rather than removed to keep line numbers
+		formats := g.formatsFor(relPath)
+		lines := bytes.Split(content, []byte("\n"))
+		for i, line := range bytes.Split(replacer.MaskComments(content, formats), []byte("\n")) {
+			if g.containsVariable(name, replacer.ExtractVariablesFromFile(line, formats)) {
+				matches = append(matches, VariableMatch{
+					Path: relPath,
+					Line: i + 1,
+					Text: strings.TrimRight(string(lines[i]), "\r"),
+				})
+			}
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// containsVariable reports whether name is among the variables extracted
+// from some text, honoring key normalization and case-insensitive matching
+func (g *Generator) containsVariable(name string, extracted []string) bool {
+	for _, v := range extracted {
+		v = g.normalizeKey(v)
+		if v == name || g.canonicalName(v) == name || (g.cfg.CaseInsensitiveVars && strings.EqualFold(v, name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindVariable(t *testing.T) {
+	tmpl := t.TempDir()
+	writeTree(t, tmpl, map[string]string{
+		"cmd/__module_path__/main.go": "package main\n\nimport \"{{module_path}}/internal\"\n\nfunc main() {}\n",
+		"go.mod":                      "module <<module_path>>\r\n\ngo 1.22\n",
+		"Makefile":                    "build:\n\tgo build %module_path%\n# {{module_path_old}} is not a match\n",
+		"docs/readme.md":              "No variables here\n{{other}}\n",
+		"{{module_path}}.txt":         "{{!-- {{module_path}} in a comment --}}\n",
+		"logo.png":                    "\x89PNG\x00{{module_path}}",
+	})
+
+	g := newTestGenerator(testConfig(tmpl, t.TempDir(), nil))
+	matches, err := g.FindVariable("module_path")
+	if err != nil {
+		t.Fatalf("FindVariable failed: %v", err)
+	}
+
+	want := []VariableMatch{
+		{Path: "Makefile", Line: 2, Text: "\tgo build %module_path%"},
+		{Path: filepath.FromSlash("cmd/__module_path__"), Text: "__module_path__"},
+		{Path: filepath.FromSlash("cmd/__module_path__/main.go"), Line: 3, Text: "import \"{{module_path}}/internal\""},
+		{Path: "go.mod", Line: 1, Text: "module <<module_path>>"},
+		{Path: "{{module_path}}.txt", Text: "{{module_path}}.txt"},
+	}
+	if !slices.Equal(matches, want) {
+		t.Errorf("FindVariable = %+v, want %+v", matches, want)
+	}
+
+	if matches, err := g.FindVariable("missing"); err != nil || len(matches) != 0 {
+		t.Errorf("FindVariable(missing) = %+v, %v; want no matches", matches, err)
+	}
+}
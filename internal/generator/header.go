@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// headerComments maps file extensions to the line comment prefix a header
+// is written with when the manifest does not choose one
+var headerComments = map[string]string{
+	".go": "//", ".c": "//", ".h": "//", ".cc": "//", ".cpp": "//", ".hpp": "//",
+	".java": "//", ".kt": "//", ".scala": "//", ".swift": "//", ".rs": "//",
+	".cs": "//", ".js": "//", ".jsx": "//", ".ts": "//", ".tsx": "//", ".proto": "//",
+	".py": "#", ".sh": "#", ".bash": "#", ".rb": "#", ".pl": "#", ".r": "#",
+	".yaml": "#", ".yml": "#", ".toml": "#", ".tf": "#",
+	".sql": "--", ".lua": "--", ".hs": "--",
+}
+
+// addHeader prepends the manifest's license header to rendered content when
+// the template path matches its globs. The header is substituted with the
+// file's variables plus "year", commented out line by line and placed after
+// any "#!" line. Content that already contains the header is returned
+// unchanged, so the header is never added twice.
+func (g *Generator) addHeader(relPath string, content []byte, variables map[string]string, formats config.FormatOptions) []byte {
+	m, err := g.loadManifest()
+	if err != nil || m == nil || m.Header == nil || m.Header.Text == "" {
+		return content
+	}
+	h := m.Header
+	if len(h.Files) > 0 && !matchesAny(h.Files, relPath) {
+		return content
+	}
+	prefix := h.Comment
+	if prefix == "" {
+		prefix = headerComments[strings.ToLower(filepath.Ext(relPath))]
+	}
+	if prefix == "" {
+		return content
+	}
+
+	header := commentHeader(g.headerText(h, variables, formats), prefix)
+	if bytes.Contains(content, header) {
+		return content
+	}
+
+	var shebang []byte
+	if bytes.HasPrefix(content, []byte("#!")) {
+		end := bytes.IndexByte(content, '\n') + 1
+		if end == 0 {
+			end = len(content)
+		}
+		shebang, content = content[:end], content[end:]
+	}
+
+	out := make([]byte, 0, len(shebang)+len(header)+1+len(content))
+	out = append(out, shebang...)
+	out = append(out, header...)
+	out = append(out, '\n')
+	return append(out, content...)
+}
+
+// headerText substitutes variables into the header text. "year" defaults
+// to the current year.
+func (g *Generator) headerText(h *manifest.Header, variables map[string]string, formats config.FormatOptions) string {
+	if variables == nil {
+		variables = g.cfg.Variables
+	}
+	merged := make(map[string]string, len(variables)+1)
+	merged["year"] = strconv.Itoa(g.now().Year())
+	for name, value := range variables {
+		merged[name] = value
+	}
+	return string(g.newReplacerWithFormats(merged, formats).ReplaceInContent([]byte(h.Text)))
+}
+
+// commentHeader turns header text into comment lines starting with prefix
+func commentHeader(text, prefix string) []byte {
+	var b bytes.Buffer
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			b.WriteString(prefix + "\n")
+			continue
+		}
+		b.WriteString(prefix + " " + line + "\n")
+	}
+	return b.Bytes()
+}
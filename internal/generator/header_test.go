@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLicenseHeader(t *testing.T) {
+	template := map[string]string{
+		"main.go":        "package {{name}}\n",
+		"tool.py":        "#!/usr/bin/env python3\nprint('{{name}}')\n",
+		"schema.sql":     "SELECT 1;\n",
+		"README.md":      "# {{name}}\n",
+		"vendor/lib.go":  "package lib\n",
+		"has_header.go":  "// Copyright 2031 Jane\n\npackage done\n",
+		"config/app.yml": "name: {{name}}\n",
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]string
+	}{
+		{
+			name:   "comment style per extension",
+			header: `{"text": "Copyright {{year}} {{author}}", "files": ["*.go", "*.py", "*.sql", "*.md"]}`,
+			want: map[string]string{
+				"main.go":        "// Copyright 2031 Jane\n\npackage app\n",
+				"tool.py":        "#!/usr/bin/env python3\n# Copyright 2031 Jane\n\nprint('app')\n",
+				"schema.sql":     "-- Copyright 2031 Jane\n\nSELECT 1;\n",
+				"README.md":      "# app\n",
+				"vendor/lib.go":  "// Copyright 2031 Jane\n\npackage lib\n",
+				"has_header.go":  "// Copyright 2031 Jane\n\npackage done\n",
+				"config/app.yml": "name: app\n",
+			},
+		},
+		{
+			name:   "every known type by default",
+			header: `{"text": "Copyright {{year}} {{author}}\n\nSPDX-License-Identifier: MIT"}`,
+			want: map[string]string{
+				"main.go":        "// Copyright 2031 Jane\n//\n// SPDX-License-Identifier: MIT\n\npackage app\n",
+				"tool.py":        "#!/usr/bin/env python3\n# Copyright 2031 Jane\n#\n# SPDX-License-Identifier: MIT\n\nprint('app')\n",
+				"schema.sql":     "-- Copyright 2031 Jane\n--\n-- SPDX-License-Identifier: MIT\n\nSELECT 1;\n",
+				"README.md":      "# app\n",
+				"vendor/lib.go":  "// Copyright 2031 Jane\n//\n// SPDX-License-Identifier: MIT\n\npackage lib\n",
+				"has_header.go":  "// Copyright 2031 Jane\n//\n// SPDX-License-Identifier: MIT\n\n// Copyright 2031 Jane\n\npackage done\n",
+				"config/app.yml": "# Copyright 2031 Jane\n#\n# SPDX-License-Identifier: MIT\n\nname: app\n",
+			},
+		},
+		{
+			name:   "manifest comment prefix",
+			header: `{"text": "(c) {{author}}", "files": ["README.md"], "comment": "<!-- -->"}`,
+			want: map[string]string{
+				"main.go":        "package app\n",
+				"tool.py":        "#!/usr/bin/env python3\nprint('app')\n",
+				"schema.sql":     "SELECT 1;\n",
+				"README.md":      "<!-- --> (c) Jane\n\n# app\n",
+				"vendor/lib.go":  "package lib\n",
+				"has_header.go":  "// Copyright 2031 Jane\n\npackage done\n",
+				"config/app.yml": "name: app\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			files := maps.Clone(template)
+			files[manifestFile] = `{"header": ` + tt.header + `}`
+			writeTree(t, tmpl, files)
+
+			// Regenerating over the output must not add the header again
+			for run := 1; run <= 2; run++ {
+				g := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app", "author": "Jane"}))
+				g.SetClock(fixedClock(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)))
+				if err := g.Generate(); err != nil {
+					t.Fatalf("run %d: Generate failed: %v", run, err)
+				}
+				if got := readTree(t, out); !maps.Equal(got, tt.want) {
+					t.Errorf("run %d: output = %q, want %q", run, got, tt.want)
+				}
+			}
+		})
+	}
+}
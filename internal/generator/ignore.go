@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// IgnoreFileName is the name of the file in the template root listing glob
+// patterns of paths that are not generated
+const IgnoreFileName = ".stencilignore"
+
+// ignoreRule excludes paths matching a pattern, or re-includes them when
+// negated
+type ignoreRule struct {
+	pattern string
+	negate  bool
+}
+
+// loadIgnore reads the template's ignore file, if present. Each line is a
+// glob as in .stencil-keep; a leading '!' re-includes matching paths.
+func (g *Generator) loadIgnore() error {
+	g.ignore = nil
+
+	file, err := g.openTemplateFile(filepath.Join(g.cfg.TemplateDir, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	patterns, err := readPatterns(file)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		rule := ignoreRule{pattern: strings.TrimPrefix(pattern, "!"), negate: strings.HasPrefix(pattern, "!")}
+		g.ignore = append(g.ignore, rule)
+	}
+	return nil
+}
+
+// ignored reports whether a template path is excluded. Each path component
+// is evaluated from the root down: the last rule matching a component
+// decides, and a component no rule matches keeps its parent's status. So
+// "!.env.example" after ".env*" re-includes that file, and a negation can
+// re-include a file inside an ignored directory.
+func (g *Generator) ignored(relPath string) bool {
+	ignored := false
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], "/")
+		for _, rule := range g.ignore {
+			if matchesAny([]string{rule.pattern}, prefix) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// skipIgnored reports whether a walked path is ignored. For an ignored
+// directory it also returns filepath.SkipDir, unless a negation rule could
+// re-include something beneath it.
+func (g *Generator) skipIgnored(relPath string, info os.FileInfo) (bool, error) {
+	if !info.IsDir() && !g.extensionAllowed(relPath) {
+		return true, nil
+	}
+	if !g.ignored(relPath) {
+		return false, nil
+	}
+	if info.IsDir() {
+		for _, rule := range g.ignore {
+			if rule.negate {
+				return true, nil
+			}
+		}
+		return true, filepath.SkipDir
+	}
+	return true, nil
+}
+
+// extensionAllowed reports whether a template file's extension is in
+// AllowedExtensions. Every file is allowed when the list is empty; an empty
+// entry allows files without an extension.
+func (g *Generator) extensionAllowed(relPath string) bool {
+	if len(g.cfg.AllowedExtensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(relPath))
+	for _, allowed := range g.cfg.AllowedExtensions {
+		if allowed != "" && !strings.HasPrefix(allowed, ".") {
+			allowed = "." + allowed
+		}
+		if strings.ToLower(allowed) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// isControlFile reports whether a template path is one of the files that
+// configure the template rather than being generated
+func isControlFile(relPath string) bool {
+	switch relPath {
+	case manifest.FileName, manifest.SignatureFileName, AttributesFileName, IgnoreFileName:
+		return true
+	}
+	return false
+}
+
+// loadRules loads the template's attributes and ignore files and the
+// manifest's variable aliases
+func (g *Generator) loadRules() error {
+	if err := g.loadAttributes(); err != nil {
+		return fmt.Errorf("failed to load %s: %w", AttributesFileName, err)
+	}
+	if err := g.loadIgnore(); err != nil {
+		return fmt.Errorf("failed to load %s: %w", IgnoreFileName, err)
+	}
+	if err := g.loadAliases(); err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	return nil
+}
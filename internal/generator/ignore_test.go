@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	template := map[string]string{
+		".env":                "SECRET=1",
+		".env.local":          "SECRET=2",
+		".env.example":        "SECRET=",
+		"main.go":             "package main",
+		"build/out.bin":       "x",
+		"build/keep.txt":      "kept",
+		"docs/a.md":           "a",
+		"docs/draft/b.md":     "b",
+		"docs/draft/c.md":     "c",
+		"nested/.env":         "SECRET=3",
+		"nested/.env.example": "SECRET=",
+	}
+
+	tests := []struct {
+		name   string
+		ignore string
+		want   []string
+	}{
+		{
+			name:   "negation re-includes one file under an ignored glob",
+			ignore: ".env*\n!.env.example\n",
+			want:   []string{".env.example", "build/keep.txt", "build/out.bin", "docs/a.md", "docs/draft/b.md", "docs/draft/c.md", "main.go", "nested/.env.example"},
+		},
+		{
+			name:   "last matching line wins",
+			ignore: "!.env.example\n.env*\n",
+			want:   []string{"build/keep.txt", "build/out.bin", "docs/a.md", "docs/draft/b.md", "docs/draft/c.md", "main.go"},
+		},
+		{
+			name:   "re-include inside an ignored directory",
+			ignore: "build\n!build/keep.txt\n",
+			want:   []string{".env", ".env.example", ".env.local", "build/keep.txt", "docs/a.md", "docs/draft/b.md", "docs/draft/c.md", "main.go", "nested/.env", "nested/.env.example"},
+		},
+		{
+			name:   "files inherit their directory's status",
+			ignore: "# drafts are private\n\ndocs/draft\n!docs/draft/c.md\n",
+			want:   []string{".env", ".env.example", ".env.local", "build/keep.txt", "build/out.bin", "docs/a.md", "docs/draft/c.md", "main.go", "nested/.env", "nested/.env.example"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			files := maps.Clone(template)
+			files[IgnoreFileName] = tt.ignore
+			writeTree(t, tmpl, files)
+
+			if err := newTestGenerator(testConfig(tmpl, out, nil)).Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			got := readTree(t, out)
+			want := make(map[string]string)
+			for _, path := range tt.want {
+				want[path] = template[path]
+			}
+			if !maps.Equal(got, want) {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// ItemVariable is the variable bound to the current list item while an
+// iterated file is generated
+const ItemVariable = "item"
+
+// iterateList returns the list variable an iterate rule of the manifest
+// assigns to a template file. Patterns are tried in sorted order.
+func (g *Generator) iterateList(m *manifest.Manifest, relPath string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(m.Iterate)) {
+		if matchesAny([]string{pattern}, relPath) {
+			return m.Iterate[pattern], true
+		}
+	}
+	return "", false
+}
+
+// itemBindings returns one set of variables per item of a comma-separated
+// list variable, each with ItemVariable bound to the item. Items are
+// trimmed and empty ones skipped, so an empty list yields no bindings.
+func (g *Generator) itemBindings(list string) []map[string]string {
+	bindings := []map[string]string{}
+	for _, item := range strings.Split(g.cfg.Variables[list], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		variables := maps.Clone(g.cfg.Variables)
+		if variables == nil {
+			variables = make(map[string]string)
+		}
+		variables[ItemVariable] = item
+		bindings = append(bindings, variables)
+	}
+	return bindings
+}
+
+// replacerFor returns the replacer for variables, or the generator's own
+// replacer when variables is nil
+func (g *Generator) replacerFor(variables map[string]string) *replacer.Replacer {
+	if variables == nil {
+		return g.replacer
+	}
+	return g.newReplacer(variables)
+}
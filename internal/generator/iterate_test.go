@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIterate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template map[string]string
+		vars     map[string]string
+		want     map[string]string
+		wantErr  string
+	}{
+		{
+			name: "one file per item",
+			template: map[string]string{
+				"models/model___item__.go": "package models\n\n// {{item}} belongs to {{name}}\ntype {{item}} struct{}\n",
+				"README.md":                "# {{name}}\n",
+				manifestFile:               `{"iterate": {"models/model___item__.go": "entities"}}`,
+			},
+			vars: map[string]string{"name": "shop", "entities": "User,Order,Product"},
+			want: map[string]string{
+				"models/model_User.go":    "package models\n\n// User belongs to shop\ntype User struct{}\n",
+				"models/model_Order.go":   "package models\n\n// Order belongs to shop\ntype Order struct{}\n",
+				"models/model_Product.go": "package models\n\n// Product belongs to shop\ntype Product struct{}\n",
+				"README.md":               "# shop\n",
+			},
+		},
+		{
+			name: "items are trimmed and empty ones skipped",
+			template: map[string]string{
+				"__item__.txt": "{{item}}",
+				manifestFile:   `{"iterate": {"*.txt": "items"}}`,
+			},
+			vars: map[string]string{"items": " a, b ,,c "},
+			want: map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"},
+		},
+		{
+			name: "empty list generates nothing",
+			template: map[string]string{
+				"__item__.txt": "{{item}}",
+				"keep.txt":     "kept",
+				manifestFile:   `{"iterate": {"__item__.txt": "items"}}`,
+			},
+			vars: map[string]string{"items": ""},
+			want: map[string]string{"keep.txt": "kept"},
+		},
+		{
+			name: "directory per item",
+			template: map[string]string{
+				"services/__item__/main.go": "package {{item}}\n",
+				manifestFile:                `{"iterate": {"services/__item__/*": "services"}}`,
+			},
+			vars: map[string]string{"services": "api,worker,cron"},
+			want: map[string]string{
+				"services/api/main.go":    "package api\n",
+				"services/worker/main.go": "package worker\n",
+				"services/cron/main.go":   "package cron\n",
+			},
+		},
+		{
+			name: "items colliding on one path",
+			template: map[string]string{
+				"fixed.txt":  "{{item}}",
+				manifestFile: `{"iterate": {"fixed.txt": "items"}}`,
+			},
+			vars:    map[string]string{"items": "a,b"},
+			wantErr: "fixed.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			templateDir, outputDir := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, templateDir, tt.template)
+
+			err := newTestGenerator(testConfig(templateDir, outputDir, tt.vars)).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, outputDir); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
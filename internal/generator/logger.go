@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Logger receives the generator's messages. The signatures match
+// *slog.Logger, so a slog logger can be used directly; args are alternating
+// keys and values with the structured details of the message.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// ConsoleLogger writes messages as plain lines: debug and info messages to
+// Out, warnings and errors to Err. Debug messages are dropped unless Verbose
+// is set. The structured args are not printed, since each message already
+// reads as a complete line.
+type ConsoleLogger struct {
+	Out     io.Writer
+	Err     io.Writer
+	Verbose bool
+
+	mu sync.Mutex
+}
+
+// NewConsoleLogger creates a ConsoleLogger writing to out and errOut
+func NewConsoleLogger(out, errOut io.Writer) *ConsoleLogger {
+	return &ConsoleLogger{Out: out, Err: errOut}
+}
+
+// Debug writes msg to Out when Verbose is set
+func (l *ConsoleLogger) Debug(msg string, args ...any) {
+	if l.Verbose {
+		l.write(l.Out, msg)
+	}
+}
+
+// Info writes msg to Out
+func (l *ConsoleLogger) Info(msg string, args ...any) {
+	l.write(l.Out, msg)
+}
+
+// Warn writes msg to Err
+func (l *ConsoleLogger) Warn(msg string, args ...any) {
+	l.write(l.Err, msg)
+}
+
+// Error writes msg to Err
+func (l *ConsoleLogger) Error(msg string, args ...any) {
+	l.write(l.Err, msg)
+}
+
+// write prints msg as a line, serializing concurrent callers
+func (l *ConsoleLogger) write(w io.Writer, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(w, msg)
+}
+
+// SetLogger replaces the logger the generator reports to, which defaults to
+// a ConsoleLogger on stdout and stderr. Loggers must be safe for concurrent
+// use when Concurrency is above 1.
+func (g *Generator) SetLogger(logger Logger) {
+	g.logger = logger
+}
+
+// defaultLogger returns the logger used until SetLogger is called
+func defaultLogger() Logger {
+	return NewConsoleLogger(os.Stdout, os.Stderr)
+}
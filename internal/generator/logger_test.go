@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureLogger records messages by level
+type captureLogger struct {
+	mu       sync.Mutex
+	messages []string // "LEVEL msg"
+}
+
+func (l *captureLogger) Debug(msg string, args ...any) { l.record("DEBUG", msg) }
+func (l *captureLogger) Info(msg string, args ...any)  { l.record("INFO", msg) }
+func (l *captureLogger) Warn(msg string, args ...any)  { l.record("WARN", msg) }
+func (l *captureLogger) Error(msg string, args ...any) { l.record("ERROR", msg) }
+
+func (l *captureLogger) record(level, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, level+" "+msg)
+}
+
+func TestGeneratorLogging(t *testing.T) {
+	tests := []struct {
+		name   string
+		dryRun bool
+		prune  bool
+		want   []string // message prefixes with OUT standing for the output directory
+	}{
+		{
+			name: "generation",
+			want: []string{
+				"DEBUG Created directory: OUT/docs",
+				"DEBUG Generated file: OUT/README.md",
+				"DEBUG Generated file: OUT/docs/app.txt",
+			},
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			prune:  true,
+			want: []string{
+				"INFO [DRY RUN] Would create directory: OUT/docs",
+				"INFO [DRY RUN] Would create file: OUT/README.md",
+				"INFO [DRY RUN] Would create file: OUT/docs/app.txt",
+				"INFO [DRY RUN] Would remove stale file: OUT/stale.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"README.md": "# {{name}}\n", "docs/__name__.txt": "{{name}}"})
+			writeTree(t, out, map[string]string{"stale.txt": "stale"})
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.DryRun = tt.dryRun
+			cfg.PruneOutput = tt.prune
+			logger := &captureLogger{}
+			g := NewGenerator(cfg)
+			g.SetLogger(logger)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			for _, want := range tt.want {
+				want = strings.ReplaceAll(want, "OUT/", out+string(filepath.Separator))
+				want = filepath.FromSlash(want)
+				if !slices.ContainsFunc(logger.messages, func(msg string) bool { return strings.HasPrefix(msg, want) }) {
+					t.Errorf("no message %q in %q", want, logger.messages)
+				}
+			}
+		})
+	}
+}
+
+func TestConsoleLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		verbose bool
+		wantOut string
+		wantErr string
+	}{
+		{name: "quiet", wantOut: "info\n", wantErr: "warn\nerror\n"},
+		{name: "verbose", verbose: true, wantOut: "debug\ninfo\n", wantErr: "warn\nerror\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			logger := NewConsoleLogger(&out, &errOut)
+			logger.Verbose = tt.verbose
+
+			logger.Debug("debug", "key", "value")
+			logger.Info("info", "key", "value")
+			logger.Warn("warn", "key", "value")
+			logger.Error("error", "key", "value")
+
+			if out.String() != tt.wantOut {
+				t.Errorf("out = %q, want %q", out.String(), tt.wantOut)
+			}
+			if errOut.String() != tt.wantErr {
+				t.Errorf("err = %q, want %q", errOut.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestConcurrencyLogging checks that serial mode logs files in plan order
+// and that concurrent workers never interleave partial lines
+func TestConcurrencyLogging(t *testing.T) {
+	template := make(map[string]string)
+	var want []string
+	for i := range 20 {
+		name := fmt.Sprintf("f%02d.txt", i)
+		template[name] = strings.Repeat("{{name}}\n", 1000)
+		want = append(want, "Generated file: "+name)
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		queueSize   int
+		ordered     bool
+	}{
+		{name: "serial", concurrency: 1, ordered: true},
+		{name: "parallel", concurrency: 8},
+		{name: "parallel with small queue", concurrency: 8, queueSize: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.Concurrency = tt.concurrency
+			cfg.QueueSize = tt.queueSize
+			var stdout, stderr bytes.Buffer
+			logger := NewConsoleLogger(&stdout, &stderr)
+			logger.Verbose = true
+			g := NewGenerator(cfg)
+			g.SetLogger(logger)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n") {
+				got = append(got, strings.Replace(line, out+string(filepath.Separator), "", 1))
+			}
+			if !tt.ordered {
+				slices.Sort(got)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("log lines = %q, want %q", got, want)
+			}
+			if files := readTree(t, out); len(files) != len(template) {
+				t.Errorf("generated %d files, want %d", len(files), len(template))
+			}
+		})
+	}
+}
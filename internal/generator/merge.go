@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Marker text delimiting the block stencil manages inside a merged file
+const (
+	mergeBeginMarker = "BEGIN stencil"
+	mergeEndMarker   = "END stencil"
+)
+
+// slashCommentFiles lists the extensions and file names whose line
+// comments start with "//"; other files use "#"
+var slashCommentFiles = map[string]bool{
+	".go": true, "go.mod": true, "go.work": true,
+	".js": true, ".ts": true, ".jsx": true, ".tsx": true,
+	".java": true, ".kt": true, ".swift": true, ".rs": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true,
+}
+
+// mergeMarkers returns the begin and end marker lines for a file, written
+// as line comments of the file's language
+func mergeMarkers(path string) (begin, end string) {
+	prefix := "#"
+	base := filepath.Base(path)
+	if slashCommentFiles[base] || slashCommentFiles[strings.ToLower(filepath.Ext(base))] {
+		prefix = "//"
+	}
+	return prefix + " " + mergeBeginMarker, prefix + " " + mergeEndMarker
+}
+
+// mergeFile renders a text template file into a managed block of the
+// existing output file, between begin and end markers. The block replaces
+// the one written by a previous run; content outside the markers is kept.
+// A file without a block gets it appended, and a missing file is created.
+func (g *Generator) mergeFile(entry planEntry, targetPath string) error {
+	if g.isBinaryTemplateFile(entry.sourcePath) {
+		return fmt.Errorf("cannot merge binary file")
+	}
+
+	content, err := g.renderText(entry.sourcePath, entry.variables)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(targetPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read existing file for merge: %w", err)
+	}
+	mode := entry.info.Mode()
+	if stat, err := os.Stat(targetPath); err == nil {
+		mode = stat.Mode()
+	}
+
+	begin, end := mergeMarkers(targetPath)
+	merged, err := mergeBlock(existing, content, begin, end)
+	if err != nil {
+		return err
+	}
+
+	if g.cfg.DryRun {
+		g.logger.Info("[DRY RUN] Would merge managed block into: "+targetPath, "path", targetPath)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return err
+	}
+	if g.cfg.Backup {
+		if err := g.backupIfChanged(targetPath, merged); err != nil {
+			return err
+		}
+	}
+
+	err = WriteFileAtomic(targetPath, mode, func(w io.Writer) error {
+		_, err := w.Write(merged)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write target file: %w", err)
+	}
+	return nil
+}
+
+// mergeBlock returns existing with the lines between the begin and end
+// marker lines replaced by block, or with a new marked block appended when
+// existing has none
+func mergeBlock(existing, block []byte, begin, end string) ([]byte, error) {
+	var managed bytes.Buffer
+	managed.WriteString(begin + "\n")
+	managed.Write(block)
+	if len(block) > 0 && !bytes.HasSuffix(block, []byte("\n")) {
+		managed.WriteByte('\n')
+	}
+	managed.WriteString(end + "\n")
+
+	lines := bytes.SplitAfter(existing, []byte("\n"))
+	start, stop := -1, -1
+	for i, line := range lines {
+		text := string(bytes.TrimSpace(line))
+		if text == begin && start < 0 {
+			start = i
+		} else if text == end && start >= 0 {
+			stop = i
+			break
+		}
+	}
+
+	var out bytes.Buffer
+	switch {
+	case start >= 0 && stop >= 0:
+		out.Write(bytes.Join(lines[:start], nil))
+		out.Write(managed.Bytes())
+		out.Write(bytes.Join(lines[stop+1:], nil))
+	case start >= 0:
+		return nil, fmt.Errorf("found %q without a matching %q", begin, end)
+	default:
+		out.Write(existing)
+		if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		out.Write(managed.Bytes())
+	}
+	return out.Bytes(), nil
+}
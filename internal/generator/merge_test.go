@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		block    string
+		want     string
+		wantErr  string
+	}{
+		{name: "new file", block: "bin/\n", want: "# BEGIN stencil\nbin/\n# END stencil\n"},
+		{name: "block without newline", block: "bin/", want: "# BEGIN stencil\nbin/\n# END stencil\n"},
+		{
+			name:     "appended",
+			existing: "*.log",
+			block:    "bin/\n",
+			want:     "*.log\n# BEGIN stencil\nbin/\n# END stencil\n",
+		},
+		{
+			name:     "replaced in place",
+			existing: "*.log\n# BEGIN stencil\nold/\n# END stencil\n.env\n",
+			block:    "bin/\ndist/\n",
+			want:     "*.log\n# BEGIN stencil\nbin/\ndist/\n# END stencil\n.env\n",
+		},
+		{
+			name:     "indented markers",
+			existing: "  # BEGIN stencil\nold/\n  # END stencil\n",
+			block:    "bin/\n",
+			want:     "# BEGIN stencil\nbin/\n# END stencil\n",
+		},
+		{
+			name:     "unterminated block",
+			existing: "# BEGIN stencil\nold/\n",
+			block:    "bin/\n",
+			wantErr:  `found "# BEGIN stencil" without a matching "# END stencil"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeBlock([]byte(tt.existing), []byte(tt.block), "# BEGIN stencil", "# END stencil")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeBlock error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeBlock failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("mergeBlock = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		manifestFile: `{"merge": [".gitignore", "go.mod"]}`,
+		".gitignore": "/{{name}}\n",
+		"go.mod":     "require example.com/{{name}} v1.0.0\n",
+		"README.md":  "# {{name}}\n",
+	})
+
+	// The first run inserts the blocks into the user's files
+	writeTree(t, out, map[string]string{
+		".gitignore": "*.log\n",
+		"go.mod":     "module example.com/project\n",
+		"README.md":  "user readme\n",
+	})
+	if err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "first"})).Generate(); err != nil {
+		t.Fatalf("first Generate failed: %v", err)
+	}
+	want := map[string]string{
+		".gitignore": "*.log\n# BEGIN stencil\n/first\n# END stencil\n",
+		"go.mod":     "module example.com/project\n// BEGIN stencil\nrequire example.com/first v1.0.0\n// END stencil\n",
+		"README.md":  "# first\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Fatalf("first output = %q, want %q", got, want)
+	}
+
+	// The user edits outside the block; the second run only updates inside
+	// the markers
+	gitignore := "*.log\n# BEGIN stencil\n/first\n# END stencil\n.env\n"
+	if err := os.WriteFile(filepath.Join(out, ".gitignore"), []byte(gitignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "second"})).Generate(); err != nil {
+		t.Fatalf("second Generate failed: %v", err)
+	}
+	want = map[string]string{
+		".gitignore": "*.log\n# BEGIN stencil\n/second\n# END stencil\n.env\n",
+		"go.mod":     "module example.com/project\n// BEGIN stencil\nrequire example.com/second v1.0.0\n// END stencil\n",
+		"README.md":  "# second\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("second output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package generator
+
+import "fmt"
+
+// MissingVariables returns the variables used by the template that have no
+// value, neither given nor declared as a manifest default, in first-seen
+// order. Nothing is generated.
+func (g *Generator) MissingVariables() ([]string, error) {
+	if err := g.ResolveConfigPaths(); err != nil {
+		return nil, err
+	}
+	if err := g.applyManifestDefaults(); err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	variables, _, err := g.scanTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range variables {
+		if _, ok := g.variableName(name); !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
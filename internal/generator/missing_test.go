@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMissingVariables(t *testing.T) {
+	template := map[string]string{
+		manifestFile:       `{"variables": [{"name": "owner", "default": "acme"}]}`,
+		"README.md":        "# {{name}} by {{owner}}\n",
+		"{{module}}/go.go": "package {{name}} // {{license}}\n",
+	}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want []string
+	}{
+		{name: "all supplied", vars: map[string]string{"name": "app", "module": "m", "license": "MIT"}},
+		{name: "some missing", vars: map[string]string{"name": "app"}, want: []string{"module", "license"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			missing, err := newTestGenerator(testConfig(tmpl, out, tt.vars)).MissingVariables()
+			if err != nil {
+				t.Fatalf("MissingVariables failed: %v", err)
+			}
+			slices.Sort(missing)
+			slices.Sort(tt.want)
+			if !slices.Equal(missing, tt.want) {
+				t.Errorf("missing = %q, want %q", missing, tt.want)
+			}
+			// Nothing is generated
+			if got := readTree(t, out); len(got) != 0 {
+				t.Errorf("output = %q, want none", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// noScanStart returns the index of the first component of a template path
+// that matches a NoScanGlobs pattern, or -1 when the path is scanned. Each
+// pattern is matched against the path's leading components or their base
+// name, so "node_modules" matches at any depth.
+func (g *Generator) noScanStart(relPath string) int {
+	if len(g.cfg.NoScanGlobs) == 0 {
+		return -1
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range parts {
+		if matchesAny(g.cfg.NoScanGlobs, strings.Join(parts[:i+1], "/")) {
+			return i
+		}
+	}
+	return -1
+}
+
+// skipUnscanned reports whether a walked path is excluded from variable
+// scanning, returning filepath.SkipDir for a directory
+func (g *Generator) skipUnscanned(relPath string, info os.FileInfo) (bool, error) {
+	if g.noScanStart(relPath) < 0 {
+		return false, nil
+	}
+	if info.IsDir() {
+		return true, filepath.SkipDir
+	}
+	return true, nil
+}
+
+// verbatimPath returns the output path of a path inside a no-scan
+// directory. The components from the no-scan directory down are kept as-is;
+// only the ones above it are substituted. root and rest are the path's
+// mapped output root and remaining path, and start is its noScanStart.
+func (g *Generator) verbatimPath(root, rest, relPath string, start int) string {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	tail := filepath.FromSlash(strings.Join(parts[start:], "/"))
+	head, ok := strings.CutSuffix(rest, tail)
+	if !ok {
+		// The mapped root already starts inside the no-scan directory
+		return filepath.Join(g.targetPath(root, nil), rest)
+	}
+	return filepath.Join(g.targetPath(filepath.Join(root, head), nil), tail)
+}
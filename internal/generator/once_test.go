@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOnce(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		manifestFile:           `{"once": ["main.go", "config/*.yaml"]}`,
+		"main.go":              "package {{name}}\n",
+		"config/app.yaml":      "name: {{name}}\n",
+		"config/defaults.json": `{"name": "{{name}}"}`,
+		"gen/boilerplate.go":   "// generated for {{name}}\n",
+	})
+
+	// The first run creates every file
+	g := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "first"}))
+	if err := g.Generate(); err != nil {
+		t.Fatalf("first Generate failed: %v", err)
+	}
+	want := map[string]string{
+		"main.go":              "package first\n",
+		"config/app.yaml":      "name: first\n",
+		"config/defaults.json": `{"name": "first"}`,
+		"gen/boilerplate.go":   "// generated for first\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Fatalf("first output = %q, want %q", got, want)
+	}
+	if skipped := g.Report().Skipped; len(skipped) != 0 {
+		t.Errorf("first run skipped %q", skipped)
+	}
+
+	// The user edits a once file; the second run keeps it and regenerates
+	// the others
+	if err := os.WriteFile(filepath.Join(out, "main.go"), []byte("package first // edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g = newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "second"}))
+	if err := g.Generate(); err != nil {
+		t.Fatalf("second Generate failed: %v", err)
+	}
+	want = map[string]string{
+		"main.go":              "package first // edited\n",
+		"config/app.yaml":      "name: first\n",
+		"config/defaults.json": `{"name": "second"}`,
+		"gen/boilerplate.go":   "// generated for second\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("second output = %q, want %q", got, want)
+	}
+	wantSkipped := []string{filepath.Join(out, "config", "app.yaml"), filepath.Join(out, "main.go")}
+	if skipped := slices.Sorted(slices.Values(g.Report().Skipped)); !slices.Equal(skipped, wantSkipped) {
+		t.Errorf("skipped = %q, want %q", skipped, wantSkipped)
+	}
+
+	// A deleted once file is generated again
+	if err := os.Remove(filepath.Join(out, "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "third"})).Generate(); err != nil {
+		t.Fatalf("third Generate failed: %v", err)
+	}
+	if got := readTree(t, out)["main.go"]; got != "package third\n" {
+		t.Errorf("main.go = %q, want it regenerated", got)
+	}
+}
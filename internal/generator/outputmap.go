@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// mapOutput returns the output root a template path is generated under,
+// relative to the output directory, and the path below that root. Paths
+// matching an outputMap pattern of the manifest go to its directory with
+// the pattern's literal leading directories removed, so "frontend/**"
+// mapped to "../web" generates frontend/src/app.js as ../web/src/app.js.
+// Other paths keep the output directory itself as their root. When several
+// patterns match, the longest wins.
+//
+// A pattern naming a single file, with no glob characters, may instead be
+// mapped to a file: a target without a trailing slash is the file's new
+// path, while a target ending in "/" is a directory to place it in under
+// its own name. The slash is read from the target as written, before
+// variables are substituted.
+func mapOutput(m *manifest.Manifest, relPath string) (root, rest string) {
+	if m == nil || len(m.OutputMap) == 0 {
+		return ".", relPath
+	}
+
+	patterns := make([]string, 0, len(m.OutputMap))
+	for pattern := range m.OutputMap {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	slashPath := filepath.ToSlash(relPath)
+	for _, pattern := range patterns {
+		if !matchesSubtree(pattern, slashPath) {
+			continue
+		}
+		target := m.OutputMap[pattern]
+		if isFileMapping(pattern, target) {
+			return filepath.FromSlash(path.Dir(target)), filepath.FromSlash(path.Base(target))
+		}
+		prefix := literalPrefix(pattern)
+		rest = strings.TrimPrefix(strings.TrimPrefix(slashPath, prefix), "/")
+		return filepath.FromSlash(target), filepath.FromSlash(rest)
+	}
+	return ".", relPath
+}
+
+// isFileMapping reports whether an outputMap entry maps a single file to a
+// new path rather than to a directory
+func isFileMapping(pattern, target string) bool {
+	return !strings.ContainsAny(pattern, "*?[") && target != "" && !strings.HasSuffix(target, "/")
+}
+
+// externalRoots returns the outputMap roots that lie outside the output
+// directory, relative to it, sorted. Variables are substituted with the
+// configured values, falling back to manifest defaults, and a root inside
+// another is left out.
+func (g *Generator) externalRoots(m *manifest.Manifest) []string {
+	if m == nil || len(m.OutputMap) == 0 {
+		return nil
+	}
+
+	variables := maps.Clone(g.cfg.Variables)
+	for _, v := range m.Variables {
+		if _, ok := variables[v.Name]; !ok && v.Default != "" {
+			variables[v.Name] = v.Default
+		}
+	}
+
+	var roots []string
+	for pattern, target := range m.OutputMap {
+		root := target
+		if isFileMapping(pattern, target) {
+			root = path.Dir(target)
+		}
+		root = filepath.Clean(g.targetPath(filepath.FromSlash(root), variables))
+		if !withinDir(g.cfg.OutputDir, filepath.Join(g.cfg.OutputDir, root)) {
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+
+	var outer []string
+	for _, root := range roots {
+		dir := absPath(filepath.Join(g.cfg.OutputDir, root))
+		outer = slices.DeleteFunc(outer, func(o string) bool {
+			return withinDir(dir, absPath(filepath.Join(g.cfg.OutputDir, o)))
+		})
+		if !slices.ContainsFunc(outer, func(o string) bool {
+			return withinDir(absPath(filepath.Join(g.cfg.OutputDir, o)), dir)
+		}) {
+			outer = append(outer, root)
+		}
+	}
+	return outer
+}
+
+// checkRootValues rejects variable values that would move an outputMap
+// root when substituted into it
+func (g *Generator) checkRootValues(root string, variables map[string]string) error {
+	if !g.cfg.ReplaceInPaths {
+		return nil
+	}
+	for _, r := range g.replacerFor(variables).FindReplacements([]byte(root)) {
+		if !isPathSafe(r.Value) {
+			return fmt.Errorf("outputMap directory %s: value of variable %s is not safe in a path: %q", root, r.Name, r.Value)
+		}
+	}
+	return nil
+}
+
+// matchesSubtree matches a slash-separated path against a glob, where a
+// trailing "/**" matches a directory and everything below it
+func matchesSubtree(pattern, slashPath string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		if slashPath == dir || strings.HasPrefix(slashPath, dir+"/") {
+			return true
+		}
+		ok, _ := path.Match(dir, slashPath)
+		return ok
+	}
+	ok, _ := path.Match(pattern, slashPath)
+	return ok
+}
+
+// literalPrefix returns the leading directories of a glob that contain no
+// glob characters, e.g. "frontend" for "frontend/**"
+func literalPrefix(pattern string) string {
+	var literal []string
+	elems := strings.Split(pattern, "/")
+	for i, elem := range elems {
+		if i == len(elems)-1 && !strings.ContainsAny(elem, "*?[") {
+			// A pattern naming a file keeps the file's own name
+			break
+		}
+		if strings.ContainsAny(elem, "*?[") {
+			break
+		}
+		literal = append(literal, elem)
+	}
+	return strings.Join(literal, "/")
+}
+
+// scratchDepth returns how many directories above the output directory
+// outputMap roots reach, so scratch runs can nest the output deep enough to
+// keep every root inside the scratch directory
+func scratchDepth(m *manifest.Manifest) int {
+	depth := 0
+	if m == nil {
+		return depth
+	}
+	for _, dir := range m.OutputMap {
+		up := 0
+		for _, elem := range strings.Split(path.Clean(filepath.ToSlash(dir)), "/") {
+			if elem != ".." {
+				break
+			}
+			up++
+		}
+		depth = max(depth, up)
+	}
+	return depth
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+func TestMapOutput(t *testing.T) {
+	outputMap := map[string]string{
+		"frontend/**":        "../web",
+		"frontend/shared/**": "../shared",
+		"deploy/*.yaml":      "k8s",
+		"deploy/app.env":     "config/{{env}}.env",
+		"deploy/Dockerfile":  "../docker/",
+	}
+
+	tests := []struct {
+		path     string
+		wantRoot string
+		wantRest string
+	}{
+		{"README.md", ".", "README.md"},
+		{"frontend/src/app.js", "../web", "src/app.js"},
+		{"frontend", "../web", ""},
+		{"frontend/shared/util.js", "../shared", "util.js"},
+		{"frontendish/a.js", ".", "frontendish/a.js"},
+		{"deploy/svc.yaml", "k8s", "svc.yaml"},
+		{"deploy/nested/svc.yaml", ".", "deploy/nested/svc.yaml"},
+		{"deploy/app.env", "config", "{{env}}.env"},
+		{"deploy/Dockerfile", "../docker/", "Dockerfile"},
+	}
+
+	m := &manifest.Manifest{OutputMap: outputMap}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			root, rest := mapOutput(m, filepath.FromSlash(tt.path))
+			if filepath.ToSlash(root) != tt.wantRoot || filepath.ToSlash(rest) != tt.wantRest {
+				t.Errorf("mapOutput(%s) = %s, %s, want %s, %s", tt.path, root, rest, tt.wantRoot, tt.wantRest)
+			}
+		})
+	}
+
+	if root, rest := mapOutput(nil, "a.txt"); root != "." || rest != "a.txt" {
+		t.Errorf("mapOutput without a manifest = %s, %s", root, rest)
+	}
+}
+
+func TestOutputMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		outputMap string
+		template  map[string]string
+		vars      map[string]string
+		want      map[string]string // under the parent of the output directory
+		wantErr   string
+	}{
+		{
+			name:      "subtree outside the output",
+			outputMap: `{"frontend/**": "../web"}`,
+			template:  map[string]string{"README.md": "# {{name}}\n", "frontend/src/app.js": "// {{name}}\n"},
+			want:      map[string]string{"out/README.md": "# app\n", "web/src/app.js": "// app\n"},
+		},
+		{
+			name:      "templated directory",
+			outputMap: `{"services/**": "../{{name}}-services"}`,
+			template:  map[string]string{"services/api.go": "package api\n"},
+			want:      map[string]string{"app-services/api.go": "package api\n"},
+		},
+		{
+			name:      "file renamed",
+			outputMap: `{"deploy/app.env": "config/{{env}}.env"}`,
+			template:  map[string]string{"deploy/app.env": "ENV={{env}}\n"},
+			vars:      map[string]string{"env": "prod"},
+			want:      map[string]string{"out/config/prod.env": "ENV=prod\n"},
+		},
+		{
+			name:      "file into a directory",
+			outputMap: `{"deploy/Dockerfile": "../docker/"}`,
+			template:  map[string]string{"deploy/Dockerfile": "FROM {{name}}\n"},
+			want:      map[string]string{"docker/Dockerfile": "FROM app\n"},
+		},
+		{
+			name:      "templated directory with a trailing slash",
+			outputMap: `{"deploy/app.env": "{{env}}/"}`,
+			template:  map[string]string{"deploy/app.env": "x"},
+			vars:      map[string]string{"env": "prod"},
+			want:      map[string]string{"out/prod/app.env": "x"},
+		},
+		{
+			name:      "value escaping the mapped directory",
+			outputMap: `{"services/**": "../services"}`,
+			template:  map[string]string{"services/__svc__/main.go": "package main\n"},
+			vars:      map[string]string{"svc": "../../etc"},
+			wantErr:   "escapes its output directory",
+		},
+		{
+			name:      "value moving the mapped directory",
+			outputMap: `{"services/**": "../{{dir}}"}`,
+			template:  map[string]string{"services/main.go": "package main\n"},
+			vars:      map[string]string{"dir": "../elsewhere"},
+			wantErr:   "not safe in a path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateDir, parent := t.TempDir(), t.TempDir()
+			files := maps.Clone(tt.template)
+			files[manifestFile] = `{"outputMap": ` + tt.outputMap + `}`
+			writeTree(t, templateDir, files)
+
+			vars := map[string]string{"name": "app"}
+			maps.Copy(vars, tt.vars)
+			cfg := testConfig(templateDir, filepath.Join(parent, "out"), vars)
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, parent); !maps.Equal(got, tt.want) {
+				t.Errorf("files = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
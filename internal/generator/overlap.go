@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checkDirOverlap rejects an output directory that is the template
+// directory itself, where every generated file would overwrite its source
+func (g *Generator) checkDirOverlap() error {
+	if g.fsys != nil {
+		return nil
+	}
+	if absPath(g.cfg.TemplateDir) == absPath(g.cfg.OutputDir) {
+		return fmt.Errorf("output directory %s is the template directory", g.cfg.OutputDir)
+	}
+	return nil
+}
+
+// nestedDir returns the path of inner relative to outer when inner lies
+// strictly beneath outer
+func nestedDir(outer, inner string) (string, bool) {
+	outer, inner = absPath(outer), absPath(inner)
+	if outer == inner || !withinDir(outer, inner) {
+		return "", false
+	}
+	rel, err := filepath.Rel(outer, inner)
+	return rel, err == nil
+}
+
+// absPath returns the absolute path with symlinks resolved as far as the
+// path exists, so an output directory not created yet still compares
+func absPath(path string) string {
+	if resolved, err := resolvePath(path); err == nil {
+		return resolved
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	dir, base := filepath.Split(abs)
+	if dir = filepath.Clean(dir); dir != abs {
+		return filepath.Join(absPath(dir), base)
+	}
+	return abs
+}
+
+// skipOutputDir wraps a walk function so that an output directory nested
+// inside the template is not walked, which would otherwise pick up files
+// generated by earlier runs as template files
+func (g *Generator) skipOutputDir(fn filepath.WalkFunc) filepath.WalkFunc {
+	outputRel, ok := nestedDir(g.cfg.TemplateDir, g.cfg.OutputDir)
+	if !ok {
+		return fn
+	}
+	return func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			if relPath, relErr := filepath.Rel(g.cfg.TemplateDir, path); relErr == nil && relPath == outputRel {
+				g.logger.Debug("Skipping output directory inside the template: "+path, "path", path)
+				return filepath.SkipDir
+			}
+		}
+		return fn(path, info, err)
+	}
+}
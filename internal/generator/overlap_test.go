@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirOverlap(t *testing.T) {
+	template := map[string]string{
+		"README.md":         "# {{name}}\n",
+		"{{name}}/main.go":  "package {{name}}\n",
+		"docs/guide.md":     "guide\n",
+		"docs/{{name}}.txt": "{{name}}\n",
+	}
+	generated := map[string]string{
+		"README.md":     "# app\n",
+		"app/main.go":   "package app\n",
+		"docs/guide.md": "guide\n",
+		"docs/app.txt":  "app\n",
+	}
+
+	t.Run("output inside template", func(t *testing.T) {
+		tmpl := t.TempDir()
+		writeTree(t, tmpl, template)
+		out := filepath.Join(tmpl, "build", "output")
+
+		// A second run must not treat the first run's output as template
+		// files
+		for run := 1; run <= 2; run++ {
+			if err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app"})).Generate(); err != nil {
+				t.Fatalf("run %d: Generate failed: %v", run, err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, generated) {
+				t.Errorf("run %d: output = %q, want %q", run, got, generated)
+			}
+		}
+
+		vars, err := newTestGenerator(testConfig(tmpl, out, nil)).ExtractVariables()
+		if err != nil {
+			t.Fatalf("ExtractVariables failed: %v", err)
+		}
+		if want := map[string]string{"name": ""}; !maps.Equal(vars, want) {
+			t.Errorf("variables = %q, want %q", vars, want)
+		}
+	})
+
+	t.Run("template inside output", func(t *testing.T) {
+		out := t.TempDir()
+		tmpl := filepath.Join(out, "template")
+		writeTree(t, tmpl, template)
+
+		// Pruning leaves the template alone
+		cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+		cfg.PruneOutput = true
+		if err := newTestGenerator(cfg).Generate(); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		want := maps.Clone(generated)
+		for path, content := range template {
+			want["template/"+path] = content
+		}
+		if got := readTree(t, out); !maps.Equal(got, want) {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+
+		// A generated path landing in the template is refused
+		err := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "template"})).Generate()
+		if err == nil || !strings.Contains(err.Error(), "would overwrite the template directory") {
+			t.Errorf("Generate error = %v, want the template protected", err)
+		}
+		if got := readTree(t, tmpl); !maps.Equal(got, template) {
+			t.Errorf("template = %q, want it unchanged", got)
+		}
+	})
+
+	t.Run("same directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTree(t, dir, template)
+
+		err := newTestGenerator(testConfig(dir, filepath.Join(dir, "."), map[string]string{"name": "app"})).Generate()
+		if err == nil || !strings.Contains(err.Error(), "is the template directory") {
+			t.Errorf("Generate error = %v, want the overlap rejected", err)
+		}
+		if got := readTree(t, dir); !maps.Equal(got, template) {
+			t.Errorf("template = %q, want it unchanged", got)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/linxux/stencil/internal/diff"
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// Patch generates into a temporary copy of the output directory and returns
+// a git diff turning the current output into what a real run would
+// produce, including added files, files removed by pruning and mode
+// changes. Paths carry
+// "a/" and "b/" prefixes relative to the output directory, so the patch
+// applies with "git apply" or "patch -p1" from there. The output directory
+// itself is never written.
+func (g *Generator) Patch() ([]byte, error) {
+	// Backups would show up as new files
+	backup := g.cfg.Backup
+	g.cfg.Backup = false
+	defer func() { g.cfg.Backup = backup }()
+
+	var patch []byte
+	err := g.generateScratch("stencil-patch-*", func(dirs []scratchDir) error {
+		// Paths of a patch are relative to the output directory, so files
+		// mapped outside it cannot be part of one
+		for _, dir := range dirs[1:] {
+			changes, err := changedFiles(dir.real, dir.scratch)
+			if err != nil {
+				return err
+			}
+			if len(changes) > 0 {
+				return fmt.Errorf("cannot write a patch for %s, outside the output directory", changes[0].Path)
+			}
+		}
+
+		var err error
+		patch, err = treePatch(dirs[0].real, dirs[0].scratch)
+		return err
+	})
+	return patch, err
+}
+
+// Git file modes written in patch headers
+const (
+	gitModeFile       = "100644"
+	gitModeExecutable = "100755"
+	gitModeSymlink    = "120000"
+)
+
+// gitBase85 is the base85 alphabet of git binary patches
+const gitBase85 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+// patchSide is one side of a file in a patch. An empty mode means the file
+// does not exist on that side.
+type patchSide struct {
+	content []byte
+	mode    string
+}
+
+// blobID returns the git object ID of the side's content, or the null ID
+// if the file does not exist
+func (s patchSide) blobID() string {
+	if s.mode == "" {
+		return strings.Repeat("0", 2*sha1.Size)
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(s.content))
+	h.Write(s.content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// treePatch returns a git diff of every file that differs between the
+// before and after directories. Headers record created and deleted files
+// and mode changes, so empty files and permission changes apply too, and
+// binary files are written as GIT binary patch literals.
+func treePatch(before, after string) ([]byte, error) {
+	beforeFiles, err := treeFiles(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFiles, err := treeFiles(after)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(afterFiles))
+	for rel := range afterFiles {
+		paths = append(paths, rel)
+	}
+	for rel := range beforeFiles {
+		if _, ok := afterFiles[rel]; !ok {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+
+	var out bytes.Buffer
+	for _, rel := range paths {
+		var old, new patchSide
+		if content, ok := beforeFiles[rel]; ok {
+			if old.mode, err = gitMode(filepath.Join(before, rel)); err != nil {
+				return nil, err
+			}
+			old.content = content
+		}
+		if content, ok := afterFiles[rel]; ok {
+			if new.mode, err = gitMode(filepath.Join(after, rel)); err != nil {
+				return nil, err
+			}
+			new.content = content
+		}
+		if old.mode == new.mode && bytes.Equal(old.content, new.content) {
+			continue
+		}
+
+		slashRel := filepath.ToSlash(rel)
+		// Git records a file replaced by a symlink, or the reverse, as a
+		// deletion and a creation
+		if old.mode != "" && new.mode != "" && (old.mode == gitModeSymlink) != (new.mode == gitModeSymlink) {
+			if err := writeFilePatch(&out, slashRel, old, patchSide{}); err != nil {
+				return nil, err
+			}
+			old = patchSide{}
+		}
+		if err := writeFilePatch(&out, slashRel, old, new); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// gitMode returns the git file mode of path
+func gitMode(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return gitModeSymlink, nil
+	case info.Mode()&0111 != 0:
+		return gitModeExecutable, nil
+	default:
+		return gitModeFile, nil
+	}
+}
+
+// writeFilePatch writes the git diff of a single file
+func writeFilePatch(out *bytes.Buffer, rel string, old, new patchSide) error {
+	fmt.Fprintf(out, "diff --git a/%s b/%s\n", rel, rel)
+	switch {
+	case old.mode == "":
+		fmt.Fprintf(out, "new file mode %s\n", new.mode)
+	case new.mode == "":
+		fmt.Fprintf(out, "deleted file mode %s\n", old.mode)
+	case old.mode != new.mode:
+		fmt.Fprintf(out, "old mode %s\nnew mode %s\n", old.mode, new.mode)
+		if bytes.Equal(old.content, new.content) {
+			return nil
+		}
+	}
+
+	fmt.Fprintf(out, "index %s..%s", old.blobID(), new.blobID())
+	if old.mode == new.mode {
+		fmt.Fprintf(out, " %s", old.mode)
+	}
+	out.WriteByte('\n')
+
+	if isBinaryContent(old.content) || isBinaryContent(new.content) {
+		out.WriteString("GIT binary patch\n")
+		if err := writeBinaryLiteral(out, new.content); err != nil {
+			return err
+		}
+		return writeBinaryLiteral(out, old.content)
+	}
+
+	oldName, newName := "a/"+rel, "b/"+rel
+	if old.mode == "" {
+		oldName = "/dev/null"
+	}
+	if new.mode == "" {
+		newName = "/dev/null"
+	}
+	out.WriteString(diff.Unified(oldName, newName, old.content, new.content))
+	return nil
+}
+
+// writeBinaryLiteral writes content as a git binary patch literal: zlib
+// compressed, then base85 encoded in lines of up to 52 bytes, each prefixed
+// with its length
+func writeBinaryLiteral(out *bytes.Buffer, content []byte) error {
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	if _, err := zw.Write(content); err != nil {
+		return fmt.Errorf("failed to compress binary patch: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress binary patch: %w", err)
+	}
+
+	fmt.Fprintf(out, "literal %d\n", len(content))
+	for data := deflated.Bytes(); len(data) > 0; {
+		n := min(len(data), 52)
+		if n <= 26 {
+			out.WriteByte(byte('A' + n - 1))
+		} else {
+			out.WriteByte(byte('a' + n - 27))
+		}
+		for i := 0; i < n; i += 4 {
+			var v uint32
+			for j := i; j < i+4; j++ {
+				v <<= 8
+				if j < n {
+					v |= uint32(data[j])
+				}
+			}
+			var group [5]byte
+			for j := 4; j >= 0; j-- {
+				group[j] = gitBase85[v%85]
+				v /= 85
+			}
+			out.Write(group[:])
+		}
+		out.WriteByte('\n')
+		data = data[n:]
+	}
+	out.WriteByte('\n')
+	return nil
+}
+
+// isBinaryContent reports whether content looks binary
+func isBinaryContent(content []byte) bool {
+	return replacer.IsBinary(bytes.NewReader(content))
+}
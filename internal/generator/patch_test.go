@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTreePatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     map[string]string
+		after      map[string]string
+		executable []string // files executable after
+		wantHeader string
+	}{
+		{
+			name:       "modified",
+			before:     map[string]string{"a.txt": "one\ntwo\n"},
+			after:      map[string]string{"a.txt": "one\nthree\n"},
+			wantHeader: "diff --git a/a.txt b/a.txt\nindex ",
+		},
+		{
+			name:       "created",
+			after:      map[string]string{"dir/new.txt": "hello\n"},
+			wantHeader: "diff --git a/dir/new.txt b/dir/new.txt\nnew file mode 100644\n",
+		},
+		{
+			name:       "deleted",
+			before:     map[string]string{"old.txt": "bye\n"},
+			wantHeader: "diff --git a/old.txt b/old.txt\ndeleted file mode 100644\n",
+		},
+		{
+			name:       "empty file created",
+			after:      map[string]string{"empty": ""},
+			wantHeader: "diff --git a/empty b/empty\nnew file mode 100644\n",
+		},
+		{
+			name:       "empty file deleted",
+			before:     map[string]string{"empty": ""},
+			wantHeader: "diff --git a/empty b/empty\ndeleted file mode 100644\n",
+		},
+		{
+			name:       "mode change only",
+			before:     map[string]string{"run.sh": "#!/bin/sh\n"},
+			after:      map[string]string{"run.sh": "#!/bin/sh\n"},
+			executable: []string{"run.sh"},
+			wantHeader: "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n",
+		},
+		{
+			name:       "executable created",
+			after:      map[string]string{"run.sh": "#!/bin/sh\necho hi\n"},
+			executable: []string{"run.sh"},
+			wantHeader: "new file mode 100755\n",
+		},
+		{
+			name:       "binary created",
+			after:      map[string]string{"logo.bin": "\x89PNG\x00\x01\x02" + strings.Repeat("\x00\xff", 100)},
+			wantHeader: "GIT binary patch\nliteral 207\n",
+		},
+		{
+			name:       "binary modified",
+			before:     map[string]string{"data.bin": "\x00\x01\x02"},
+			after:      map[string]string{"data.bin": "\x00\x01\x02\x03\x04"},
+			wantHeader: "GIT binary patch\nliteral 5\n",
+		},
+		{
+			name:       "binary deleted",
+			before:     map[string]string{"data.bin": "\x00\x01\x02"},
+			wantHeader: "deleted file mode 100644\n",
+		},
+		{
+			name:       "unchanged",
+			before:     map[string]string{"same.txt": "same\n"},
+			after:      map[string]string{"same.txt": "same\n"},
+			wantHeader: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := t.TempDir(), t.TempDir()
+			writeTree(t, before, tt.before)
+			writeTree(t, after, tt.after)
+			for _, rel := range tt.executable {
+				if err := os.Chmod(filepath.Join(after, rel), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			patch, err := treePatch(before, after)
+			if err != nil {
+				t.Fatalf("treePatch failed: %v", err)
+			}
+			if tt.wantHeader == "" {
+				if len(patch) != 0 {
+					t.Fatalf("patch = %q, want empty", patch)
+				}
+				return
+			}
+			if !strings.Contains(string(patch), tt.wantHeader) {
+				t.Fatalf("patch does not contain %q:\n%s", tt.wantHeader, patch)
+			}
+
+			gitApply(t, before, patch)
+			if got, want := readTree(t, before), readTree(t, after); !maps.Equal(got, want) {
+				t.Fatalf("applied tree = %q, want %q", got, want)
+			}
+			for _, rel := range tt.executable {
+				info, err := os.Stat(filepath.Join(before, rel))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode()&0100 == 0 {
+					t.Errorf("%s is not executable after applying the patch", rel)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchLeavesOutputUntouched(t *testing.T) {
+	templateDir, outputDir := t.TempDir(), t.TempDir()
+	writeTree(t, templateDir, map[string]string{
+		"README.md":         "# {{name}}\n",
+		"{{name}}/empty.go": "",
+	})
+	writeTree(t, outputDir, map[string]string{"README.md": "# old\n"})
+
+	patch, err := newTestGenerator(testConfig(templateDir, outputDir, map[string]string{"name": "app"})).Patch()
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if got := readTree(t, outputDir); !maps.Equal(got, map[string]string{"README.md": "# old\n"}) {
+		t.Fatalf("Patch changed the output directory: %q", got)
+	}
+
+	gitApply(t, outputDir, patch)
+	want := map[string]string{"README.md": "# app\n", "app/empty.go": ""}
+	if got := readTree(t, outputDir); !maps.Equal(got, want) {
+		t.Fatalf("applied tree = %q, want %q", got, want)
+	}
+}
+
+// gitApply applies patch in dir with git apply, skipping the test if git
+// is not installed
+func gitApply(t *testing.T, dir string, patch []byte) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	cmd := exec.Command("git", "apply", "--whitespace=nowarn", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(string(patch))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed: %v\n%s\npatch:\n%s", err, out, patch)
+	}
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveConfigPaths substitutes variables into the template and output
+// directory paths, e.g. "./build/{{project_name}}". Only variables given in
+// the configuration are used, since manifest defaults are read from the
+// template directory itself. Placeholders without a value are left as they
+// are, so calling it again after more variables are set resolves them.
+func (g *Generator) ResolveConfigPaths() error {
+	templateDir, err := g.resolveConfigPath("templateDir", g.cfg.TemplateDir)
+	if err != nil {
+		return err
+	}
+	outputDir, err := g.resolveConfigPath("outputDir", g.cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	g.cfg.TemplateDir = templateDir
+	g.cfg.OutputDir = outputDir
+	return nil
+}
+
+// resolveConfigPath substitutes variables into a configured path, rejecting
+// values that could move it somewhere unexpected
+func (g *Generator) resolveConfigPath(field, path string) (string, error) {
+	for _, r := range g.replacer.FindReplacements([]byte(path)) {
+		if !isPathSafe(r.Value) {
+			return "", fmt.Errorf("%s: value of variable %s is not safe in a path: %q", field, r.Name, r.Value)
+		}
+		if g.pathVariables == nil {
+			g.pathVariables = make(map[string]bool)
+		}
+		g.pathVariables[g.canonicalize(r.Name)] = true
+	}
+	return g.replacer.ReplaceInPath(path), nil
+}
+
+// isPathSafe reports whether a value can be substituted into a path without
+// making it absolute or escaping its parent: it must not be empty, rooted or
+// contain a NUL byte or a ".." element
+func isPathSafe(value string) bool {
+	if value == "" || strings.ContainsRune(value, 0) || filepath.IsAbs(value) || strings.HasPrefix(value, "/") || strings.HasPrefix(value, `\`) {
+		return false
+	}
+	for _, elem := range strings.FieldsFunc(value, func(c rune) bool { return c == '/' || c == '\\' }) {
+		if elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// stripOutputPrefix removes the configured StripPrefix from the front of a
+// generated path. It reports false for the prefix's own directories, which
+// have nothing left to generate.
+func (g *Generator) stripOutputPrefix(path string) (string, bool) {
+	prefix := filepath.Clean(filepath.FromSlash(g.cfg.StripPrefix))
+	if g.cfg.StripPrefix == "" || prefix == "." {
+		return path, true
+	}
+
+	path = filepath.Clean(path)
+	if path == prefix || strings.HasPrefix(prefix, path+string(filepath.Separator)) {
+		return "", false
+	}
+	if rest, ok := strings.CutPrefix(path, prefix+string(filepath.Separator)); ok {
+		return rest, true
+	}
+	return path, true
+}
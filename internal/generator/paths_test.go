@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveConfigPaths(t *testing.T) {
+	tests := []struct {
+		name         string
+		templateDir  string
+		outputDir    string
+		vars         map[string]string
+		wantTemplate string
+		wantOutput   string
+		wantErr      string
+	}{
+		{
+			name:         "output from a variable",
+			templateDir:  "./template",
+			outputDir:    "./build/{{project_name}}",
+			vars:         map[string]string{"project_name": "app"},
+			wantTemplate: "./template",
+			wantOutput:   "./build/app",
+		},
+		{
+			name:         "template from a variable",
+			templateDir:  "templates/<<kind>>",
+			outputDir:    "out/__kind__-%version%",
+			vars:         map[string]string{"kind": "service", "version": "v2"},
+			wantTemplate: "templates/service",
+			wantOutput:   "out/service-v2",
+		},
+		{
+			name:         "nested value",
+			templateDir:  "./template",
+			outputDir:    "build/{{path}}",
+			vars:         map[string]string{"path": "team/app"},
+			wantTemplate: "./template",
+			wantOutput:   "build/team/app",
+		},
+		{
+			name:         "missing variable kept",
+			templateDir:  "./template",
+			outputDir:    "build/{{project_name}}",
+			wantTemplate: "./template",
+			wantOutput:   "build/{{project_name}}",
+		},
+		{
+			name:        "parent directory",
+			templateDir: "./template",
+			outputDir:   "build/{{name}}",
+			vars:        map[string]string{"name": "../../etc"},
+			wantErr:     `outputDir: value of variable name is not safe in a path: "../../etc"`,
+		},
+		{
+			name:        "absolute value",
+			templateDir: "./template",
+			outputDir:   "build/{{name}}",
+			vars:        map[string]string{"name": "/etc"},
+			wantErr:     "not safe in a path",
+		},
+		{
+			name:        "backslash parent",
+			templateDir: "templates/{{kind}}",
+			outputDir:   "out",
+			vars:        map[string]string{"kind": `a\..\..`},
+			wantErr:     "templateDir: value of variable kind is not safe",
+		},
+		{
+			name:        "empty value",
+			templateDir: "./template",
+			outputDir:   "build/{{name}}",
+			vars:        map[string]string{"name": ""},
+			wantErr:     "not safe in a path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(tt.templateDir, tt.outputDir, tt.vars)
+			g := newTestGenerator(cfg)
+			err := g.ResolveConfigPaths()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveConfigPaths error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveConfigPaths failed: %v", err)
+			}
+			if g.TemplateDir() != tt.wantTemplate || g.OutputDir() != tt.wantOutput {
+				t.Errorf("paths = %s, %s; want %s, %s", g.TemplateDir(), g.OutputDir(), tt.wantTemplate, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestGenerateIntoResolvedOutput(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "template")
+	writeTree(t, tmpl, map[string]string{"README.md": "# {{project_name}}\n"})
+
+	cfg := testConfig(tmpl, filepath.Join(dir, "build", "{{project_name}}"), map[string]string{"project_name": "app"})
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := map[string]string{"README.md": "# app\n"}
+	if got := readTree(t, filepath.Join(dir, "build", "app")); !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	template := map[string]string{
+		"root/README.md":             "# {{name}}\n",
+		"root/src/{{name}}/main.go":  "package {{name}}\n",
+		"root/empty/.gitkeep":        "",
+		"{{dir}}/docs/{{name}}.md":   "{{name}}\n",
+		"other/notes.txt":            "outside the prefix\n",
+		"rootless/kept.txt":          "kept\n",
+		"root/src/app/lib/helper.go": "package lib\n",
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   map[string]string
+	}{
+		{
+			name:   "single directory",
+			prefix: "root",
+			want: map[string]string{
+				"README.md":             "# app\n",
+				"src/app/main.go":       "package app\n",
+				"empty/.gitkeep":        "",
+				"docs/app.md":           "app\n",
+				"other/notes.txt":       "outside the prefix\n",
+				"rootless/kept.txt":     "kept\n",
+				"src/app/lib/helper.go": "package lib\n",
+			},
+		},
+		{
+			// The prefix is matched after substitution, so root/src/{{name}}
+			// is stripped as root/src/app
+			name:   "nested directories",
+			prefix: "root/src/app/",
+			want: map[string]string{
+				"root/README.md":      "# app\n",
+				"main.go":             "package app\n",
+				"root/empty/.gitkeep": "",
+				"root/docs/app.md":    "app\n",
+				"other/notes.txt":     "outside the prefix\n",
+				"rootless/kept.txt":   "kept\n",
+				"lib/helper.go":       "package lib\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app", "dir": "root"})
+			cfg.StripPrefix = tt.prefix
+			if err := newTestGenerator(cfg).Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			// The prefix's own directories are not created
+			if _, err := os.Stat(filepath.Join(out, tt.prefix)); !os.IsNotExist(err) {
+				t.Errorf("prefix directory %s exists (%v)", tt.prefix, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// planEntry is a single template path and where it will be generated
+type planEntry struct {
+	// sourcePath is the path of the entry in the template
+	sourcePath string
+	// relPath is the path relative to the template directory
+	relPath string
+	// targetRel is the output path relative to the output directory
+	targetRel string
+	info      os.FileInfo
+	// once marks a file that is kept when it already exists in the output
+	once bool
+	// merge marks a file merged into the existing output as a managed block
+	merge bool
+	// verbatim marks a path inside a no-scan directory, copied as-is with
+	// neither its name nor its content substituted
+	verbatim bool
+	// variables, when set, replaces the configured variables for this entry,
+	// e.g. with the item of an iterated file bound
+	variables map[string]string
+}
+
+// plan walks the template directory and returns the entries to generate in
+// walk order. Unreadable paths are recorded in the report and skipped.
+func (g *Generator) plan() ([]planEntry, error) {
+	var entries []planEntry
+
+	m, err := g.loadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	if err := g.loadRules(); err != nil {
+		return nil, err
+	}
+
+	// Output paths claimed so far, to detect collisions from normalization
+	claimed := make(map[string]string)
+
+	// A template inside the output directory must not be written over
+	templateRel, templateInOutput := "", false
+	if g.fsys == nil {
+		templateRel, templateInOutput = nestedDir(g.cfg.OutputDir, g.cfg.TemplateDir)
+	}
+
+	err = g.walkTemplate(func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			g.report.Error(path, err)
+			return nil
+		}
+
+		// Get relative path from template directory
+		relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
+		if err != nil {
+			return err
+		}
+
+		// Skip the template directory itself and its control files
+		if relPath == "." || isControlFile(relPath) {
+			return nil
+		}
+
+		// Version control metadata is never part of the template
+		if info.IsDir() && info.Name() == gitDirName {
+			return filepath.SkipDir
+		}
+
+		if skip, err := g.skipIgnored(relPath, info); skip {
+			return err
+		}
+
+		// Prune conditional paths before descending into them
+		if !g.conditionMet(m, relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// An iterated file is planned once per item of its list
+		noScan := g.noScanStart(relPath)
+		bindings := []map[string]string{nil}
+		if !info.IsDir() && noScan < 0 {
+			if list, ok := g.iterateList(m, relPath); ok {
+				bindings = g.itemBindings(list)
+			}
+		}
+
+		root, rest := mapOutput(m, relPath)
+		for _, variables := range bindings {
+			target := g.targetPath(filepath.Join(root, rest), variables)
+			if noScan >= 0 {
+				target = g.verbatimPath(root, rest, relPath, noScan)
+			}
+			target, keep := g.stripOutputPrefix(target)
+			if !keep {
+				continue
+			}
+			targetRel, err := g.normalizePath(target)
+			if err != nil {
+				return err
+			}
+
+			// Substituted values must not move files out of their output
+			// root, nor move the root itself
+			if err := g.checkRootValues(root, variables); err != nil {
+				return err
+			}
+			rootDir := filepath.Join(g.cfg.OutputDir, g.targetPath(root, variables))
+			if !withinDir(rootDir, filepath.Join(g.cfg.OutputDir, targetRel)) {
+				return fmt.Errorf("generated path %s for %s escapes its output directory", targetRel, relPath)
+			}
+			if templateInOutput && withinDir(templateRel, targetRel) {
+				return fmt.Errorf("generated path %s for %s would overwrite the template directory", targetRel, relPath)
+			}
+
+			if g.checkPortablePaths() {
+				if err := g.checkWindowsName(relPath, targetRel); err != nil {
+					return err
+				}
+			}
+
+			if !info.IsDir() && (g.cfg.NormalizeFilenames != "" || variables != nil) {
+				if other, ok := claimed[targetRel]; ok {
+					return fmt.Errorf("filename collision: %s and %s both generate %s",
+						other, relPath, targetRel)
+				}
+				claimed[targetRel] = relPath
+			}
+
+			entries = append(entries, planEntry{
+				sourcePath: path,
+				relPath:    relPath,
+				targetRel:  targetRel,
+				info:       info,
+				once:       m != nil && !info.IsDir() && matchesAny(m.Once, relPath),
+				merge:      m != nil && !info.IsDir() && noScan < 0 && matchesAny(m.Merge, relPath),
+				verbatim:   noScan >= 0,
+				variables:  variables,
+			})
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// targetPath returns the output path for a template path, substituting
+// variables unless path replacement is disabled. Non-nil variables replace
+// the configured ones.
+func (g *Generator) targetPath(relPath string, variables map[string]string) string {
+	if !g.cfg.ReplaceInPaths {
+		return relPath
+	}
+	return g.replacerFor(variables).ReplaceInPath(relPath)
+}
+
+// normalizePath applies the configured filename normalization to a
+// substituted output path
+func (g *Generator) normalizePath(path string) (string, error) {
+	switch g.cfg.NormalizeFilenames {
+	case "", "none":
+		return path, nil
+	case "lower":
+		return strings.ToLower(path), nil
+	default:
+		return "", fmt.Errorf("unknown filename normalization: %s", g.cfg.NormalizeFilenames)
+	}
+}
+
+// conditionMet evaluates the manifest condition attached to a path, if any
+func (g *Generator) conditionMet(m *manifest.Manifest, relPath string) bool {
+	if m == nil {
+		return true
+	}
+
+	variable, ok := m.Conditions[filepath.ToSlash(relPath)]
+	if !ok {
+		return true
+	}
+
+	negate := strings.HasPrefix(variable, "!")
+	variable = strings.TrimPrefix(variable, "!")
+	return replacer.IsTruthy(g.cfg.Variables[variable]) != negate
+}
+
+// RenderedFile is a single file produced by an in-memory render
+type RenderedFile struct {
+	// Path is the output path relative to the output directory
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+	Binary  bool
+}
+
+// Render renders the whole template in memory without writing anything and
+// returns the produced files sorted by path. It runs the same configuration
+// and variable checks as Generate.
+func (g *Generator) Render() ([]RenderedFile, error) {
+	if err := g.prepare(); err != nil {
+		return nil, err
+	}
+
+	g.report = &GenerationReport{}
+
+	entries, err := g.plan()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []RenderedFile
+	for _, entry := range entries {
+		if entry.info.IsDir() {
+			continue
+		}
+
+		file := RenderedFile{
+			Path:   entry.targetRel,
+			Mode:   entry.info.Mode(),
+			Binary: g.isBinaryTemplateFile(entry.sourcePath),
+		}
+		if file.Binary || entry.verbatim {
+			file.Content, err = g.readTemplateFile(entry.sourcePath)
+		} else {
+			file.Content, err = g.renderText(entry.sourcePath, entry.variables)
+		}
+		if err != nil {
+			g.report.Error(entry.relPath, err)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	if g.report.HasErrors() {
+		return files, fmt.Errorf("render finished with %d error(s)", len(g.report.Errors()))
+	}
+	return files, nil
+}
@@ -0,0 +1,280 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// manifestFile is the template manifest's file name
+const manifestFile = manifest.FileName
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		template map[string]string
+		vars     map[string]string
+		setup    func(cfg *config.Config)
+		want     map[string]string
+		wantErr  string
+	}{
+		{
+			name: "content and paths",
+			template: map[string]string{
+				"README.md":            "# {{name}}\n",
+				"cmd/__name__/main.go": "package main // <<name>>\n",
+				"docs/%name%.txt":      "",
+				manifestFile:           `{"variables": [{"name": "name"}]}`,
+			},
+			vars: map[string]string{"name": "app"},
+			want: map[string]string{
+				"README.md":       "# app\n",
+				"cmd/app/main.go": "package main // app\n",
+				"docs/app.txt":    "",
+			},
+		},
+		{
+			name: "manifest defaults",
+			template: map[string]string{
+				"a.txt":      "{{greeting}}",
+				manifestFile: `{"variables": [{"name": "greeting", "default": "hello"}]}`,
+			},
+			want: map[string]string{"a.txt": "hello"},
+		},
+		{
+			name: "conditional path",
+			template: map[string]string{
+				"ci.yml":     "ci",
+				"main.go":    "main",
+				manifestFile: `{"conditions": {"ci.yml": "with_ci"}}`,
+			},
+			vars: map[string]string{"with_ci": "false"},
+			want: map[string]string{"main.go": "main"},
+		},
+		{
+			name: "invalid configuration",
+			template: map[string]string{
+				"a.txt": "{{name}}",
+			},
+			setup:   func(cfg *config.Config) { cfg.OnEmptyValue = "bogus" },
+			wantErr: "invalid configuration",
+		},
+		{
+			name: "invalid variable value",
+			template: map[string]string{
+				"a.go":       "package {{pkg}}",
+				manifestFile: `{"variables": [{"name": "pkg", "type": "identifier"}]}`,
+			},
+			vars:    map[string]string{"pkg": "not valid"},
+			wantErr: "invalid value for variable pkg",
+		},
+		{
+			name: "conflicting aliases",
+			template: map[string]string{
+				"a.txt":      "{{project_name}} {{projectName}}",
+				manifestFile: `{"aliases": {"projectName": "project_name"}}`,
+			},
+			vars:    map[string]string{"project_name": "a", "projectName": "b"},
+			wantErr: "conflicting values",
+		},
+		{
+			name: "output is the template",
+			template: map[string]string{
+				"a.txt": "x",
+			},
+			setup:   func(cfg *config.Config) { cfg.OutputDir = cfg.TemplateDir },
+			wantErr: "is the template directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateDir := t.TempDir()
+			writeTree(t, templateDir, tt.template)
+			cfg := testConfig(templateDir, t.TempDir(), tt.vars)
+			if tt.setup != nil {
+				tt.setup(cfg)
+			}
+
+			files, err := newTestGenerator(cfg).Render()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, f := range files {
+				got[f.Path] = string(f.Content)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRenderMatchesGenerate checks that rendering in memory produces the
+// files a real run writes
+func TestRenderMatchesGenerate(t *testing.T) {
+	templateDir, outputDir := t.TempDir(), t.TempDir()
+	writeTree(t, templateDir, map[string]string{
+		"README.md":            "# {{name}}\n",
+		"__name__/config.yaml": "name: <<name>>\n",
+		"bin/data.bin":         "\x00\x01{{name}}",
+	})
+	vars := map[string]string{"name": "app"}
+
+	files, err := newTestGenerator(testConfig(templateDir, t.TempDir(), vars)).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	rendered := make(map[string]string)
+	for _, f := range files {
+		rendered[f.Path] = string(f.Content)
+	}
+
+	if err := newTestGenerator(testConfig(templateDir, outputDir, vars)).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if generated := readTree(t, outputDir); !maps.Equal(rendered, generated) {
+		t.Errorf("Render = %q, Generate wrote %q", rendered, generated)
+	}
+}
+
+func TestConditionalDirectories(t *testing.T) {
+	template := map[string]string{
+		"main.go":                 "main",
+		"deploy/Dockerfile":       "FROM {{name}}",
+		"deploy/k8s/__name__.yml": "app: {{name}}",
+		"deploy/__escape__/x.txt": "would fail if planned",
+		"legacy/old.txt":          "old",
+		manifestFile:              `{"conditions": {"deploy": "include_deploy", "legacy": "!modern"}}`,
+	}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want map[string]string
+	}{
+		{
+			name: "included",
+			vars: map[string]string{"include_deploy": "yes", "modern": "false", "escape": "ok"},
+			want: map[string]string{
+				"main.go":            "main",
+				"deploy/Dockerfile":  "FROM app",
+				"deploy/k8s/app.yml": "app: app",
+				"deploy/ok/x.txt":    "would fail if planned",
+				"legacy/old.txt":     "old",
+			},
+		},
+		{
+			// The subtree is pruned before it is planned, so the escaping
+			// path inside it is never substituted
+			name: "excluded",
+			vars: map[string]string{"include_deploy": "false", "modern": "true", "escape": "../../outside"},
+			want: map[string]string{"main.go": "main"},
+		},
+		{
+			name: "unset variable excludes",
+			vars: map[string]string{"modern": "1"},
+			want: map[string]string{"main.go": "main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			vars := map[string]string{"name": "app"}
+			maps.Copy(vars, tt.vars)
+			if err := newTestGenerator(testConfig(tmpl, out, vars)).Generate(); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFilenames(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  map[string]string
+		vars      map[string]string
+		normalize string
+		want      map[string]string
+		wantErr   string
+	}{
+		{
+			name:      "lowercases after substitution",
+			template:  map[string]string{"__Name__/Main.go": "{{Name}}"},
+			vars:      map[string]string{"Name": "MyApp"},
+			normalize: "lower",
+			want:      map[string]string{"myapp/main.go": "MyApp"},
+		},
+		{
+			name:     "case-only names kept apart without normalization",
+			template: map[string]string{"Foo.go": "upper", "foo.go": "lower"},
+			want:     map[string]string{"Foo.go": "upper", "foo.go": "lower"},
+		},
+		{
+			name:      "case-only names collide",
+			template:  map[string]string{"Foo.go": "upper", "foo.go": "lower"},
+			normalize: "lower",
+			wantErr:   "filename collision: Foo.go and foo.go both generate foo.go",
+		},
+		{
+			name:      "substituted names collide",
+			template:  map[string]string{"__first__.go": "", "__second__.go": ""},
+			vars:      map[string]string{"first": "Model", "second": "model"},
+			normalize: "lower",
+			wantErr:   "both generate model.go",
+		},
+		{
+			name:      "none",
+			template:  map[string]string{"Foo.go": "upper"},
+			normalize: "none",
+			want:      map[string]string{"Foo.go": "upper"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, tt.template)
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.NormalizeFilenames = tt.normalize
+			files, err := newTestGenerator(cfg).Render()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			got := make(map[string]string)
+			for _, f := range files {
+				got[filepath.ToSlash(f.Path)] = string(f.Content)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("files = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// windowsReservedNames lists device names Windows reserves regardless of
+// case or extension, e.g. "con" and "nul.txt"
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// checkPortablePaths reports whether generated names must be valid on
+// Windows: always on Windows, and elsewhere when PortablePaths is set
+func (g *Generator) checkPortablePaths() bool {
+	return runtime.GOOS == "windows" || g.cfg.PortablePaths
+}
+
+// checkWindowsName checks the generated name of a template path, naming the
+// variables that produced it when it is not a valid Windows file name
+func (g *Generator) checkWindowsName(relPath, targetRel string) error {
+	name := filepath.Base(targetRel)
+	problem := windowsNameProblem(name)
+	if problem == "" {
+		return nil
+	}
+
+	err := fmt.Errorf("generated name %q for %s is not a valid Windows file name: %s", name, relPath, problem)
+	var vars []string
+	for _, r := range g.replacer.FindReplacements([]byte(filepath.Base(relPath))) {
+		vars = append(vars, r.Name)
+	}
+	if len(vars) > 0 {
+		err = fmt.Errorf("%w; choose a different value for %s", err, strings.Join(vars, ", "))
+	}
+	return err
+}
+
+// windowsNameProblem describes why name is not a valid Windows file name,
+// or returns "" if it is
+func windowsNameProblem(name string) string {
+	for _, c := range name {
+		if c < 0x20 {
+			return fmt.Sprintf("it contains the control character %q", c)
+		}
+		if strings.ContainsRune(`<>:"/\|?*`, c) {
+			return fmt.Sprintf("it contains the character %q", c)
+		}
+	}
+	if strings.HasSuffix(name, " ") || strings.HasSuffix(name, ".") {
+		return "it ends with a space or a dot"
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	if device := strings.ToUpper(strings.TrimRight(base, " ")); windowsReservedNames[device] {
+		return fmt.Sprintf("%s is a reserved device name", device)
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWindowsNameProblem(t *testing.T) {
+	for device := range windowsReservedNames {
+		for _, name := range []string{device, strings.ToLower(device), device + ".txt", strings.ToLower(device) + ".tar.gz", device + " .txt"} {
+			if got := windowsNameProblem(name); !strings.Contains(got, device+" is a reserved device name") {
+				t.Errorf("windowsNameProblem(%q) = %q, want %s reported as reserved", name, got, device)
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "main.go"},
+		{name: "console.go"},
+		{name: "com10"},
+		{name: "nul_handler.go"},
+		{name: ".gitignore"},
+		{name: "a:b", want: `it contains the character ':'`},
+		{name: "what?.txt", want: `it contains the character '?'`},
+		{name: `x"y`, want: `it contains the character '"'`},
+		{name: "a|b", want: `it contains the character '|'`},
+		{name: "tab\tname", want: `it contains the control character '\t'`},
+		{name: "trailing.", want: "it ends with a space or a dot"},
+		{name: "trailing ", want: "it ends with a space or a dot"},
+	}
+	for _, tt := range tests {
+		if got := windowsNameProblem(tt.name); got != tt.want {
+			t.Errorf("windowsNameProblem(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPortablePaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		portable bool
+		value    string
+		wantErr  string
+	}{
+		{name: "reserved file name", path: "src/{{name}}.go", portable: true, value: "con", wantErr: `generated name "con.go" for src/{{name}}.go is not a valid Windows file name: CON is a reserved device name; choose a different value for name`},
+		{name: "reserved directory name", path: "{{name}}/main.go", portable: true, value: "aux", wantErr: `generated name "aux" for {{name}} is not a valid Windows file name`},
+		{name: "illegal character", path: "{{name}}.go", portable: true, value: "a:b", wantErr: `it contains the character ':'`},
+		{name: "valid name", path: "{{name}}/{{name}}.go", portable: true, value: "app"},
+		{name: "off by default", path: "{{name}}/{{name}}.go", value: "con"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" && !tt.portable {
+				t.Skip("the check is always on on Windows")
+			}
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{tt.path: "package main\n"})
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": tt.value})
+			cfg.PortablePaths = tt.portable
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package generator
+
+import "time"
+
+// etaWindow is the number of recent completions used to estimate throughput
+const etaWindow = 50
+
+// ProgressEvent describes the completion of a single file during generation
+type ProgressEvent struct {
+	// Path is the output path of the file
+	Path string
+	// Index is the number of files completed so far, starting at 1
+	Index int
+	// Total is the number of files planned for this run
+	Total int
+	// Err is the error that prevented the file from being generated, if any
+	Err error
+	// Rate is the recent throughput in files per second, or 0 until it can
+	// be estimated
+	Rate float64
+	// ETA is the estimated time until the remaining files complete, or 0
+	// when unknown
+	ETA time.Duration
+}
+
+// SetProgress registers a callback invoked once per file as it completes.
+// Calls are serialized, so the callback does not need to be safe for
+// concurrent use.
+func (g *Generator) SetProgress(fn func(ProgressEvent)) {
+	g.progress = fn
+}
+
+// resetProgress starts progress tracking for a new run
+func (g *Generator) resetProgress() {
+	g.progressDone = 0
+	g.progressETA = newETAEstimator(etaWindow)
+}
+
+// notifyProgress reports a completed file to the progress callback
+func (g *Generator) notifyProgress(path string, total int, err error) {
+	if g.progress == nil {
+		return
+	}
+
+	g.progressMu.Lock()
+	defer g.progressMu.Unlock()
+	g.progressDone++
+	rate := g.progressETA.observe(g.now())
+	g.progress(ProgressEvent{
+		Path:  path,
+		Index: g.progressDone,
+		Total: total,
+		Err:   err,
+		Rate:  rate,
+		ETA:   estimateETA(rate, total-g.progressDone),
+	})
+}
+
+// etaEstimator estimates throughput over a sliding window of the most
+// recent completion times, so the estimate follows changes in speed
+type etaEstimator struct {
+	times []time.Time
+	size  int
+}
+
+// newETAEstimator creates an estimator over the last size completions
+func newETAEstimator(size int) *etaEstimator {
+	return &etaEstimator{size: max(size, 2)}
+}
+
+// observe records a completion at t and returns the throughput in files per
+// second across the window, or 0 while it cannot be estimated
+func (e *etaEstimator) observe(t time.Time) float64 {
+	e.times = append(e.times, t)
+	if len(e.times) > e.size {
+		e.times = e.times[len(e.times)-e.size:]
+	}
+	if len(e.times) < 2 {
+		return 0
+	}
+
+	elapsed := e.times[len(e.times)-1].Sub(e.times[0])
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(len(e.times)-1) / elapsed.Seconds()
+}
+
+// estimateETA returns the time to complete remaining files at rate, or 0
+// when the rate is unknown or nothing remains
+func estimateETA(rate float64, remaining int) time.Duration {
+	if rate <= 0 || remaining <= 0 {
+		return 0
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second))
+}
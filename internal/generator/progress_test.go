@@ -0,0 +1,177 @@
+package generator
+
+import (
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	template := map[string]string{
+		"README.md":            "# {{name}}\n",
+		"cmd/__name__/main.go": "package main\n",
+		"docs/a.txt":           "a",
+		"docs/b.txt":           "b",
+		"logo.bin":             "\x00\x01\x02",
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		dir := t.TempDir()
+		tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+		writeTree(t, tmpl, template)
+
+		cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+		cfg.Concurrency = concurrency
+		g := newTestGenerator(cfg)
+		var events []ProgressEvent
+		g.SetProgress(func(e ProgressEvent) { events = append(events, e) })
+		if err := g.Generate(); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+
+		// One event per produced file, never for directories
+		if len(events) != len(template) {
+			t.Fatalf("concurrency %d: got %d events, want %d", concurrency, len(events), len(template))
+		}
+		var paths []string
+		for i, e := range events {
+			if e.Index != i+1 || e.Total != len(template) || e.Err != nil {
+				t.Errorf("concurrency %d: event %d = %+v, want index %d of %d", concurrency, i, e, i+1, len(template))
+			}
+			rel, err := filepath.Rel(out, e.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		slices.Sort(paths)
+		want := []string{"README.md", "cmd/app/main.go", "docs/a.txt", "docs/b.txt", "logo.bin"}
+		if !slices.Equal(paths, want) {
+			t.Errorf("concurrency %d: paths = %q, want %q", concurrency, paths, want)
+		}
+	}
+}
+
+func TestProgressReportsErrors(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{"a.txt": "a", "blocked": "b", "c.txt": "c"})
+	// A non-empty directory where a file belongs makes its write fail
+	writeTree(t, out, map[string]string{"blocked/inner.txt": "x"})
+
+	g := newTestGenerator(testConfig(tmpl, out, nil))
+	var failed []string
+	total := 0
+	g.SetProgress(func(e ProgressEvent) {
+		total = e.Total
+		if e.Err != nil {
+			failed = append(failed, filepath.Base(e.Path))
+		}
+	})
+	if err := g.Generate(); err == nil {
+		t.Fatal("Generate succeeded, want an error")
+	}
+	if total != 3 || !slices.Equal(failed, []string{"blocked"}) {
+		t.Errorf("total = %d, failed = %q; want 3 and [blocked]", total, failed)
+	}
+}
+
+func TestETAEstimator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	tests := []struct {
+		name     string
+		window   int
+		times    []int // completion times in milliseconds
+		total    int
+		wantRate float64
+		wantETA  time.Duration
+	}{
+		{name: "single completion", window: 10, times: []int{0}, total: 10},
+		{name: "steady", window: 10, times: []int{0, 100, 200, 300, 400}, total: 10, wantRate: 10, wantETA: 500 * time.Millisecond},
+		{name: "simultaneous completions", window: 10, times: []int{0, 0, 0}, total: 10},
+		{name: "done", window: 10, times: []int{0, 100, 200}, total: 3, wantRate: 10},
+		{
+			// Only the last three completions count, so the early slow
+			// files no longer weigh on the estimate
+			name:     "window follows a speedup",
+			window:   3,
+			times:    []int{0, 1000, 2000, 2050, 2100},
+			total:    15,
+			wantRate: 20,
+			wantETA:  500 * time.Millisecond,
+		},
+		{
+			name:     "window follows a slowdown",
+			window:   2,
+			times:    []int{0, 10, 20, 1020},
+			total:    6,
+			wantRate: 1,
+			wantETA:  2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newETAEstimator(tt.window)
+			var rate float64
+			for _, ms := range tt.times {
+				rate = e.observe(at(ms))
+			}
+			if math.Abs(rate-tt.wantRate) > 1e-9 {
+				t.Errorf("rate = %v, want %v", rate, tt.wantRate)
+			}
+			if eta := estimateETA(rate, tt.total-len(tt.times)); eta != tt.wantETA {
+				t.Errorf("ETA = %v, want %v", eta, tt.wantETA)
+			}
+		})
+	}
+}
+
+// steppingClock advances by step on every reading
+type steppingClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func TestProgressETA(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c", "d.txt": "d"})
+
+	cfg := testConfig(tmpl, out, nil)
+	cfg.Concurrency = 1
+	g := newTestGenerator(cfg)
+	g.SetClock(&steppingClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), step: 250 * time.Millisecond})
+	var events []ProgressEvent
+	g.SetProgress(func(e ProgressEvent) { events = append(events, e) })
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+	if events[0].Rate != 0 || events[0].ETA != 0 {
+		t.Errorf("first event = %+v, want no estimate yet", events[0])
+	}
+	for _, e := range events[1:] {
+		if e.Rate <= 0 {
+			t.Errorf("event %d has no rate", e.Index)
+		}
+		if want := estimateETA(e.Rate, e.Total-e.Index); e.ETA != want {
+			t.Errorf("event %d ETA = %v, want %v", e.Index, e.ETA, want)
+		}
+	}
+	if last := events[len(events)-1]; last.ETA != 0 {
+		t.Errorf("last event ETA = %v, want 0", last.ETA)
+	}
+}
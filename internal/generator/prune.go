@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeepFileName is the name of the allowlist file in the output directory
+// listing glob patterns of files that pruning must never remove
+const KeepFileName = ".stencil-keep"
+
+// prune removes files under the output directory that were not produced by
+// the current run. Symlinks are removed, never followed, so nothing outside
+// the output directory is touched.
+func (g *Generator) prune() error {
+	keep, err := loadKeepPatterns(filepath.Join(g.cfg.OutputDir, KeepFileName))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", KeepFileName, err)
+	}
+
+	produced := make(map[string]bool, len(g.report.Files))
+	for _, path := range g.report.Files {
+		produced[filepath.Clean(path)] = true
+	}
+
+	// A template inside the output directory is never stale
+	templateRel, templateInOutput := nestedDir(g.cfg.OutputDir, g.cfg.TemplateDir)
+
+	return filepath.Walk(g.cfg.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Never touch version control metadata, including the .git file
+		// of a worktree or submodule
+		if info.Name() == gitDirName {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() && templateInOutput {
+			if relPath, err := filepath.Rel(g.cfg.OutputDir, path); err == nil && relPath == templateRel {
+				return filepath.SkipDir
+			}
+		}
+		if info.IsDir() || produced[filepath.Clean(path)] {
+			return nil
+		}
+
+		// Backups of produced files are kept
+		if strings.HasSuffix(path, BackupSuffix) && produced[filepath.Clean(strings.TrimSuffix(path, BackupSuffix))] {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(g.cfg.OutputDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == KeepFileName || relPath == ValuesFileName || matchesAny(keep, relPath) {
+			return nil
+		}
+
+		if g.cfg.DryRun {
+			g.logger.Info("[DRY RUN] Would remove stale file: "+path, "path", path)
+		} else if err := os.Remove(path); err != nil {
+			g.report.Error(relPath, fmt.Errorf("failed to remove stale file: %w", err))
+			return nil
+		}
+		g.report.Pruned = append(g.report.Pruned, path)
+		return nil
+	})
+}
+
+// loadKeepPatterns reads glob patterns from a keep file, one per line.
+// Blank lines and lines starting with '#' are ignored.
+func loadKeepPatterns(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readPatterns(file)
+}
+
+// readPatterns reads glob patterns, one per line, skipping blank lines and
+// '#' comments
+func readPatterns(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// matchesAny reports whether relPath, or its base name, matches any of the
+// glob patterns
+func matchesAny(patterns []string, relPath string) bool {
+	slashPath := filepath.ToSlash(relPath)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, slashPath); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(relPath)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name     string
+		template map[string]string
+		output   map[string]string
+		onEmpty  string
+		cleanup  bool
+		wantErr  bool
+		want     map[string]string
+	}{
+		{
+			name:     "removes stale files",
+			template: map[string]string{"a.txt": "a {{name}}"},
+			output:   map[string]string{"a.txt": "old", "stale.txt": "stale"},
+			want:     map[string]string{"a.txt": "a x"},
+		},
+		{
+			name:     "keeps patterns in the keep file",
+			template: map[string]string{"a.txt": "a"},
+			output:   map[string]string{".stencil-keep": "*.log\n", "debug.log": "log", "stale.txt": "stale"},
+			want:     map[string]string{"a.txt": "a", ".stencil-keep": "*.log\n", "debug.log": "log"},
+		},
+		{
+			name:     "keeps the git directory",
+			template: map[string]string{"a.txt": "a"},
+			output:   map[string]string{".git/HEAD": "ref: refs/heads/main\n", "stale.txt": "stale"},
+			want:     map[string]string{"a.txt": "a", ".git/HEAD": "ref: refs/heads/main\n"},
+		},
+		{
+			name:     "keeps a git file",
+			template: map[string]string{"a.txt": "a"},
+			output:   map[string]string{".git": "gitdir: ../repo/.git/worktrees/output\n", "stale.txt": "stale"},
+			want:     map[string]string{"a.txt": "a", ".git": "gitdir: ../repo/.git/worktrees/output\n"},
+		},
+		{
+			name:     "keeps everything when a file fails",
+			template: map[string]string{"a.txt": "a {{name}}", "b.txt": "b {{empty}}"},
+			output:   map[string]string{"b.txt": "previous", "stale.txt": "stale"},
+			onEmpty:  "error",
+			wantErr:  true,
+			want:     map[string]string{"a.txt": "a x", "b.txt": "previous", "stale.txt": "stale"},
+		},
+		{
+			name:     "removes only created files when a file fails",
+			template: map[string]string{"a.txt": "a {{name}}", "b.txt": "b {{empty}}"},
+			output:   map[string]string{"b.txt": "previous", "stale.txt": "stale"},
+			onEmpty:  "error",
+			cleanup:  true,
+			wantErr:  true,
+			want:     map[string]string{"b.txt": "previous", "stale.txt": "stale"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, tt.template)
+			writeTree(t, out, tt.output)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "x", "empty": ""})
+			cfg.PruneOutput = true
+			cfg.OnEmptyValue = tt.onEmpty
+			cfg.CleanupOnError = tt.cleanup
+			err := newTestGenerator(cfg).Generate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := readTree(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("output = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderFile(t *testing.T) {
+	template := map[string]string{
+		"README.md":        "# {{name}}\n",
+		"cmd/main.go":      "package main // <<name>>\n",
+		"assets/logo.bin":  "\x00\x01{{name}}",
+		"docs/__name__.md": "{{name}} docs",
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "text file", path: "README.md", want: "# app\n"},
+		{name: "nested text file", path: filepath.Join("cmd", "main.go"), want: "package main // app\n"},
+		{name: "binary file unchanged", path: filepath.Join("assets", "logo.bin"), want: "\x00\x01{{name}}"},
+		{name: "template path with placeholder", path: filepath.Join("docs", "__name__.md"), want: "app docs"},
+		{name: "nonexistent path", path: "missing.txt", wantErr: "failed to stat template file"},
+		{name: "directory", path: "cmd", wantErr: "not a file"},
+		{name: "outside the template", path: filepath.Join("..", "secret.txt"), wantErr: "outside the template"},
+		{name: "absolute path", path: string(filepath.Separator) + "etc", wantErr: "outside the template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			templateDir, outputDir := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, templateDir, template)
+			writeTree(t, dir, map[string]string{"secret.txt": "secret"})
+
+			got, err := newTestGenerator(testConfig(templateDir, outputDir, map[string]string{"name": "app"})).RenderFile(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RenderFile error = %v, want one containing %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("RenderFile failed: %v", err)
+				}
+				if !bytes.Equal(got, []byte(tt.want)) {
+					t.Errorf("RenderFile = %q, want %q", got, tt.want)
+				}
+			}
+
+			// Nothing is written
+			if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+				t.Errorf("RenderFile created the output directory")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Severity classifies a generation issue
+type Severity int
+
+const (
+	// SeverityWarning marks an issue that did not stop a file from being generated
+	SeverityWarning Severity = iota
+	// SeverityError marks an issue that prevented a file from being generated
+	SeverityError
+)
+
+// String returns the display name of the severity
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a single problem found during generation
+type Issue struct {
+	Severity Severity
+	Path     string
+	Message  string
+}
+
+// String formats the issue for display
+func (i Issue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// GenerationReport collects the issues found during a generation run so they
+// can be surfaced together instead of failing on the first one
+type GenerationReport struct {
+	// mu guards Issues, which workers append to concurrently
+	mu sync.Mutex
+
+	Issues []Issue
+
+	// Files lists the output paths produced by the run
+	Files []string
+
+	// Pruned lists the stale output paths removed by pruning
+	Pruned []string
+
+	// Backups lists the backup files written before overwriting
+	Backups []string
+
+	// Skipped lists the generate-once files kept because they already
+	// existed; they are also listed in Files
+	Skipped []string
+}
+
+// Warn records a warning
+func (r *GenerationReport) Warn(path, format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Issues = append(r.Issues, Issue{Severity: SeverityWarning, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Error records a hard error
+func (r *GenerationReport) Error(path string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Issues = append(r.Issues, Issue{Severity: SeverityError, Path: path, Message: err.Error()})
+}
+
+// addBackup records a backup file written by a worker
+func (r *GenerationReport) addBackup(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Backups = append(r.Backups, path)
+}
+
+// Warnings returns the recorded warnings
+func (r *GenerationReport) Warnings() []Issue {
+	return r.filter(SeverityWarning)
+}
+
+// Errors returns the recorded hard errors
+func (r *GenerationReport) Errors() []Issue {
+	return r.filter(SeverityError)
+}
+
+// HasErrors reports whether any hard error was recorded
+func (r *GenerationReport) HasErrors() bool {
+	return len(r.Errors()) > 0
+}
+
+// Summary formats all issues as a consolidated section, or returns an empty
+// string when there is nothing to report
+func (r *GenerationReport) Summary() string {
+	if len(r.Issues) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("=== Issues ===\n")
+	for _, sev := range []Severity{SeverityError, SeverityWarning} {
+		for _, issue := range r.filter(sev) {
+			fmt.Fprintf(&b, "  [%s] %s\n", sev, issue)
+		}
+	}
+	fmt.Fprintf(&b, "%d error(s), %d warning(s)\n", len(r.Errors()), len(r.Warnings()))
+	return b.String()
+}
+
+func (r *GenerationReport) filter(sev Severity) []Issue {
+	var issues []Issue
+	for _, issue := range r.Issues {
+		if issue.Severity == sev {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestReportCollectsIssues checks that one run reports every problem it
+// finds rather than stopping at the first
+func TestReportCollectsIssues(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{
+		"a.txt":      "{{name}} {{missing}}\n",
+		"b.txt":      "{{name}}\nbroken {{name\n",
+		"blocked":    "{{name}}\n",
+		"keep.txt":   "{{name}}\n",
+		"z.txt":      "{{name}}\n",
+		manifestFile: `{"once": ["keep.txt"]}`,
+	})
+	// A non-empty directory where a file belongs makes its write fail
+	writeTree(t, out, map[string]string{"blocked/inner.txt": "x", "keep.txt": "mine\n"})
+
+	g := newTestGenerator(testConfig(tmpl, out, map[string]string{"name": "app"}))
+	err := g.Generate()
+	if err == nil || !strings.Contains(err.Error(), "generation finished with 1 error(s)") {
+		t.Fatalf("Generate error = %v, want one error", err)
+	}
+
+	report := g.Report()
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, issue.Severity.String()+" "+issue.String())
+	}
+	for _, want := range []string{
+		"warning a.txt: unresolved variable: missing",
+		"warning b.txt: line 2: unterminated placeholder",
+		"error blocked: ",
+	} {
+		if !slices.ContainsFunc(got, func(s string) bool { return strings.HasPrefix(s, want) }) {
+			t.Errorf("no issue %q in %q", want, got)
+		}
+	}
+	if len(report.Errors()) != 1 || len(report.Warnings()) != 2 {
+		t.Errorf("got %d error(s) and %d warning(s), want 1 and 2", len(report.Errors()), len(report.Warnings()))
+	}
+	if want := []string{filepath.Join(out, "keep.txt")}; !slices.Equal(report.Skipped, want) {
+		t.Errorf("Skipped = %q, want %q", report.Skipped, want)
+	}
+
+	// Files after the failing one are still generated
+	if files := readTree(t, out); files["z.txt"] != "app\n" || files["keep.txt"] != "mine\n" {
+		t.Errorf("output = %q, want z.txt generated and keep.txt kept", files)
+	}
+}
+
+func TestReportSummary(t *testing.T) {
+	var empty GenerationReport
+	if s := empty.Summary(); s != "" {
+		t.Errorf("empty Summary() = %q, want \"\"", s)
+	}
+	if empty.HasErrors() {
+		t.Error("empty report has errors")
+	}
+
+	var r GenerationReport
+	r.Warn("a.txt", "unresolved variable: %s", "x")
+	r.Error("b.txt", errors.New("permission denied"))
+	r.Warn("", "no path")
+	want := "=== Issues ===\n" +
+		"  [error] b.txt: permission denied\n" +
+		"  [warning] a.txt: unresolved variable: x\n" +
+		"  [warning] no path\n" +
+		"1 error(s), 2 warning(s)\n"
+	if s := r.Summary(); s != want {
+		t.Errorf("Summary() = %q, want %q", s, want)
+	}
+	if !r.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+}
@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// TemplateStats summarizes a template without generating it
+type TemplateStats struct {
+	Files       int   `json:"files"`
+	TextFiles   int   `json:"textFiles"`
+	BinaryFiles int   `json:"binaryFiles"`
+	Directories int   `json:"directories"`
+	Variables   int   `json:"variables"`
+	TotalBytes  int64 `json:"totalBytes"`
+}
+
+// Stats scans the template and returns its statistics
+func (g *Generator) Stats() (*TemplateStats, error) {
+	_, stats, err := g.scanTemplate()
+	return stats, err
+}
+
+// Variables returns the variables used by the template in order of first
+// appearance
+func (g *Generator) Variables() ([]string, error) {
+	variables, _, err := g.scanTemplate()
+	return variables, err
+}
+
+// scanTemplate walks the template once, extracting variables from paths and
+// text content in first-seen order and collecting statistics
+func (g *Generator) scanTemplate() ([]string, *TemplateStats, error) {
+	var variables []string
+	seen := make(map[string]bool)
+	stats := &TemplateStats{}
+
+	m, err := g.loadManifest()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	iterating := m != nil && len(m.Iterate) > 0
+
+	// Names arrive canonicalized. With case-insensitive matching, report
+	// the first-seen spelling. The item of iterated files is bound by
+	// generation, never asked for.
+	addVariable := func(v string) {
+		if v == "" || (iterating && v == ItemVariable) {
+			return
+		}
+		key := v
+		if g.cfg.CaseInsensitiveVars {
+			key = strings.ToLower(v)
+		}
+		if !seen[key] {
+			seen[key] = true
+			variables = append(variables, v)
+		}
+	}
+
+	if err := g.loadRules(); err != nil {
+		return nil, nil, err
+	}
+
+	err = g.walkTemplate(func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(g.cfg.TemplateDir, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && info.Name() == gitDirName {
+			return filepath.SkipDir
+		}
+		if relPath != "." {
+			if skip, err := g.skipIgnored(relPath, info); skip {
+				return err
+			}
+			if skip, err := g.skipUnscanned(relPath, info); skip {
+				return err
+			}
+		}
+
+		if info.IsDir() {
+
+			// Extract variables from directory names
+			if relPath != "." {
+				stats.Directories++
+				if g.cfg.ReplaceInPaths {
+					for _, v := range replacer.ExtractCanonicalFromPath(relPath, g.cfg.Formats, g.canonicalize) {
+						addVariable(v)
+					}
+				}
+			}
+			return nil
+		}
+
+		// Extract variables from file names
+		if isControlFile(relPath) {
+			return nil
+		}
+		stats.Files++
+		stats.TotalBytes += info.Size()
+		if g.cfg.ReplaceInPaths {
+			for _, v := range replacer.ExtractCanonicalFromPath(relPath, g.cfg.Formats, g.canonicalize) {
+				addVariable(v)
+			}
+		}
+
+		// Extract variables from file content
+		if g.isBinaryTemplateFile(path) {
+			stats.BinaryFiles++
+			return nil
+		}
+		stats.TextFiles++
+		if !g.cfg.ReplaceInContent {
+			return nil
+		}
+
+		content, err := g.readTemplateFile(path)
+		if err != nil {
+			return err
+		}
+		formats := g.formatsFor(relPath)
+		content = replacer.StripComments(stripFrontMatter(content), formats)
+		for _, v := range replacer.DirectiveVariables(content, g.cfg.DirectivePrefixes) {
+			addVariable(g.canonicalize(v))
+		}
+		for _, v := range replacer.ExtractCanonicalFromFile(content, formats, g.canonicalize) {
+			addVariable(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// List variables of iterate rules need values even though they appear
+	// in no placeholder
+	if iterating {
+		for _, pattern := range slices.Sorted(maps.Keys(m.Iterate)) {
+			addVariable(g.canonicalize(m.Iterate[pattern]))
+		}
+	}
+
+	stats.Variables = len(variables)
+	return variables, stats, nil
+}
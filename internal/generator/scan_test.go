@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	tmpl := t.TempDir()
+	writeTree(t, tmpl, map[string]string{
+		"README.md":            "# {{name}}\n",           // 11 bytes
+		"cmd/__name__/main.go": "package {{pkg}}\n",      // 16 bytes
+		"assets/logo.png":      "\x89PNG\x00\x01",        // 6 bytes
+		"docs/guide.md":        "{{name}} by {{author}}", // 22 bytes
+		manifestFile:           `{"variables": [{"name": "name"}]}`,
+	})
+	if err := os.Mkdir(filepath.Join(tmpl, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newTestGenerator(testConfig(tmpl, t.TempDir(), nil))
+	stats, err := g.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	want := TemplateStats{
+		Files:       4,
+		TextFiles:   3,
+		BinaryFiles: 1,
+		Directories: 5,
+		Variables:   3,
+		TotalBytes:  55,
+	}
+	if *stats != want {
+		t.Errorf("Stats() = %+v, want %+v", *stats, want)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"files":4,"textFiles":3,"binaryFiles":1,"directories":5,"variables":3,"totalBytes":55}`
+	if string(data) != wantJSON {
+		t.Errorf("JSON = %s, want %s", data, wantJSON)
+	}
+
+	// The same walk lists the variables in order of first appearance
+	variables, err := g.Variables()
+	if err != nil {
+		t.Fatalf("Variables failed: %v", err)
+	}
+	if want := []string{"name", "pkg", "author"}; !slices.Equal(variables, want) {
+		t.Errorf("Variables() = %q, want %q", variables, want)
+	}
+}
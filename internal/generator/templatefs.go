@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/manifest"
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// NewGeneratorFromFS creates a Generator that reads the template from root
+// inside fsys, e.g. an embed.FS, instead of the OS file system. Output is
+// still written to cfg.OutputDir. Symlink following, the sandbox and
+// variable commands only apply to templates on disk.
+func NewGeneratorFromFS(fsys fs.FS, root string, cfg *config.Config) *Generator {
+	if root == "" {
+		root = "."
+	}
+	cfg.TemplateDir = root
+	g := NewGenerator(cfg)
+	g.fsys = fsys
+	return g
+}
+
+// readTemplateFile reads a file of the template. Reads go through the
+// generator's content cache, so a file scanned for variables is not read
+// again when it is generated.
+func (g *Generator) readTemplateFile(path string) ([]byte, error) {
+	info, err := g.statTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+	if content, ok := g.contents.get(path, info); ok {
+		return content, nil
+	}
+
+	var content []byte
+	if g.fsys == nil {
+		content, err = os.ReadFile(path)
+	} else {
+		content, err = fs.ReadFile(g.fsys, filepath.ToSlash(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.contents.put(path, info, content)
+	return content, nil
+}
+
+// openTemplateFile opens a file of the template for reading
+func (g *Generator) openTemplateFile(path string) (io.ReadCloser, error) {
+	if g.fsys == nil {
+		return os.Open(path)
+	}
+	return g.fsys.Open(filepath.ToSlash(path))
+}
+
+// statTemplate returns information about a path of the template
+func (g *Generator) statTemplate(path string) (os.FileInfo, error) {
+	if g.fsys == nil {
+		return os.Stat(path)
+	}
+	return fs.Stat(g.fsys, filepath.ToSlash(path))
+}
+
+// isBinaryTemplateFile reports whether a template file is binary and copied
+// as-is; with ForceTextReplacement no file is
+func (g *Generator) isBinaryTemplateFile(path string) bool {
+	if g.cfg.ForceTextReplacement {
+		return false
+	}
+	if g.fsys == nil {
+		return replacer.IsBinaryFile(path)
+	}
+	file, err := g.openTemplateFile(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	return replacer.IsBinary(file)
+}
+
+// loadManifest loads the template's manifest, if any, with the variables
+// declared in the front matter of its files added
+func (g *Generator) loadManifest() (*manifest.Manifest, error) {
+	var m *manifest.Manifest
+	var err error
+	if g.fsys == nil {
+		m, err = manifest.Load(g.cfg.TemplateDir)
+	} else {
+		m, err = manifest.LoadFS(g.fsys, filepath.ToSlash(g.cfg.TemplateDir))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g.withFrontMatter(m)
+}
+
+// walkTemplateFS walks a template inside fsys, adapting fs.WalkDir to the
+// filepath.WalkFunc used for templates on disk
+func (g *Generator) walkTemplateFS(fn filepath.WalkFunc) error {
+	return fs.WalkDir(g.fsys, filepath.ToSlash(g.cfg.TemplateDir), func(path string, d fs.DirEntry, err error) error {
+		var info os.FileInfo
+		if err == nil {
+			info, err = d.Info()
+		}
+		if info != nil {
+			info = writableInfo{info}
+		}
+		return fn(path, info, err)
+	})
+}
+
+// writableInfo grants the owner write permission, which read-only file
+// systems such as embed.FS never report, so generated files stay editable
+type writableInfo struct {
+	os.FileInfo
+}
+
+// Mode returns the underlying mode with the owner write bit set
+func (w writableInfo) Mode() os.FileMode {
+	return w.FileInfo.Mode() | 0200
+}
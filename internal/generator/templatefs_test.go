@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewGeneratorFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/app/" + manifestFile:           {Data: []byte(`{"variables": [{"name": "name"}, {"name": "owner", "default": "acme"}]}`)},
+		"templates/app/" + IgnoreFileName:         {Data: []byte("*.tmp\n")},
+		"templates/app/README.md":                 {Data: []byte("# {{name}} by {{owner}}\n")},
+		"templates/app/{{name}}/main.go":          {Data: []byte("package {{name}}\n")},
+		"templates/app/bin/run.sh":                {Data: []byte("#!/bin/sh\necho {{name}}\n"), Mode: 0555},
+		"templates/app/logo.png":                  {Data: []byte("\x89PNG\x00{{name}}")},
+		"templates/app/scratch.tmp":               {Data: []byte("ignored\n")},
+		"templates/other/README.md":               {Data: []byte("# other\n")},
+		"templates/app/{{name}}/empty/.gitkeep":   {Data: nil},
+		"templates/app/{{name}}/docs/{{name}}.md": {Data: []byte("{{name}}\n")},
+	}
+
+	out := filepath.Join(t.TempDir(), "output")
+	cfg := testConfig("", out, map[string]string{"name": "demo"})
+	g := NewGeneratorFromFS(fsys, "templates/app", cfg)
+	g.SetLogger(NewConsoleLogger(io.Discard, io.Discard))
+
+	vars, err := g.ExtractVariables()
+	if err != nil {
+		t.Fatalf("ExtractVariables failed: %v", err)
+	}
+	if want := map[string]string{"name": "", "owner": "acme"}; !maps.Equal(vars, want) {
+		t.Errorf("variables = %q, want %q", vars, want)
+	}
+
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := map[string]string{
+		"README.md":           "# demo by acme\n",
+		"demo/main.go":        "package demo\n",
+		"bin/run.sh":          "#!/bin/sh\necho demo\n",
+		"logo.png":            "\x89PNG\x00{{name}}",
+		"demo/empty/.gitkeep": "",
+		"demo/docs/demo.md":   "demo\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Modes come from the FS, with the owner write bit added since
+	// embedded files are read-only
+	info, err := os.Stat(filepath.Join(out, "bin", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0700 != 0700 {
+		t.Errorf("run.sh mode = %v, want owner rwx", info.Mode().Perm())
+	}
+}
+
+func TestNewGeneratorFromFSRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go": {Data: []byte("package {{name}}\n")},
+	}
+
+	out := filepath.Join(t.TempDir(), "output")
+	g := NewGeneratorFromFS(fsys, "", testConfig("", out, map[string]string{"name": "demo"}))
+	g.SetLogger(NewConsoleLogger(io.Discard, io.Discard))
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got, want := readTree(t, out), map[string]string{"main.go": "package demo\n"}; !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
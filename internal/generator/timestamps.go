@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"os"
+	"time"
+)
+
+// preserveTimestamps sets a generated file's access and modification times
+// to those of its template file. Sources without a modification time, such
+// as embedded files, are left alone.
+func preserveTimestamps(targetPath string, info os.FileInfo) error {
+	mtime := info.ModTime()
+	if mtime.IsZero() {
+		return nil
+	}
+	return os.Chtimes(targetPath, accessTime(info), mtime)
+}
+
+// accessTime returns the file's access time where the platform reports it,
+// falling back to its modification time
+func accessTime(info os.FileInfo) time.Time {
+	if atime, ok := platformAccessTime(info); ok {
+		return atime
+	}
+	return info.ModTime()
+}
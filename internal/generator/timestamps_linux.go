@@ -0,0 +1,18 @@
+//go:build linux
+
+package generator
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// platformAccessTime returns the access time recorded by the file system
+func platformAccessTime(info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(stat.Atim.Sec, stat.Atim.Nsec), true
+}
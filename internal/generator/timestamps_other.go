@@ -0,0 +1,13 @@
+//go:build !linux
+
+package generator
+
+import (
+	"os"
+	"time"
+)
+
+// platformAccessTime reports that the access time is not available
+func platformAccessTime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
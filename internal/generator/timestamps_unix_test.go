@@ -0,0 +1,59 @@
+//go:build unix
+
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPreserveTimestamps(t *testing.T) {
+	mtime := time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)
+	atime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	files := []string{"README.md", "sub/main.go", "logo.png"}
+
+	for _, preserve := range []bool{true, false} {
+		dir := t.TempDir()
+		tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+		writeTree(t, tmpl, map[string]string{
+			"README.md":   "# {{name}}\n",
+			"sub/main.go": "package {{name}}\n",
+			"logo.png":    "\x89PNG\x00\x01",
+		})
+		for _, file := range files {
+			if err := os.Chtimes(filepath.Join(tmpl, file), atime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+		cfg.PreserveTimestamps = preserve
+		if err := newTestGenerator(cfg).Generate(); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+
+		for _, file := range files {
+			info, err := os.Stat(filepath.Join(out, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.ModTime(); got.Equal(mtime) != preserve {
+				t.Errorf("preserve %v: %s mtime = %v, template has %v", preserve, file, got, mtime)
+			}
+
+			// Reading the template may update its access time, so compare
+			// with the time it has after generating
+			source, err := os.Stat(filepath.Join(tmpl, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := platformAccessTime(info)
+			want, _ := platformAccessTime(source)
+			if ok && preserve && !got.Equal(want) {
+				t.Errorf("%s atime = %v, want %v", file, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// trial generates into a temporary copy of the output directory, exercising
+// the real write path, and then discards it. Errors a real run would hit are
+// reported as usual; report paths refer to the real output directory.
+func (g *Generator) trial() error {
+	if g.cfg.RequireCleanGit {
+		if err := checkCleanGit(g.cfg.OutputDir); err != nil {
+			return err
+		}
+	}
+
+	return g.generateScratch("stencil-trial-*", nil)
+}
+
+// scratchDir pairs a directory of a scratch run with the real directory it
+// stands for
+type scratchDir struct {
+	scratch, real string
+}
+
+// generateScratch generates into a temporary copy of the output directory
+// and of every outputMap root outside it and, if inspect is set, calls it
+// with those directories, the output directory first, before the copy is
+// discarded. Report paths refer to the real directories afterwards.
+func (g *Generator) generateScratch(pattern string, inspect func(dirs []scratchDir) error) error {
+	root, err := os.MkdirTemp(g.cfg.TempDir, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(root)
+
+	// Nest the output so outputMap roots above it stay inside the scratch
+	// directory
+	m, err := g.loadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	tmp := root
+	for range scratchDepth(m) {
+		tmp = filepath.Join(tmp, "output")
+	}
+
+	// Start from the current output and mapped roots so that conflicts with
+	// existing files surface
+	dirs := []scratchDir{{tmp, g.cfg.OutputDir}}
+	for _, rel := range g.externalRoots(m) {
+		dir := scratchDir{filepath.Join(tmp, rel), filepath.Join(g.cfg.OutputDir, rel)}
+		if withinDir(dir.scratch, tmp) {
+			return fmt.Errorf("outputMap directory %s contains the output directory, which a scratch run cannot copy", rel)
+		}
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs {
+		if err := copyTree(dir.real, dir.scratch); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", dir.real, err)
+		}
+	}
+
+	saved := *g.cfg
+	defer func() {
+		*g.cfg = saved
+		g.report.rebase(dirs)
+	}()
+	g.cfg.Trial = false
+	g.cfg.DryRun = false
+	g.cfg.RequireCleanGit = false
+	g.cfg.OutputDir = tmp
+
+	// Comparing output does not need the project validated; a trial does
+	if inspect != nil {
+		g.skipValidation = true
+		defer func() { g.skipValidation = false }()
+	}
+
+	if err := g.Generate(); err != nil {
+		return err
+	}
+	if inspect != nil {
+		return inspect(dirs)
+	}
+	return nil
+}
+
+// copyTree copies the files and directories under src into dst, preserving
+// modes. A missing src copies nothing; git metadata is skipped.
+func copyTree(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		switch {
+		case info.IsDir() && info.Name() == gitDirName:
+			return filepath.SkipDir
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// rebase rewrites recorded output paths, and paths mentioned in issues,
+// under a scratch directory to be under the real one
+func (r *GenerationReport) rebase(dirs []scratchDir) {
+	rebase := func(paths []string) {
+		for i, path := range paths {
+			for _, dir := range dirs {
+				if rel, err := filepath.Rel(dir.scratch, path); err == nil && !strings.HasPrefix(rel, "..") {
+					paths[i] = filepath.Join(dir.real, rel)
+					break
+				}
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rebase(r.Files)
+	rebase(r.Pruned)
+	rebase(r.Backups)
+	rebase(r.Skipped)
+	for i := range r.Issues {
+		for _, dir := range dirs {
+			r.Issues[i].Message = strings.ReplaceAll(r.Issues[i].Message, dir.scratch, dir.real)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrial(t *testing.T) {
+	template := map[string]string{
+		"README.md":     "# {{name}}\n",
+		"src/main.go":   "package {{name}}\n",
+		"docs/intro.md": "intro\n",
+	}
+
+	tests := []struct {
+		name     string
+		existing map[string]string // output content before the run
+		dryRun   bool
+		wantErr  bool
+	}{
+		{name: "empty output"},
+		{name: "existing files", existing: map[string]string{"notes.txt": "keep\n"}},
+		{
+			// A directory where the template writes a file is only caught
+			// by the real write path
+			name:     "collision missed by dry run",
+			existing: map[string]string{"README.md/old.txt": "old\n"},
+			dryRun:   true,
+		},
+		{
+			name:     "collision surfaced by trial",
+			existing: map[string]string{"README.md/old.txt": "old\n"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+			if tt.existing != nil {
+				writeTree(t, out, tt.existing)
+			}
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.DryRun = tt.dryRun
+			cfg.Trial = !tt.dryRun
+			cfg.TempDir = filepath.Join(dir, "tmp")
+			if err := os.Mkdir(cfg.TempDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			g := newTestGenerator(cfg)
+			err := g.Generate()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Generate succeeded, want the collision reported")
+				}
+				errs := g.Report().Errors()
+				if len(errs) != 1 || errs[0].Path != "README.md" || strings.Contains(errs[0].Message, cfg.TempDir) {
+					t.Errorf("errors = %q, want one for README.md naming the real output", errs)
+				}
+			} else if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+
+			// Neither mode touches the output, and a trial cleans up after
+			// itself
+			if got := readTree(t, out); !maps.Equal(got, tt.existing) && len(got)+len(tt.existing) > 0 {
+				t.Errorf("output = %q, want %q", got, tt.existing)
+			}
+			if entries, err := os.ReadDir(cfg.TempDir); err != nil || len(entries) != 0 {
+				t.Errorf("temp directory holds %v (%v), want it empty", entries, err)
+			}
+			if cfg.OutputDir != out || cfg.Trial == tt.dryRun {
+				t.Errorf("config not restored: output %s, trial %v", cfg.OutputDir, cfg.Trial)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// checkUnusedVariables returns an error naming the supplied variables that
+// nothing uses, which usually means a misspelled name. A variable counts as
+// used when it appears in the template, in the template or output
+// directory setting, in another variable's value or in a formatter
+// command, or when the manifest declares it or refers to it in conditions,
+// iterations, derived variables, the header, validate commands or the post
+// message. Built-in variables are exempt.
+func (g *Generator) checkUnusedVariables(supplied []string) error {
+	names, _, err := g.scanTemplate()
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	use := func(name string) {
+		used[g.unusedKey(name)] = true
+	}
+	useText := func(text string) {
+		for _, name := range replacer.ExtractCanonicalFromPath(text, g.cfg.Formats, g.canonicalize) {
+			use(name)
+		}
+	}
+
+	for _, name := range names {
+		use(name)
+	}
+	for name := range g.pathVariables {
+		use(name)
+	}
+	for _, value := range g.cfg.Variables {
+		useText(value)
+	}
+	for _, command := range g.cfg.Formatters {
+		useText(command)
+	}
+
+	m, err := g.loadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	if m != nil {
+		for _, condition := range m.Conditions {
+			use(strings.TrimPrefix(condition, "!"))
+		}
+		for _, list := range m.Iterate {
+			use(list)
+		}
+		for _, v := range m.Variables {
+			use(v.Name)
+			if v.When != "" {
+				use(strings.TrimSpace(strings.TrimPrefix(v.When, "!")))
+			}
+		}
+		for _, spec := range m.Derived {
+			if source, _, err := parseDerived(spec); err == nil {
+				use(source)
+			}
+		}
+		for _, command := range m.Validate {
+			useText(command)
+		}
+		if m.Header != nil {
+			useText(m.Header.Text)
+		}
+		useText(m.PostMessage)
+	}
+
+	builtins := g.BuiltinVariables()
+	var unused []string
+	for _, name := range slices.Sorted(slices.Values(supplied)) {
+		if _, builtin := builtins[name]; builtin || name == ItemVariable {
+			continue
+		}
+		if !used[g.unusedKey(g.canonicalize(name))] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		return fmt.Errorf("variables not used by the template: %s", strings.Join(unused, ", "))
+	}
+	return nil
+}
+
+// unusedKey returns the key a variable name is compared under when looking
+// for unused variables
+func (g *Generator) unusedKey(name string) string {
+	if g.cfg.CaseInsensitiveVars {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// suppliedVariables returns the names of the variables given before
+// manifest defaults are applied
+func (g *Generator) suppliedVariables() []string {
+	return slices.Collect(maps.Keys(g.cfg.Variables))
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestErrorOnUnusedVars(t *testing.T) {
+	template := map[string]string{
+		manifestFile:        `{"variables": [{"name": "license", "default": "MIT"}], "postMessage": "cd {{dir}}"}`,
+		"README.md":         "# {{project_name}}\n",
+		"{{module}}/doc.go": "// {{description}}\n",
+	}
+
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		disabled bool
+		wantErr  string
+	}{
+		{
+			name: "all used",
+			vars: map[string]string{"project_name": "app", "module": "m", "description": "d"},
+		},
+		{
+			name: "used through declarations, values and the post message",
+			vars: map[string]string{
+				"project_name": "app", "module": "m", "description": "about {{owner}}",
+				"owner": "Jane", "license": "Apache-2.0", "dir": "app",
+			},
+		},
+		{
+			name: "built-ins are exempt",
+			vars: map[string]string{"project_name": "app", "module": "m", "description": "d", "__year__": "2031"},
+		},
+		{
+			name:    "misspelled names",
+			vars:    map[string]string{"porject_name": "app", "project_name": "app", "module": "m", "description": "d", "moduel": "m"},
+			wantErr: "variables not used by the template: moduel, porject_name",
+		},
+		{
+			name:     "off by default",
+			vars:     map[string]string{"porject_name": "app", "project_name": "app", "module": "m", "description": "d"},
+			disabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, tt.vars)
+			cfg.ErrorOnUnusedVars = !tt.disabled
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				if got := readTree(t, out); len(got) != 0 {
+					t.Errorf("output = %q, want nothing generated", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// ValidateValue checks a value against the type the manifest declares for
+// the variable, so interactive mode can re-prompt for invalid answers
+func (g *Generator) ValidateValue(name, value string) error {
+	m, err := g.loadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	v, ok := m.Variable(g.canonicalName(name))
+	if !ok {
+		return nil
+	}
+	return v.Validate(value)
+}
+
+// PromptConditions returns the when conditions the manifest declares,
+// keyed by variable name
+func (g *Generator) PromptConditions() (map[string]string, error) {
+	m, err := g.loadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	conditions := make(map[string]string)
+	if m != nil {
+		for _, v := range m.Variables {
+			if v.When != "" {
+				conditions[v.Name] = v.When
+			}
+		}
+	}
+	return conditions, nil
+}
+
+// PromptHelp returns the variables the manifest declares, keyed by name, so
+// interactive mode can show their descriptions and examples
+func (g *Generator) PromptHelp() (map[string]manifest.Variable, error) {
+	m, err := g.loadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	help := make(map[string]manifest.Variable)
+	if m != nil {
+		for _, v := range m.Variables {
+			help[v.Name] = v
+		}
+	}
+	return help, nil
+}
+
+// validateVariables checks every variable value against its declared type
+func (g *Generator) validateVariables() error {
+	m, err := g.loadManifest()
+	if err != nil || m == nil {
+		return err
+	}
+
+	names := make([]string, 0, len(g.cfg.Variables))
+	for name := range g.cfg.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, ok := m.Variable(g.canonicalName(name))
+		if !ok {
+			continue
+		}
+		if err := v.Validate(g.cfg.Variables[name]); err != nil {
+			return fmt.Errorf("invalid value for variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// ValuesFileName is the name of the file in the output directory recording
+// the resolved variable values when WriteValues is set
+const ValuesFileName = "stencil.values.json"
+
+// writeValues records the resolved variables, after manifest defaults,
+// computed and expanded values, in the output directory so a later run can
+// reuse them with --var-file. Variables the manifest marks as secret are
+// left out.
+func (g *Generator) writeValues() error {
+	m, err := g.loadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+
+	values := make(map[string]string, len(g.cfg.Variables))
+	for name, value := range g.cfg.Variables {
+		if v, ok := m.Variable(g.canonicalName(name)); ok && v.Secret {
+			continue
+		}
+		values[name] = value
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join(g.cfg.OutputDir, ValuesFileName)
+	if g.cfg.DryRun {
+		g.logger.Info("[DRY RUN] Would write resolved values: "+path, "path", path)
+		return nil
+	}
+	err = WriteFileAtomic(path, 0644, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", ValuesFileName, err)
+	}
+	return nil
+}
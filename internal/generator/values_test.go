@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+)
+
+func TestWriteValues(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "template")
+	writeTree(t, tmpl, map[string]string{
+		manifestFile: `{"variables": [
+			{"name": "name"},
+			{"name": "owner", "default": "acme"},
+			{"name": "module", "default": "example.com/{{owner}}/{{name}}"},
+			{"name": "token", "secret": true}
+		]}`,
+		"go.mod":    "module {{module}}\n",
+		"README.md": "# {{name}} by {{owner}}\n",
+		".env":      "TOKEN={{token}}\n",
+	})
+
+	// The first run records the resolved values, without the secret
+	first := filepath.Join(dir, "first")
+	cfg := testConfig(tmpl, first, map[string]string{"name": "app", "token": "s3cret"})
+	cfg.WriteValues = true
+	cfg.ExpandValues = true
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("first Generate failed: %v", err)
+	}
+	values, err := config.LoadValues(filepath.Join(first, ValuesFileName))
+	if err != nil {
+		t.Fatalf("LoadValues failed: %v", err)
+	}
+	want := map[string]string{"name": "app", "owner": "acme", "module": "example.com/acme/app"}
+	if !maps.Equal(values, want) {
+		t.Errorf("recorded values = %q, want %q", values, want)
+	}
+
+	// Reusing them, with the secret given again, reproduces the output
+	second := filepath.Join(dir, "second")
+	values["token"] = "s3cret"
+	cfg = testConfig(tmpl, second, values)
+	cfg.WriteValues = true
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("second Generate failed: %v", err)
+	}
+	firstFiles, secondFiles := readTree(t, first), readTree(t, second)
+	if !maps.Equal(firstFiles, secondFiles) {
+		t.Errorf("second output = %q, want %q", secondFiles, firstFiles)
+	}
+	if firstFiles[".env"] != "TOKEN=s3cret\n" {
+		t.Errorf(".env = %q, want the secret substituted", firstFiles[".env"])
+	}
+
+	// Pruning a regenerated output keeps the values file
+	cfg = testConfig(tmpl, first, values)
+	cfg.PruneOutput = true
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("regenerate failed: %v", err)
+	}
+	if _, ok := readTree(t, first)[ValuesFileName]; !ok {
+		t.Errorf("%s was removed by a later run", ValuesFileName)
+	}
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runValidateCommands runs the manifest's validate commands in the output
+// directory, in order, after a successful generation. Unlike formatters, a
+// failing command fails the whole generation. Variables are substituted
+// per argument, see commandArgs.
+func (g *Generator) runValidateCommands() error {
+	m, err := g.loadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load template manifest: %w", err)
+	}
+	if m == nil {
+		return nil
+	}
+
+	for _, command := range m.Validate {
+		args := g.commandArgs(command)
+		if len(args) == 0 {
+			continue
+		}
+
+		g.logger.Info("Validating: "+strings.Join(args, " "), "command", command)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = g.cfg.OutputDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			err = fmt.Errorf("validation command %q failed: %w", strings.Join(args, " "), err)
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%w\n%s", err, msg)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommands(t *testing.T) {
+	template := map[string]string{
+		"README.md":        "# {{name}}\n",
+		"src/{{name}}.txt": "{{name}}",
+	}
+
+	tests := []struct {
+		name     string
+		validate string // JSON list of commands
+		existing map[string]string
+		mapped   bool // generate src/ into ../lib
+		cleanup  bool
+		dryRun   bool
+		wantErr  string
+		want     map[string]string // under the parent of the output directory; nil when it is missing
+	}{
+		{
+			name:     "passing",
+			validate: `["test -f README.md", "test -f src/{{name}}.txt"]`,
+			cleanup:  true,
+			want:     map[string]string{"out/README.md": "# app\n", "out/src/app.txt": "app"},
+		},
+		{
+			name:     "failing keeps the output",
+			validate: `["test -f README.md", "ls missing.txt"]`,
+			wantErr:  `validation command "ls missing.txt" failed`,
+			want:     map[string]string{"out/README.md": "# app\n", "out/src/app.txt": "app"},
+		},
+		{
+			name:     "failing with cleanup removes the output",
+			validate: `["false"]`,
+			cleanup:  true,
+			wantErr:  `validation command "false" failed`,
+		},
+		{
+			name:     "cleanup keeps files that existed",
+			validate: `["false"]`,
+			existing: map[string]string{"out/README.md": "# old\n", "out/notes.txt": "mine"},
+			cleanup:  true,
+			wantErr:  "failed",
+			want:     map[string]string{"out/README.md": "# app\n", "out/notes.txt": "mine"},
+		},
+		{
+			name:     "cleanup removes mapped outputs",
+			validate: `["false"]`,
+			mapped:   true,
+			existing: map[string]string{"out/keep.txt": "x"},
+			cleanup:  true,
+			wantErr:  "failed",
+			want:     map[string]string{"out/keep.txt": "x"},
+		},
+		{
+			name:     "skipped in a dry run",
+			validate: `["false"]`,
+			existing: map[string]string{"out/keep.txt": "x"},
+			cleanup:  true,
+			dryRun:   true,
+			want:     map[string]string{"out/keep.txt": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateDir, parent := t.TempDir(), t.TempDir()
+			files := maps.Clone(template)
+			m := `{"validate": ` + tt.validate
+			if tt.mapped {
+				m += `, "outputMap": {"src/**": "../lib"}`
+			}
+			files[manifestFile] = m + "}"
+			writeTree(t, templateDir, files)
+			writeTree(t, parent, tt.existing)
+
+			cfg := testConfig(templateDir, filepath.Join(parent, "out"), map[string]string{"name": "app"})
+			cfg.CleanupOnError = tt.cleanup
+			cfg.DryRun = tt.dryRun
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Generate error = %v, want one containing %q", err, tt.wantErr)
+			}
+
+			got := readTree(t, parent)
+			if len(got) == 0 {
+				got = nil
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("files = %q, want %q", got, tt.want)
+			}
+			if tt.want == nil {
+				if entries, _ := os.ReadDir(parent); len(entries) != 0 {
+					t.Errorf("cleanup left %d entries", len(entries))
+				}
+			}
+			if tt.cleanup && tt.wantErr != "" {
+				for _, dir := range []string{"out/src", "lib"} {
+					if _, err := os.Stat(filepath.Join(parent, dir)); err == nil {
+						t.Errorf("cleanup left the directory %s", dir)
+					}
+				}
+			}
+		})
+	}
+}
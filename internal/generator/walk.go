@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// walkTemplate walks the template directory like filepath.Walk. With
+// FollowSymlinks, symlinked files and directories are resolved and
+// symlinked directories are descended into under the link's own path; a
+// link back to a directory already being walked is skipped with a warning.
+func (g *Generator) walkTemplate(fn filepath.WalkFunc) error {
+	if g.cfg.MaxDepth > 0 {
+		fn = g.depthLimited(fn)
+	}
+	if g.fsys != nil {
+		return g.walkTemplateFS(fn)
+	}
+
+	if !g.cfg.FollowSymlinks {
+		fn = g.skipLinkedDirs(fn)
+	}
+	if g.cfg.SandboxRoot != "" {
+		fn = g.sandboxed(fn)
+	}
+	fn = g.skipOutputDir(fn)
+
+	if !g.cfg.FollowSymlinks {
+		return filepath.Walk(g.cfg.TemplateDir, fn)
+	}
+
+	info, err := os.Lstat(g.cfg.TemplateDir)
+	if err != nil {
+		return fn(g.cfg.TemplateDir, nil, err)
+	}
+
+	err = g.walkFollow(g.cfg.TemplateDir, info, fn, make(map[string]bool))
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkFollow visits path and, for directories, its entries in lexical order.
+// ancestors holds the resolved paths of the directories on the current
+// branch, which detects cycles.
+func (g *Generator) walkFollow(path string, info os.FileInfo, fn filepath.WalkFunc, ancestors map[string]bool) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			return fn(path, info, err)
+		}
+		info = target
+	}
+
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	if ancestors[resolved] {
+		relPath, _ := filepath.Rel(g.cfg.TemplateDir, path)
+		g.report.Warn(relPath, "skipping symlink cycle back to %s", resolved)
+		return nil
+	}
+
+	if err := fn(path, info, nil); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+
+	ancestors[resolved] = true
+	defer delete(ancestors, resolved)
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := os.Lstat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := g.walkFollow(child, childInfo, fn, ancestors); err != nil {
+			if err == filepath.SkipDir {
+				if !childInfo.IsDir() && childInfo.Mode()&os.ModeSymlink == 0 {
+					return nil
+				}
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// skipLinkedDirs wraps a walk function so that symlinks to directories,
+// which filepath.Walk neither follows nor reports as directories, are
+// skipped with a warning instead of being read as files
+func (g *Generator) skipLinkedDirs(fn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if target, statErr := os.Stat(path); statErr == nil && target.IsDir() {
+				relPath, _ := filepath.Rel(g.cfg.TemplateDir, path)
+				g.report.Warn(relPath, "skipping symlinked directory; set followSymlinks to include it")
+				return nil
+			}
+		}
+		return fn(path, info, err)
+	}
+}
+
+// depthLimited wraps a walk function so that visiting a path nested more
+// than MaxDepth levels below the template root fails the walk
+func (g *Generator) depthLimited(fn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			relPath, relErr := filepath.Rel(g.cfg.TemplateDir, path)
+			if relErr == nil && relPath != "." && len(strings.Split(filepath.ToSlash(relPath), "/")) > g.cfg.MaxDepth {
+				return fmt.Errorf("template path %s exceeds the maximum depth of %d", relPath, g.cfg.MaxDepth)
+			}
+		}
+		return fn(path, info, err)
+	}
+}
+
+// sandboxed wraps a walk function so that every visited path is checked
+// against SandboxRoot before it is handled
+func (g *Generator) sandboxed(fn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			if err := g.checkSandbox(path); err != nil {
+				return err
+			}
+		}
+		return fn(path, info, err)
+	}
+}
+
+// checkSandbox returns an error if path, with all symlinks resolved, lies
+// outside SandboxRoot. It does nothing when no sandbox is configured.
+func (g *Generator) checkSandbox(path string) error {
+	if g.cfg.SandboxRoot == "" {
+		return nil
+	}
+
+	root, err := resolvePath(g.cfg.SandboxRoot)
+	if err != nil {
+		return fmt.Errorf("invalid sandbox root: %w", err)
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if !withinDir(root, resolved) {
+		return fmt.Errorf("%s resolves to %s, outside the sandbox root %s", path, resolved, root)
+	}
+	return nil
+}
+
+// checkTemplateSandbox checks the template directory and the control files
+// read before the walk against SandboxRoot
+func (g *Generator) checkTemplateSandbox() error {
+	if g.fsys != nil {
+		return nil
+	}
+	if err := g.checkSandbox(g.cfg.TemplateDir); err != nil {
+		return err
+	}
+	for _, name := range []string{manifest.FileName, AttributesFileName, IgnoreFileName} {
+		path := filepath.Join(g.cfg.TemplateDir, name)
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		if err := g.checkSandbox(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePath returns the absolute path with all symlinks resolved
+func resolvePath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
+
+// withinDir reports whether path is dir or lies beneath it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,282 @@
+package generator
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// symlink creates a symlink, skipping the test where that is not allowed
+func symlink(t *testing.T, target, path string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	shared, tmpl := filepath.Join(dir, "shared"), filepath.Join(dir, "template")
+	writeTree(t, shared, map[string]string{"lib/util.go": "package {{name}}\n"})
+	writeTree(t, tmpl, map[string]string{"main.go": "package {{name}}\n"})
+	symlink(t, filepath.Join(shared, "lib"), filepath.Join(tmpl, "lib"))
+
+	for _, follow := range []bool{false, true} {
+		out := filepath.Join(t.TempDir(), "output")
+		cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+		cfg.FollowSymlinks = follow
+		g := newTestGenerator(cfg)
+		if err := g.Generate(); err != nil {
+			t.Fatalf("follow=%v: Generate failed: %v", follow, err)
+		}
+
+		want := map[string]string{"main.go": "package app\n"}
+		wantWarnings := []string{"lib: skipping symlinked directory; set followSymlinks to include it"}
+		if follow {
+			want["lib/util.go"] = "package app\n"
+			wantWarnings = nil
+		}
+		if got := readTree(t, out); !maps.Equal(got, want) {
+			t.Errorf("follow=%v: output = %q, want %q", follow, got, want)
+		}
+		var warnings []string
+		for _, w := range g.Report().Warnings() {
+			warnings = append(warnings, w.String())
+		}
+		if !slices.Equal(warnings, wantWarnings) {
+			t.Errorf("follow=%v: warnings = %q, want %q", follow, warnings, wantWarnings)
+		}
+	}
+}
+
+func TestFollowSymlinksCycle(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{"sub/a.txt": "{{name}}"})
+	symlink(t, "..", filepath.Join(tmpl, "sub", "loop"))
+	symlink(t, ".", filepath.Join(tmpl, "self"))
+
+	cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+	cfg.FollowSymlinks = true
+	g := newTestGenerator(cfg)
+
+	done := make(chan error, 1)
+	go func() { done <- g.Generate() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Generate did not finish on a symlink cycle")
+	}
+
+	if got, want := readTree(t, out), map[string]string{"sub/a.txt": "app"}; !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	var warnings []string
+	for _, w := range g.Report().Warnings() {
+		warnings = append(warnings, w.String())
+	}
+	for _, want := range []string{"self: skipping symlink cycle", "sub/loop: skipping symlink cycle"} {
+		if !slices.ContainsFunc(warnings, func(w string) bool { return strings.HasPrefix(w, filepath.FromSlash(want)) }) {
+			t.Errorf("no warning %q in %q", want, warnings)
+		}
+	}
+}
+
+func TestSandboxRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, root, tmpl, outside string)
+		follow  bool
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "plain template",
+			want: map[string]string{"a.txt": "app"},
+		},
+		{
+			name: "symlink inside the sandbox",
+			setup: func(t *testing.T, root, tmpl, outside string) {
+				writeTree(t, root, map[string]string{"shared/b.txt": "{{name}} shared"})
+				symlink(t, filepath.Join(root, "shared", "b.txt"), filepath.Join(tmpl, "b.txt"))
+			},
+			want: map[string]string{"a.txt": "app", "b.txt": "app shared"},
+		},
+		{
+			name: "absolute symlink to a file outside",
+			setup: func(t *testing.T, root, tmpl, outside string) {
+				symlink(t, filepath.Join(outside, "secret"), filepath.Join(tmpl, "passwd"))
+			},
+			wantErr: "outside the sandbox root",
+		},
+		{
+			name: "relative symlink to a file outside",
+			setup: func(t *testing.T, root, tmpl, outside string) {
+				symlink(t, "../../outside/secret", filepath.Join(tmpl, "passwd"))
+			},
+			wantErr: "outside the sandbox root",
+		},
+		{
+			name: "symlinked directory outside",
+			setup: func(t *testing.T, root, tmpl, outside string) {
+				symlink(t, outside, filepath.Join(tmpl, "etc"))
+			},
+			wantErr: "outside the sandbox root",
+		},
+		{
+			name: "followed symlinked directory outside",
+			setup: func(t *testing.T, root, tmpl, outside string) {
+				symlink(t, outside, filepath.Join(tmpl, "etc"))
+			},
+			follow:  true,
+			wantErr: "outside the sandbox root",
+		},
+		{
+			name: "manifest outside",
+			setup: func(t *testing.T, root, tmpl, outside string) {
+				writeTree(t, outside, map[string]string{"manifest.json": `{}`})
+				symlink(t, filepath.Join(outside, "manifest.json"), filepath.Join(tmpl, manifestFile))
+			},
+			wantErr: "outside the sandbox root",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			root, outside := filepath.Join(dir, "sandbox"), filepath.Join(dir, "outside")
+			tmpl, out := filepath.Join(root, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, map[string]string{"a.txt": "{{name}}"})
+			writeTree(t, outside, map[string]string{"secret": "root:x:0:0"})
+			if tt.setup != nil {
+				tt.setup(t, root, tmpl, outside)
+			}
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.SandboxRoot = root
+			cfg.FollowSymlinks = tt.follow
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				if files := readTree(t, out); strings.Contains(strings.Join(slices.Collect(maps.Values(files)), ""), "root:x") {
+					t.Errorf("content from outside the sandbox was written: %q", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if got := readTree(t, out); !maps.Equal(got, tt.want) {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSandboxTemplateOutside(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "template")
+	writeTree(t, tmpl, map[string]string{"a.txt": "a"})
+
+	cfg := testConfig(tmpl, filepath.Join(dir, "output"), nil)
+	cfg.SandboxRoot = filepath.Join(dir, "sandbox")
+	if err := os.Mkdir(cfg.SandboxRoot, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestGenerator(cfg).Generate(); err == nil || !strings.Contains(err.Error(), "outside the sandbox root") {
+		t.Errorf("Generate error = %v, want the template rejected", err)
+	}
+}
+
+// TestOutputTraversal checks the write side of the sandbox: a substituted
+// value cannot move a file out of the output directory
+func TestOutputTraversal(t *testing.T) {
+	for _, value := range []string{"../escaped", "../../etc", "a/../../b"} {
+		dir := t.TempDir()
+		tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+		writeTree(t, tmpl, map[string]string{"__dir__/x.txt": "x"})
+
+		err := newTestGenerator(testConfig(tmpl, out, map[string]string{"dir": value})).Generate()
+		if err == nil || !strings.Contains(err.Error(), "escapes its output directory") {
+			t.Errorf("dir=%q: Generate error = %v, want the path rejected", value, err)
+		}
+		if _, statErr := os.Stat(filepath.Join(dir, "escaped")); statErr == nil {
+			t.Errorf("dir=%q: file written outside the output directory", value)
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	template := map[string]string{
+		"README.md":         "# {{name}}\n",
+		"a/b/c.txt":         "c\n",
+		"ignored/x/y/z.txt": "deep but ignored\n",
+		IgnoreFileName:      "ignored\n",
+	}
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		wantErr  string
+	}{
+		{name: "unlimited by default"},
+		{name: "at the limit", maxDepth: 3},
+		{name: "over the limit", maxDepth: 2, wantErr: "template path " + filepath.Join("a", "b", "c.txt") + " exceeds the maximum depth of 2"},
+		{name: "directory over the limit", maxDepth: 1, wantErr: "template path " + filepath.Join("a", "b") + " exceeds the maximum depth of 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+			writeTree(t, tmpl, template)
+
+			cfg := testConfig(tmpl, out, map[string]string{"name": "app"})
+			cfg.MaxDepth = tt.maxDepth
+			err := newTestGenerator(cfg).Generate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			want := map[string]string{"README.md": "# app\n", "a/b/c.txt": "c\n"}
+			if got := readTree(t, out); !maps.Equal(got, want) {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMaxDepthSymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, out := filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, tmpl, map[string]string{"a/file.txt": "a\n"})
+	symlink(t, "..", filepath.Join(tmpl, "a", "loop"))
+
+	cfg := testConfig(tmpl, out, nil)
+	cfg.FollowSymlinks = true
+	cfg.MaxDepth = 4
+	if err := newTestGenerator(cfg).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got, want := readTree(t, out), map[string]string{"a/file.txt": "a\n"}; !maps.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
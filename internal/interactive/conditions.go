@@ -0,0 +1,62 @@
+package interactive
+
+import (
+	"strings"
+
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// SetConditions registers "when" conditions of PromptForValues, mapping a
+// variable to the earlier answer it depends on. A condition names a
+// variable that must be truthy, or with a leading '!' falsy; when it is not
+// met the variable is not asked for and keeps its default.
+func (p *Prompter) SetConditions(conditions map[string]string) {
+	p.conditions = conditions
+}
+
+// SetKnownValues registers the variable values set outside the prompts,
+// e.g. by the config file or -v. Conditions on variables that are not
+// prompted for, or not yet, are evaluated against them, and a skipped
+// variable keeps its known value instead of its default.
+func (p *Prompter) SetKnownValues(values map[string]string) {
+	p.known = values
+}
+
+// conditionMet evaluates a when condition against the variables known so
+// far: the known values overlaid with the answers. Unknown variables are
+// falsy.
+func conditionMet(condition string, values map[string]string) bool {
+	negate := strings.HasPrefix(condition, "!")
+	name := strings.TrimSpace(strings.TrimPrefix(condition, "!"))
+	return replacer.IsTruthy(values[name]) != negate
+}
+
+// promptOrder orders sorted variable names so that each variable comes
+// after the variable its condition depends on
+func promptOrder(keys []string, conditions map[string]string) []string {
+	present := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		present[key] = true
+	}
+
+	order := make([]string, 0, len(keys))
+	visited := make(map[string]bool, len(keys))
+	var visit func(key string)
+	visit = func(key string) {
+		if visited[key] {
+			return
+		}
+		// Marking first also stops dependency cycles
+		visited[key] = true
+		if condition, ok := conditions[key]; ok {
+			if dep := strings.TrimSpace(strings.TrimPrefix(condition, "!")); present[dep] {
+				visit(dep)
+			}
+		}
+		order = append(order, key)
+	}
+	for _, key := range keys {
+		visit(key)
+	}
+	return order
+}
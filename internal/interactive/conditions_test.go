@@ -0,0 +1,92 @@
+package interactive
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestPromptForValuesConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		variables  map[string]string // with their defaults
+		conditions map[string]string
+		known      map[string]string
+		answers    string
+		want       map[string]string
+	}{
+		{
+			name:       "met by an answer",
+			variables:  map[string]string{"use_db": "", "db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			answers:    "true\nmydb\n",
+			want:       map[string]string{"use_db": "true", "db_name": "mydb"},
+		},
+		{
+			name:       "not met by an answer",
+			variables:  map[string]string{"use_db": "", "db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			answers:    "false\n",
+			want:       map[string]string{"use_db": "false", "db_name": "app"},
+		},
+		{
+			name:       "met by a configured value",
+			variables:  map[string]string{"db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			known:      map[string]string{"use_db": "true"},
+			answers:    "mydb\n",
+			want:       map[string]string{"db_name": "mydb"},
+		},
+		{
+			name:       "not met by a configured value",
+			variables:  map[string]string{"db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			known:      map[string]string{"use_db": "false"},
+			want:       map[string]string{"db_name": "app"},
+		},
+		{
+			name:       "answer overrides a configured value",
+			variables:  map[string]string{"use_db": "", "db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			known:      map[string]string{"use_db": "true"},
+			answers:    "false\n",
+			want:       map[string]string{"use_db": "false", "db_name": "app"},
+		},
+		{
+			name:       "skipped variable keeps its configured value",
+			variables:  map[string]string{"db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			known:      map[string]string{"use_db": "false", "db_name": "legacy"},
+			want:       map[string]string{"db_name": "legacy"},
+		},
+		{
+			name:       "negated",
+			variables:  map[string]string{"sqlite_path": "app.db"},
+			conditions: map[string]string{"sqlite_path": "!use_server"},
+			known:      map[string]string{"use_server": "true"},
+			want:       map[string]string{"sqlite_path": "app.db"},
+		},
+		{
+			name:       "unknown variable is falsy",
+			variables:  map[string]string{"db_name": "app"},
+			conditions: map[string]string{"db_name": "use_db"},
+			want:       map[string]string{"db_name": "app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPrompterWithReader(strings.NewReader(tt.answers))
+			p.SetConditions(tt.conditions)
+			p.SetKnownValues(tt.known)
+
+			got, err := p.PromptForValues(tt.variables)
+			if err != nil {
+				t.Fatalf("PromptForValues failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("PromptForValues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package interactive
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Editor returns the user's editor command from $VISUAL or $EDITOR, or ""
+// when neither is set
+func Editor() string {
+	if editor := strings.TrimSpace(os.Getenv("VISUAL")); editor != "" {
+		return editor
+	}
+	return strings.TrimSpace(os.Getenv("EDITOR"))
+}
+
+// EditValues lets the user fill in every variable at once in their editor,
+// from a key=value file prefilled with the defaults, and validates the
+// result like PromptForValues does. Without an editor it falls back to
+// prompting for each variable in turn.
+func (p *Prompter) EditValues(variables map[string]string) (map[string]string, error) {
+	editor := Editor()
+	if editor == "" {
+		fmt.Println("No editor set in $VISUAL or $EDITOR; prompting for each variable instead.")
+		return p.PromptForValues(variables)
+	}
+
+	file, err := os.CreateTemp("", "stencil-values-*.env")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create values file: %w", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	_, err = file.Write(p.valuesFile(variables))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write values file: %w", err)
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %q failed: %w", args[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	values, err := ParseEditedValues(data, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.validate != nil {
+		var errs []error
+		for _, key := range sortedKeys(values) {
+			if err := p.validate(key, values[key]); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value for %s: %w", key, err))
+			}
+		}
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+	}
+	return values, nil
+}
+
+// valuesFile renders the variables as the key=value file opened in the
+// editor, in prompt order, with each variable's description and example as
+// comments above it
+func (p *Prompter) valuesFile(variables map[string]string) []byte {
+	var b bytes.Buffer
+	b.WriteString("# Set the template variables below, one key=value per line.\n")
+	b.WriteString("# Lines starting with '#' are ignored; a removed line keeps its default.\n")
+
+	for _, key := range promptOrder(sortedKeys(variables), p.conditions) {
+		b.WriteString("\n")
+		if v, ok := p.help[key]; ok {
+			if v.Description != "" {
+				fmt.Fprintf(&b, "# %s\n", v.Description)
+			}
+			if v.Example != "" {
+				fmt.Fprintf(&b, "# Example: %s\n", v.Example)
+			}
+		}
+		if condition, ok := p.conditions[key]; ok {
+			fmt.Fprintf(&b, "# Only used when %s\n", condition)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, variables[key])
+	}
+	return b.Bytes()
+}
+
+// ParseEditedValues reads back a key=value file edited by the user.
+// Blank lines and '#' comments are ignored, whitespace around keys and
+// values is trimmed, and variables whose line was removed keep their value
+// from variables. Unknown or repeated keys and lines without '=' are
+// errors.
+func ParseEditedValues(data []byte, variables map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(variables))
+	for key, value := range variables {
+		result[key] = value
+	}
+
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key=value: %s", n, line)
+		}
+		key = strings.TrimSpace(key)
+		if _, known := variables[key]; !known {
+			return nil, fmt.Errorf("line %d: unknown variable %q", n, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: variable %q is set more than once", n, key)
+		}
+		seen[key] = true
+		result[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	return result, nil
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+package interactive
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+func TestParseEditedValues(t *testing.T) {
+	variables := map[string]string{"name": "app", "owner": "acme", "license": ""}
+
+	tests := []struct {
+		name    string
+		edited  string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "every line edited",
+			edited: "name=demo\nowner=Jane Doe\nlicense=MIT\n",
+			want:   map[string]string{"name": "demo", "owner": "Jane Doe", "license": "MIT"},
+		},
+		{
+			name:   "removed lines keep their defaults",
+			edited: "# comment\nlicense=MIT\n",
+			want:   map[string]string{"name": "app", "owner": "acme", "license": "MIT"},
+		},
+		{
+			name:   "blank lines, comments and spacing",
+			edited: "\n  # owner=ignored\n\n  name =  demo  \n\t\nowner=\n",
+			want:   map[string]string{"name": "demo", "owner": "", "license": ""},
+		},
+		{
+			name:   "value containing '=' and '#'",
+			edited: "name=a=b # not a comment\n",
+			want:   map[string]string{"name": "a=b # not a comment", "owner": "acme", "license": ""},
+		},
+		{
+			name:   "CRLF line endings",
+			edited: "name=demo\r\nowner=Jane\r\n",
+			want:   map[string]string{"name": "demo", "owner": "Jane", "license": ""},
+		},
+		{name: "empty file", edited: "", want: variables},
+		{name: "missing '='", edited: "name=demo\nowner\n", wantErr: "line 2: expected key=value"},
+		{name: "unknown variable", edited: "nmae=demo\n", wantErr: `line 1: unknown variable "nmae"`},
+		{name: "repeated variable", edited: "name=a\n\nname=b\n", wantErr: `line 3: variable "name" is set more than once`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEditedValues([]byte(tt.edited), variables)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseEditedValues error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEditedValues failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("values = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValuesFileRoundTrip checks that the prefilled file parses back to the
+// defaults unchanged
+func TestValuesFileRoundTrip(t *testing.T) {
+	variables := map[string]string{"name": "app", "owner": "acme", "license": ""}
+	p := NewPrompterWithReader(strings.NewReader(""))
+	p.SetHelp(map[string]manifest.Variable{"owner": {Name: "owner", Description: "Who owns it", Example: "Jane"}})
+	p.SetConditions(map[string]string{"license": "owner"})
+
+	data := p.valuesFile(variables)
+	for _, want := range []string{"# Who owns it\n# Example: Jane\nowner=acme\n", "# Only used when owner\nlicense=\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("values file = %q, want it to contain %q", data, want)
+		}
+	}
+	got, err := ParseEditedValues(data, variables)
+	if err != nil {
+		t.Fatalf("ParseEditedValues failed: %v", err)
+	}
+	if !maps.Equal(got, variables) {
+		t.Errorf("values = %q, want %q", got, variables)
+	}
+}
+
+func TestEditValues(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run the editor script")
+	}
+	variables := map[string]string{"name": "app", "owner": "acme"}
+
+	// The "editor" replaces the name line of the file it is given
+	script := filepath.Join(t.TempDir(), "edit.sh")
+	if err := os.WriteFile(script, []byte("sed 's/^name=.*/name=demo/' \"$1\" > \"$1.new\" && mv \"$1.new\" \"$1\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", sh+" "+script)
+
+	p := NewPrompterWithReader(strings.NewReader(""))
+	got, err := p.EditValues(variables)
+	if err != nil {
+		t.Fatalf("EditValues failed: %v", err)
+	}
+	if want := map[string]string{"name": "demo", "owner": "acme"}; !maps.Equal(got, want) {
+		t.Errorf("values = %q, want %q", got, want)
+	}
+
+	// Edited values are validated like prompted ones
+	p.SetValidator(func(name, value string) error {
+		if name == "name" && value == "demo" {
+			return errors.New("reserved")
+		}
+		return nil
+	})
+	if _, err := p.EditValues(variables); err == nil || !strings.Contains(err.Error(), "invalid value for name: reserved") {
+		t.Errorf("EditValues error = %v, want the value rejected", err)
+	}
+}
+
+func TestEditValuesWithoutEditor(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+
+	p := NewPrompterWithReader(strings.NewReader("demo\n\n"))
+	var got map[string]string
+	output := captureStdout(t, func() {
+		var err error
+		got, err = p.EditValues(map[string]string{"name": "app", "owner": "acme"})
+		if err != nil {
+			t.Errorf("EditValues failed: %v", err)
+		}
+	})
+	if want := map[string]string{"name": "demo", "owner": "acme"}; !maps.Equal(got, want) {
+		t.Errorf("values = %q, want %q", got, want)
+	}
+	if !strings.Contains(output, "prompting for each variable instead") {
+		t.Errorf("output = %q, want the fallback explained", output)
+	}
+}
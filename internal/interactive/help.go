@@ -0,0 +1,30 @@
+package interactive
+
+import (
+	"fmt"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// SetHelp registers the manifest declarations of the variables
+// PromptForValues asks for. The description and example of a declared
+// variable are shown above its prompt; undeclared variables are asked for
+// by name alone.
+func (p *Prompter) SetHelp(variables map[string]manifest.Variable) {
+	p.help = variables
+}
+
+// printHelp prints the description and example declared for a variable,
+// if any
+func (p *Prompter) printHelp(key string) {
+	v, ok := p.help[key]
+	if !ok {
+		return
+	}
+	if v.Description != "" {
+		fmt.Printf("  %s\n", v.Description)
+	}
+	if v.Example != "" {
+		fmt.Printf("  Example: %s\n", v.Example)
+	}
+}
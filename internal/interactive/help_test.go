@@ -0,0 +1,42 @@
+package interactive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+func TestPromptHelp(t *testing.T) {
+	p := NewPrompterWithReader(strings.NewReader("Jane\ngithub.com/acme/app\napp\n"))
+	p.SetHelp(map[string]manifest.Variable{
+		"module_path": {Name: "module_path", Description: "Go module path", Example: "github.com/acme/app"},
+		"author":      {Name: "author", Description: "Author name"},
+	})
+
+	var values map[string]string
+	output := captureStdout(t, func() {
+		var err error
+		values, err = p.PromptForValues(map[string]string{"module_path": "", "author": "", "name": ""})
+		if err != nil {
+			t.Errorf("PromptForValues failed: %v", err)
+		}
+	})
+	if values["module_path"] != "github.com/acme/app" || values["author"] != "Jane" || values["name"] != "app" {
+		t.Errorf("values = %q", values)
+	}
+
+	// The help of each variable comes right before its own prompt
+	for _, want := range []string{
+		"  Author name\n[1/3] author: ",
+		"  Go module path\n  Example: github.com/acme/app\n[2/3] module_path: ",
+		"[2/3] module_path: [3/3] name: ",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+	if strings.Count(output, "Example:") != 1 || strings.Count(output, "  Author name\n") != 1 {
+		t.Errorf("output = %q, want help only for the annotated variables", output)
+	}
+}
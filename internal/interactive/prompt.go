@@ -2,22 +2,77 @@ package interactive
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
 )
 
+// ErrNoInput is returned when input ends before a prompt without a default
+// is answered, e.g. when a piped answers file runs out
+var ErrNoInput = errors.New("no input: reached end of input")
+
 // Prompter handles interactive user prompts
 type Prompter struct {
 	reader *bufio.Reader
+
+	// validate, when set, checks each answer; invalid answers are prompted
+	// for again
+	validate func(name, value string) error
+
+	// conditions maps variables to the when condition that must hold for
+	// them to be asked for
+	conditions map[string]string
+
+	// known holds the values set outside the prompts, such as from the
+	// config or -v, which conditions see until they are answered
+	known map[string]string
+
+	// help maps variables to their manifest declarations, whose description
+	// and example are shown with the prompt
+	help map[string]manifest.Variable
 }
 
 // NewPrompter creates a new Prompter instance
 func NewPrompter() *Prompter {
+	return NewPrompterWithReader(os.Stdin)
+}
+
+// NewPrompterWithReader creates a Prompter that reads answers from r, one
+// per line in prompt order, e.g. from a piped answers file
+func NewPrompterWithReader(r io.Reader) *Prompter {
 	return &Prompter{
-		reader: bufio.NewReader(os.Stdin),
+		reader: bufio.NewReader(r),
+	}
+}
+
+// readLine reads one answer. A final line without a newline is still an
+// answer; reaching the end of input with nothing read returns ErrNoInput.
+func (p *Prompter) readLine() (string, error) {
+	input, err := p.reader.ReadString('\n')
+	if err == io.EOF {
+		if input != "" {
+			return input, nil
+		}
+		fmt.Println()
+		return "", ErrNoInput
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
 	}
+	return input, nil
+}
+
+// SetValidator registers a check applied to every answer of PromptForValues.
+// An answer it rejects is reported and the variable is prompted for again.
+func (p *Prompter) SetValidator(fn func(name, value string) error) {
+	p.validate = fn
 }
 
 // PromptForValues prompts the user for variable values
@@ -28,14 +83,31 @@ func (p *Prompter) PromptForValues(variables map[string]string) (map[string]stri
 	fmt.Println("Please provide values for the following variables:")
 	fmt.Println()
 
-	// Convert to slice for ordered prompting
+	// Convert to sorted slice so prompts (and answers files) have a stable order
 	varKeys := make([]string, 0, len(variables))
 	for k := range variables {
 		varKeys = append(varKeys, k)
 	}
+	sort.Strings(varKeys)
+	varKeys = promptOrder(varKeys, p.conditions)
+
+	// Conditions see the known values overlaid with the answers so far
+	values := maps.Clone(p.known)
+	if values == nil {
+		values = make(map[string]string)
+	}
 
 	for i, key := range varKeys {
 		defaultValue := variables[key]
+		if condition, ok := p.conditions[key]; ok && !conditionMet(condition, values) {
+			// A skipped variable keeps a value set outside the prompts
+			if value, ok := p.known[key]; ok {
+				defaultValue = value
+			}
+			result[key] = defaultValue
+			values[key] = defaultValue
+			continue
+		}
 		prompt := fmt.Sprintf("[%d/%d] %s", i+1, len(varKeys), key)
 
 		if defaultValue != "" {
@@ -43,35 +115,70 @@ func (p *Prompter) PromptForValues(variables map[string]string) (map[string]stri
 		}
 		prompt += ": "
 
-		fmt.Print(prompt)
-		input, err := p.reader.ReadString('\n')
-		if err != nil {
-			return nil, fmt.Errorf("failed to read input: %w", err)
-		}
-
-		input = strings.TrimSpace(input)
-
-		// Use default value if input is empty
-		if input == "" && defaultValue != "" {
-			input = defaultValue
+		p.printHelp(key)
+		for {
+			fmt.Print(prompt)
+			input, err := p.readLine()
+			noInput := errors.Is(err, ErrNoInput)
+			if noInput && defaultValue == "" {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			} else if err != nil && !noInput {
+				return nil, err
+			}
+
+			input = strings.TrimSpace(input)
+
+			// Use default value if input is empty
+			if input == "" && defaultValue != "" {
+				input = defaultValue
+			}
+
+			if p.validate != nil {
+				if err := p.validate(key, input); err != nil {
+					// Without more input the answer can never change
+					if noInput {
+						return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+					}
+					fmt.Printf("Invalid value: %v\n", err)
+					continue
+				}
+			}
+
+			result[key] = input
+			values[key] = input
+			break
 		}
-
-		result[key] = input
 	}
 
 	return result, nil
 }
 
-// PromptForConfirmation prompts the user for confirmation
+// PromptForConfirmation prompts the user for confirmation; empty input
+// means no
 func (p *Prompter) PromptForConfirmation(message string) (bool, error) {
-	fmt.Printf("\n%s [y/N]: ", message)
+	return p.PromptForConfirmationDefault(message, false)
+}
 
-	input, err := p.reader.ReadString('\n')
-	if err != nil {
-		return false, fmt.Errorf("failed to read input: %w", err)
+// PromptForConfirmationDefault prompts the user for confirmation, treating
+// empty input as yes when defaultYes is set ("[Y/n]") and as no otherwise
+// ("[y/N]")
+func (p *Prompter) PromptForConfirmationDefault(message string, defaultYes bool) (bool, error) {
+	options := "[y/N]"
+	if defaultYes {
+		options = "[Y/n]"
+	}
+	fmt.Printf("\n%s %s: ", message, options)
+
+	// The end of input takes the default, like an empty answer
+	input, err := p.readLine()
+	if err != nil && !errors.Is(err, ErrNoInput) {
+		return false, err
 	}
 
 	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "" {
+		return defaultYes, nil
+	}
 
 	return input == "y" || input == "yes", nil
 }
@@ -94,9 +201,12 @@ func (p *Prompter) PromptForChoice(message string, choices []string, defaultInde
 	}
 	fmt.Print(": ")
 
-	input, err := p.reader.ReadString('\n')
+	input, err := p.readLine()
+	if errors.Is(err, ErrNoInput) && defaultIndex >= 0 {
+		return defaultIndex, nil
+	}
 	if err != nil {
-		return -1, fmt.Errorf("failed to read input: %w", err)
+		return -1, err
 	}
 
 	input = strings.TrimSpace(input)
@@ -129,9 +239,12 @@ func (p *Prompter) PromptForString(message, defaultValue string) (string, error)
 
 	fmt.Print(prompt)
 
-	input, err := p.reader.ReadString('\n')
+	input, err := p.readLine()
+	if errors.Is(err, ErrNoInput) && defaultValue != "" {
+		return defaultValue, nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read input: %w", err)
+		return "", err
 	}
 
 	input = strings.TrimSpace(input)
@@ -0,0 +1,266 @@
+package interactive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPromptForValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string // with their defaults
+		answers   string
+		validate  func(name, value string) error
+		want      map[string]string
+		wantErr   error
+	}{
+		{
+			name:      "answers in sorted order",
+			variables: map[string]string{"project": "", "author": "", "license": ""},
+			answers:   "Ada\nMIT\nmyapp\n",
+			want:      map[string]string{"author": "Ada", "license": "MIT", "project": "myapp"},
+		},
+		{
+			name:      "blank line takes the default",
+			variables: map[string]string{"author": "", "license": "MIT"},
+			answers:   "Ada\n\n",
+			want:      map[string]string{"author": "Ada", "license": "MIT"},
+		},
+		{
+			name:      "answers are trimmed",
+			variables: map[string]string{"author": ""},
+			answers:   "  Ada Lovelace \r\n",
+			want:      map[string]string{"author": "Ada Lovelace"},
+		},
+		{
+			name:      "last line without a newline",
+			variables: map[string]string{"author": "", "project": ""},
+			answers:   "Ada\nmyapp",
+			want:      map[string]string{"author": "Ada", "project": "myapp"},
+		},
+		{
+			name:      "end of input takes the default",
+			variables: map[string]string{"author": "", "license": "MIT"},
+			answers:   "Ada\n",
+			want:      map[string]string{"author": "Ada", "license": "MIT"},
+		},
+		{
+			name:      "end of input without a default",
+			variables: map[string]string{"author": "", "project": ""},
+			answers:   "Ada\n",
+			wantErr:   ErrNoInput,
+		},
+		{
+			name:      "invalid answer is asked again",
+			variables: map[string]string{"port": ""},
+			answers:   "http\n8080\n",
+			validate: func(name, value string) error {
+				if strings.Trim(value, "0123456789") != "" {
+					return fmt.Errorf("%s must be a number", name)
+				}
+				return nil
+			},
+			want: map[string]string{"port": "8080"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPrompterWithReader(strings.NewReader(tt.answers))
+			p.SetValidator(tt.validate)
+
+			got, err := p.PromptForValues(tt.variables)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PromptForValues error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PromptForValues failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("PromptForValues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptFromAnswersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.txt")
+	if err := os.WriteFile(path, []byte("Ada\n\nmyapp\ny\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	p := NewPrompterWithReader(file)
+	got, err := p.PromptForValues(map[string]string{"author": "", "license": "MIT", "project": ""})
+	if err != nil {
+		t.Fatalf("PromptForValues failed: %v", err)
+	}
+	if want := map[string]string{"author": "Ada", "license": "MIT", "project": "myapp"}; !maps.Equal(got, want) {
+		t.Errorf("PromptForValues = %q, want %q", got, want)
+	}
+
+	// The same reader continues with the confirmation
+	ok, err := p.PromptForConfirmation("Proceed?")
+	if err != nil || !ok {
+		t.Errorf("PromptForConfirmation = %v, %v; want true", ok, err)
+	}
+}
+
+func TestPromptForConfirmationDefault(t *testing.T) {
+	tests := []struct {
+		name       string
+		defaultYes bool
+		answer     string
+		want       bool
+	}{
+		{name: "default no, empty", answer: "\n", want: false},
+		{name: "default no, y", answer: "y\n", want: true},
+		{name: "default no, n", answer: "n\n", want: false},
+		{name: "default no, end of input", answer: "", want: false},
+		{name: "default yes, empty", defaultYes: true, answer: "\n", want: true},
+		{name: "default yes, y", defaultYes: true, answer: "Y\n", want: true},
+		{name: "default yes, n", defaultYes: true, answer: "n\n", want: false},
+		{name: "default yes, end of input", defaultYes: true, answer: "", want: true},
+		{name: "default yes, other answer", defaultYes: true, answer: "maybe\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPrompterWithReader(strings.NewReader(tt.answer))
+			var got bool
+			output := captureStdout(t, func() {
+				var err error
+				got, err = p.PromptForConfirmationDefault("Proceed?", tt.defaultYes)
+				if err != nil {
+					t.Errorf("PromptForConfirmationDefault failed: %v", err)
+				}
+			})
+			if got != tt.want {
+				t.Errorf("PromptForConfirmationDefault = %v, want %v", got, tt.want)
+			}
+			options := "[y/N]"
+			if tt.defaultYes {
+				options = "[Y/n]"
+			}
+			if !strings.Contains(output, "Proceed? "+options) {
+				t.Errorf("prompt = %q, want it to offer %s", output, options)
+			}
+		})
+	}
+}
+
+// captureStdout returns what fn prints to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(output)
+}
+
+func TestPromptEOF(t *testing.T) {
+	errRead := errors.New("read failed")
+
+	tests := []struct {
+		name    string
+		input   io.Reader
+		prompt  func(p *Prompter) (any, error)
+		want    any
+		wantErr error
+	}{
+		{
+			name:   "string with a default",
+			input:  strings.NewReader(""),
+			prompt: func(p *Prompter) (any, error) { return p.PromptForString("Name", "app") },
+			want:   "app",
+		},
+		{
+			name:    "string without a default",
+			input:   strings.NewReader(""),
+			prompt:  func(p *Prompter) (any, error) { return p.PromptForString("Name", "") },
+			wantErr: ErrNoInput,
+		},
+		{
+			name:   "string on a last line without a newline",
+			input:  strings.NewReader("given"),
+			prompt: func(p *Prompter) (any, error) { return p.PromptForString("Name", "app") },
+			want:   "given",
+		},
+		{
+			name:   "choice with a default",
+			input:  strings.NewReader(""),
+			prompt: func(p *Prompter) (any, error) { return p.PromptForChoice("License", []string{"MIT", "Apache-2.0"}, 1) },
+			want:   1,
+		},
+		{
+			name:    "choice without a default",
+			input:   strings.NewReader(""),
+			prompt:  func(p *Prompter) (any, error) { return p.PromptForChoice("License", []string{"MIT", "Apache-2.0"}, -1) },
+			wantErr: ErrNoInput,
+		},
+		{
+			name:   "confirmation",
+			input:  strings.NewReader(""),
+			prompt: func(p *Prompter) (any, error) { return p.PromptForConfirmation("Proceed?") },
+			want:   false,
+		},
+		{
+			name:  "input ends after the first prompt",
+			input: strings.NewReader("Ada\n"),
+			prompt: func(p *Prompter) (any, error) {
+				return p.PromptForValues(map[string]string{"author": "", "license": "MIT"})
+			},
+			want: map[string]string{"author": "Ada", "license": "MIT"},
+		},
+		{
+			name:    "read error is not end of input",
+			input:   iotest.ErrReader(errRead),
+			prompt:  func(p *Prompter) (any, error) { return p.PromptForString("Name", "app") },
+			wantErr: errRead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.prompt(NewPrompterWithReader(tt.input))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prompt failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("answer = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
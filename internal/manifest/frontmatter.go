@@ -0,0 +1,188 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FrontMatterDelimiter opens and closes a front-matter block
+const FrontMatterDelimiter = "---"
+
+// ParseFrontMatter parses the variable declarations of a front-matter block
+// at the very start of a template file and returns them with the content
+// that follows the block. The block is a small subset of YAML:
+//
+//	---
+//	variables:
+//	  project_name: myapp
+//	  author:
+//	    default: Jane
+//	    description: Author name
+//	    example: Jane Doe
+//	---
+//
+// A variable maps either to its default or to the Variable fields default,
+// description, example, group, when, type, language and secret. Content
+// that does not start with such a block, including an ordinary YAML
+// document starting with "---", is reported as having none.
+func ParseFrontMatter(content []byte) ([]Variable, []byte, bool) {
+	first, rest, ok := cutLine(content)
+	if !ok || strings.TrimRight(first, " \t\r") != FrontMatterDelimiter {
+		return nil, content, false
+	}
+
+	var block []string
+	for {
+		var line string
+		line, rest, ok = cutLine(rest)
+		if !ok {
+			return nil, content, false
+		}
+		if strings.TrimRight(line, " \t\r") == FrontMatterDelimiter {
+			break
+		}
+		block = append(block, strings.TrimRight(line, " \t\r"))
+	}
+
+	variables, err := parseFrontMatterBlock(block)
+	if err != nil {
+		return nil, content, false
+	}
+	return variables, rest, true
+}
+
+// cutLine splits off the first line of content, which must end in a newline
+func cutLine(content []byte) (string, []byte, bool) {
+	line, rest, ok := bytes.Cut(content, []byte("\n"))
+	return string(line), rest, ok
+}
+
+// parseFrontMatterBlock parses the lines between the delimiters
+func parseFrontMatterBlock(lines []string) ([]Variable, error) {
+	var variables []Variable
+	seenRoot := false
+	nameIndent, fieldIndent := 0, 0
+	var current *Variable
+
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("tabs are not allowed for indentation")
+		}
+		indent := len(line) - len(trimmed)
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected key: value: %s", line)
+		}
+		key = strings.TrimSpace(key)
+		value, err := frontMatterValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case indent == 0:
+			if key != "variables" || value != "" || seenRoot {
+				return nil, fmt.Errorf("unexpected key %q", key)
+			}
+			seenRoot = true
+
+		case seenRoot && (nameIndent == 0 || indent == nameIndent):
+			nameIndent, fieldIndent = indent, 0
+			variables = append(variables, Variable{Name: key, Default: value})
+			current = &variables[len(variables)-1]
+			if value != "" {
+				current = nil
+			}
+
+		case current != nil && indent > nameIndent && (fieldIndent == 0 || indent == fieldIndent):
+			fieldIndent = indent
+			if err := setFrontMatterField(current, key, value); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("unexpected indentation: %s", line)
+		}
+	}
+
+	if !seenRoot {
+		return nil, fmt.Errorf("missing variables")
+	}
+	return variables, nil
+}
+
+// setFrontMatterField sets a declared field of a variable
+func setFrontMatterField(v *Variable, key, value string) error {
+	switch key {
+	case "default":
+		v.Default = value
+	case "description":
+		v.Description = value
+	case "example":
+		v.Example = value
+	case "group":
+		v.Group = value
+	case "when":
+		v.When = value
+	case "type":
+		v.Type = value
+	case "language":
+		v.Language = value
+	case "secret":
+		secret, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("secret: %w", err)
+		}
+		v.Secret = secret
+	default:
+		return fmt.Errorf("unknown field %q", key)
+	}
+	return nil
+}
+
+// frontMatterValue unquotes a scalar value, which may be followed by a
+// " #" comment
+func frontMatterValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+
+	// Find the closing quote: double-quoted strings escape with a
+	// backslash, single-quoted ones by doubling the quote
+	quote, end := value[0], -1
+	for i := 1; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			if quote == '\'' && i+1 < len(value) && value[i+1] == '\'' {
+				i++
+				continue
+			}
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string: %s", value)
+	}
+	if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected text after string: %s", value)
+	}
+
+	if quote == '"' {
+		return strconv.Unquote(value[:end+1])
+	}
+	return strings.ReplaceAll(value[1:end], "''", "'"), nil
+}
@@ -0,0 +1,68 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		want      []Variable
+		wantRest  string
+		wantFound bool
+	}{
+		{
+			name:      "short and long forms",
+			content:   "---\nvariables:\n  project_name: myapp\n  author:\n    default: Jane\n    description: Author name\n    example: Jane Doe\n---\n# {{project_name}}\n",
+			want:      []Variable{{Name: "project_name", Default: "myapp"}, {Name: "author", Default: "Jane", Description: "Author name", Example: "Jane Doe"}},
+			wantRest:  "# {{project_name}}\n",
+			wantFound: true,
+		},
+		{
+			name: "every field",
+			content: "---\nvariables:\n  pkg:\n    type: identifier\n    language: python\n    group: Code\n" +
+				"    when: use_pkg\n    secret: true\n---\n",
+			want:      []Variable{{Name: "pkg", Type: "identifier", Language: "python", Group: "Code", When: "use_pkg", Secret: true}},
+			wantFound: true,
+		},
+		{
+			name:      "quoted values and comments",
+			content:   "---\n# declared here\nvariables:\n  a: \"x # y\" # comment\n  b: 'it''s'\n  c: plain # comment\n  d: \"tab\\t\"\n---\nbody",
+			want:      []Variable{{Name: "a", Default: "x # y"}, {Name: "b", Default: "it's"}, {Name: "c", Default: "plain"}, {Name: "d", Default: "tab\t"}},
+			wantRest:  "body",
+			wantFound: true,
+		},
+		{
+			name:      "CRLF line endings",
+			content:   "---\r\nvariables:\r\n  a: b\r\n---\r\nbody",
+			want:      []Variable{{Name: "a", Default: "b"}},
+			wantRest:  "body",
+			wantFound: true,
+		},
+		{name: "no block", content: "# title\n", wantRest: "# title\n"},
+		{name: "ordinary YAML document", content: "---\nname: app\n---\n", wantRest: "---\nname: app\n---\n"},
+		{name: "unclosed block", content: "---\nvariables:\n  a: b\n", wantRest: "---\nvariables:\n  a: b\n"},
+		{name: "unknown field", content: "---\nvariables:\n  a:\n    colour: red\n---\n", wantRest: "---\nvariables:\n  a:\n    colour: red\n---\n"},
+		{name: "bad secret", content: "---\nvariables:\n  a:\n    secret: maybe\n---\n", wantRest: "---\nvariables:\n  a:\n    secret: maybe\n---\n"},
+		{name: "tab indentation", content: "---\nvariables:\n\ta: b\n---\n", wantRest: "---\nvariables:\n\ta: b\n---\n"},
+		{name: "uneven indentation", content: "---\nvariables:\n  a:\n    default: x\n      description: y\n---\n", wantRest: "---\nvariables:\n  a:\n    default: x\n      description: y\n---\n"},
+		{name: "unterminated string", content: "---\nvariables:\n  a: \"x\n---\n", wantRest: "---\nvariables:\n  a: \"x\n---\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, found := ParseFrontMatter([]byte(tt.content))
+			if found != tt.wantFound {
+				t.Fatalf("found = %v, want %v", found, tt.wantFound)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("variables = %+v, want %+v", got, tt.want)
+			}
+			if string(rest) != tt.wantRest {
+				t.Errorf("rest = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FileName is the name of the manifest file inside a template directory
+const FileName = "stencil.manifest.json"
+
+// SignatureFileName is the name of the file holding a template's signed
+// digest, written by "stencil sign"
+const SignatureFileName = "stencil.signature"
+
+// Variable describes a single template variable
+type Variable struct {
+	// Name is the variable name as used in placeholders
+	Name string `json:"name"`
+
+	// Default is the value used when none is provided
+	Default string `json:"default,omitempty"`
+
+	// Description explains what the variable is for
+	Description string `json:"description,omitempty"`
+
+	// Example is a sample value shown when the variable is asked for
+	Example string `json:"example,omitempty"`
+
+	// Command computes the value when none is provided. It is split on
+	// whitespace, run in the template directory with the other variables as
+	// a JSON object on stdin, and its trimmed stdout becomes the value.
+	Command string `json:"command,omitempty"`
+
+	// Group is an optional heading the variable is listed under
+	Group string `json:"group,omitempty"`
+
+	// When makes interactive mode ask for the variable only if an earlier
+	// answer is truthy, e.g. "use_database", or falsy with a leading '!'
+	When string `json:"when,omitempty"`
+
+	// Secret marks a value, such as a token, that must not be recorded in
+	// the values file written by WriteValues
+	Secret bool `json:"secret,omitempty"`
+
+	// Type constrains the value. "identifier" requires a valid identifier
+	// in Language; empty accepts any value.
+	Type string `json:"type,omitempty"`
+
+	// Language selects the identifier rules for the identifier type: "go"
+	// (the default), "python" or "javascript"
+	Language string `json:"language,omitempty"`
+}
+
+// Manifest describes a template and the variables it declares
+type Manifest struct {
+	// Name is the template name
+	Name string `json:"name,omitempty"`
+
+	// Description is a short summary of the template
+	Description string `json:"description,omitempty"`
+
+	// MinVersion is the oldest stencil version the template supports
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// Variables lists the variables declared by the template
+	Variables []Variable `json:"variables,omitempty"`
+
+	// Conditions maps template paths (slash-separated, relative to the
+	// template root) to a variable that must be truthy for the path to be
+	// generated. A leading '!' negates the condition. Directory conditions
+	// apply to the whole subtree.
+	Conditions map[string]string `json:"conditions,omitempty"`
+
+	// Once lists glob patterns of template paths generated only when the
+	// output file does not exist yet, e.g. files users are expected to edit
+	Once []string `json:"once,omitempty"`
+
+	// Iterate maps glob patterns of template files to a list variable whose
+	// value is comma-separated. A matching file is generated once per list
+	// item with the "item" variable bound to it, e.g. model___item__.go.
+	Iterate map[string]string `json:"iterate,omitempty"`
+
+	// OutputMap maps glob patterns of template paths to the directory they
+	// are generated under instead of the output directory, relative to it,
+	// e.g. "frontend/**" to "../web". The patterns' leading literal
+	// directories are not repeated below the mapped directory.
+	OutputMap map[string]string `json:"outputMap,omitempty"`
+
+	// Merge lists glob patterns of template paths whose content is merged
+	// into the existing output file as a block between "BEGIN stencil" and
+	// "END stencil" comment lines, instead of overwriting it
+	Merge []string `json:"merge,omitempty"`
+
+	// Aliases maps alternative variable names to the canonical variable they
+	// stand for, so one answer fills every synonymous placeholder
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// Derived maps a variable name to a transform of another variable,
+	// written "variable|transform" as in "project_name|slug", so templates
+	// can use a value and its slug side by side. The transforms are those of
+	// the config's normalize section.
+	Derived map[string]string `json:"derived,omitempty"`
+
+	// PostMessage is shown after a successful generation, e.g. next steps.
+	// Variables in it are substituted like in template files.
+	PostMessage string `json:"postMessage,omitempty"`
+
+	// Validate lists commands, e.g. "go build ./...", run in the output
+	// directory after generation. Generation fails when one of them fails.
+	Validate []string `json:"validate,omitempty"`
+
+	// Header is a license header prepended to generated files
+	Header *Header `json:"header,omitempty"`
+}
+
+// Header is a license header added to the top of generated files that do
+// not contain it yet
+type Header struct {
+	// Text is the header without comment markers. Variables are
+	// substituted, and "year" defaults to the current year.
+	Text string `json:"text"`
+
+	// Files lists glob patterns of template paths that get the header;
+	// empty means every file with a known comment style
+	Files []string `json:"files,omitempty"`
+
+	// Comment is the line comment prefix, e.g. "//" or "#". Empty picks it
+	// from the file extension, and files of unknown types are left alone.
+	Comment string `json:"comment,omitempty"`
+}
+
+// Load reads the manifest from a template directory. It returns nil without
+// an error when the template has no manifest.
+func Load(templateDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(templateDir, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(data)
+}
+
+// LoadFS reads the manifest from a template directory inside fsys. It
+// returns nil without an error when the template has no manifest.
+func LoadFS(fsys fs.FS, templateDir string) (*Manifest, error) {
+	data, err := fs.ReadFile(fsys, path.Join(templateDir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(data)
+}
+
+// parse decodes manifest JSON
+func parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	for _, v := range m.Variables {
+		if err := v.checkSpec(); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+	}
+	for pattern, dir := range m.OutputMap {
+		if dir == "" || filepath.IsAbs(dir) || path.IsAbs(filepath.ToSlash(dir)) {
+			return nil, fmt.Errorf("invalid manifest: outputMap %s: directory must be relative to the output directory", pattern)
+		}
+	}
+
+	return &m, nil
+}
+
+// Save writes the manifest into a template directory
+func Save(templateDir string, m *Manifest) error {
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(templateDir, FileName), data, 0644)
+}
+
+// Defaults returns the declared default values keyed by variable name
+func (m *Manifest) Defaults() map[string]string {
+	defaults := make(map[string]string)
+	if m == nil {
+		return defaults
+	}
+	for _, v := range m.Variables {
+		if v.Default != "" {
+			defaults[v.Name] = v.Default
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,117 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+	"unicode"
+)
+
+// TypeIdentifier is the variable type of values used as identifiers in
+// code, such as package or type names
+const TypeIdentifier = "identifier"
+
+// DefaultLanguage is the language whose identifier rules apply when a
+// variable does not name one
+const DefaultLanguage = "go"
+
+// keywords lists the reserved words of each supported language, which are
+// not valid identifiers
+var keywords = map[string][]string{
+	"go": {
+		"break", "case", "chan", "const", "continue", "default", "defer",
+		"else", "fallthrough", "for", "func", "go", "goto", "if", "import",
+		"interface", "map", "package", "range", "return", "select", "struct",
+		"switch", "type", "var",
+	},
+	"python": {
+		"False", "None", "True", "and", "as", "assert", "async", "await",
+		"break", "class", "continue", "def", "del", "elif", "else", "except",
+		"finally", "for", "from", "global", "if", "import", "in", "is",
+		"lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try",
+		"while", "with", "yield",
+	},
+	"javascript": {
+		"await", "break", "case", "catch", "class", "const", "continue",
+		"debugger", "default", "delete", "do", "else", "enum", "export",
+		"extends", "false", "finally", "for", "function", "if", "import",
+		"in", "instanceof", "let", "new", "null", "return", "static", "super",
+		"switch", "this", "throw", "true", "try", "typeof", "var", "void",
+		"while", "with", "yield",
+	},
+}
+
+// checkSpec reports an unknown type or language in a variable declaration
+func (v Variable) checkSpec() error {
+	switch v.Type {
+	case "":
+		return nil
+	case TypeIdentifier:
+		if _, ok := keywords[v.language()]; !ok {
+			return fmt.Errorf("variable %s: unknown language %q", v.Name, v.Language)
+		}
+		return nil
+	}
+	return fmt.Errorf("variable %s: unknown type %q", v.Name, v.Type)
+}
+
+// language returns the language whose identifier rules apply
+func (v Variable) language() string {
+	if v.Language == "" {
+		return DefaultLanguage
+	}
+	return v.Language
+}
+
+// Validate checks a value against the variable's declared type. Values of
+// untyped variables are always valid.
+func (v Variable) Validate(value string) error {
+	if v.Type != TypeIdentifier {
+		return nil
+	}
+	return ValidIdentifier(value, v.language())
+}
+
+// ValidIdentifier checks that value is a valid identifier in language: a
+// letter or underscore followed by letters, digits and underscores, and not
+// a reserved word. JavaScript identifiers may also contain '$'.
+func ValidIdentifier(value, language string) error {
+	reserved, ok := keywords[language]
+	if !ok {
+		return fmt.Errorf("unknown language %q", language)
+	}
+	if value == "" {
+		return fmt.Errorf("an identifier cannot be empty")
+	}
+
+	for i, c := range value {
+		switch {
+		case c == '_' || unicode.IsLetter(c):
+		case c == '$' && language == "javascript":
+		case unicode.IsDigit(c) && i > 0:
+		case unicode.IsDigit(c):
+			return fmt.Errorf("%q is not a valid %s identifier: it starts with a digit", value, language)
+		case unicode.IsSpace(c):
+			return fmt.Errorf("%q is not a valid %s identifier: it contains whitespace", value, language)
+		default:
+			return fmt.Errorf("%q is not a valid %s identifier: it contains %q", value, language, c)
+		}
+	}
+
+	if slices.Contains(reserved, value) {
+		return fmt.Errorf("%q is not a valid %s identifier: it is a reserved word", value, language)
+	}
+	return nil
+}
+
+// Variable returns the declaration of the named variable
+func (m *Manifest) Variable(name string) (Variable, bool) {
+	if m == nil {
+		return Variable{}, false
+	}
+	for _, v := range m.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidIdentifier(t *testing.T) {
+	tests := []struct {
+		value    string
+		language string
+		wantErr  string
+	}{
+		{value: "models", language: "go"},
+		{value: "_private", language: "go"},
+		{value: "Order2", language: "go"},
+		{value: "naïve", language: "go"},
+		{value: "", language: "go", wantErr: "cannot be empty"},
+		{value: "2fast", language: "go", wantErr: "starts with a digit"},
+		{value: "my app", language: "go", wantErr: "contains whitespace"},
+		{value: "my-app", language: "go", wantErr: `contains '-'`},
+		{value: "$el", language: "go", wantErr: `contains '$'`},
+		{value: "$el", language: "javascript"},
+		{value: "type", language: "go", wantErr: "reserved word"},
+		{value: "type", language: "python"},
+		{value: "None", language: "python", wantErr: "reserved word"},
+		{value: "none", language: "python"},
+		{value: "class", language: "javascript", wantErr: "reserved word"},
+		{value: "x", language: "cobol", wantErr: `unknown language "cobol"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language+"/"+tt.value, func(t *testing.T) {
+			err := ValidIdentifier(tt.value, tt.language)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidIdentifier failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidIdentifier error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVariableValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable Variable
+		value    string
+		wantErr  bool
+		specErr  bool
+	}{
+		{name: "untyped accepts anything", variable: Variable{Name: "v"}, value: "not an identifier"},
+		{name: "go by default", variable: Variable{Name: "v", Type: TypeIdentifier}, value: "func", wantErr: true},
+		{name: "language applies", variable: Variable{Name: "v", Type: TypeIdentifier, Language: "python"}, value: "func"},
+		{name: "unknown type", variable: Variable{Name: "v", Type: "number"}, value: "1", specErr: true},
+		{name: "unknown language", variable: Variable{Name: "v", Type: TypeIdentifier, Language: "cobol"}, value: "x", wantErr: true, specErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.variable.Validate(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err := tt.variable.checkSpec(); (err != nil) != tt.specErr {
+				t.Errorf("checkSpec error = %v, wantErr %v", err, tt.specErr)
+			}
+		})
+	}
+}
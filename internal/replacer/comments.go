@@ -0,0 +1,98 @@
+package replacer
+
+import (
+	"bytes"
+
+	"github.com/linxux/stencil/config"
+)
+
+// Delimiters of author comments, which are part of the braces format
+const (
+	commentOpen  = "{{!--"
+	commentClose = "--}}"
+)
+
+// commentSpans returns the byte ranges of the author comments in content.
+// A comment that is alone on its lines is widened to cover those lines
+// entirely, so removing it leaves no blank line behind. An unterminated
+// comment is not a comment.
+func commentSpans(content []byte) [][2]int {
+	var spans [][2]int
+	offset := 0
+	for {
+		open := bytes.Index(content[offset:], []byte(commentOpen))
+		if open < 0 {
+			break
+		}
+		start := offset + open
+		end := bytes.Index(content[start+len(commentOpen):], []byte(commentClose))
+		if end < 0 {
+			break
+		}
+		end += start + len(commentOpen) + len(commentClose)
+		offset = end
+
+		lineStart := bytes.LastIndexByte(content[:start], '\n') + 1
+		lineEnd := len(content)
+		if i := bytes.IndexByte(content[end:], '\n'); i >= 0 {
+			lineEnd = end + i + 1
+		}
+		if isBlank(content[lineStart:start]) && isBlank(content[end:lineEnd]) {
+			start, end = lineStart, lineEnd
+			offset = end
+		}
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
+
+// isBlank reports whether b holds only spaces, tabs and a line ending
+func isBlank(b []byte) bool {
+	return len(bytes.Trim(b, " \t\r\n")) == 0
+}
+
+// StripComments removes author comments, "{{!-- note --}}", which may span
+// several lines and never appear in output. Placeholders and directives
+// inside a comment are ignored. Comments are recognized only when the braces
+// format is enabled.
+func StripComments(content []byte, formats config.FormatOptions) []byte {
+	if !formats.EnableBraces {
+		return content
+	}
+	spans := commentSpans(content)
+	if len(spans) == 0 {
+		return content
+	}
+
+	var out bytes.Buffer
+	prev := 0
+	for _, span := range spans {
+		out.Write(content[prev:span[0]])
+		prev = span[1]
+	}
+	out.Write(content[prev:])
+	return out.Bytes()
+}
+
+// MaskComments returns a copy of content with the text of author comments
+// replaced by spaces, keeping line breaks, so line numbers still match the
+// original content
+func MaskComments(content []byte, formats config.FormatOptions) []byte {
+	if !formats.EnableBraces {
+		return content
+	}
+	spans := commentSpans(content)
+	if len(spans) == 0 {
+		return content
+	}
+
+	masked := bytes.Clone(content)
+	for _, span := range spans {
+		for i := span[0]; i < span[1]; i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+	return masked
+}
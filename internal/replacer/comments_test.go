@@ -0,0 +1,58 @@
+package replacer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "inline", content: "a {{!-- note --}}b\n", want: "a b\n"},
+		{name: "own line", content: "a\n  {{!-- note --}}\nb\n", want: "a\nb\n"},
+		{name: "multi-line", content: "a\n{{!-- first\n  {{name}} second\n--}}\nb\n", want: "a\nb\n"},
+		{name: "multi-line inline", content: "a {{!-- x\ny --}} b\n", want: "a  b\n"},
+		{name: "several", content: "{{!-- 1 --}}a{{!-- 2 --}}b\n{{!-- 3 --}}\n", want: "ab\n"},
+		{name: "last line without newline", content: "a\n{{!-- note --}}", want: "a\n"},
+		{name: "unterminated", content: "a {{!-- note\n", want: "a {{!-- note\n"},
+		{name: "no comments", content: "{{name}}\n", want: "{{name}}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(StripComments([]byte(tt.content), allFormats)); got != tt.want {
+				t.Errorf("StripComments(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("braces disabled", func(t *testing.T) {
+		content := "a {{!-- note --}}\n"
+		if got := string(StripComments([]byte(content), config.FormatOptions{EnablePercent: true})); got != content {
+			t.Errorf("StripComments = %q, want content unchanged", got)
+		}
+	})
+}
+
+func TestMaskComments(t *testing.T) {
+	content := "a\n{{!-- {{x}}\n--}} {{name}}\n"
+	got := string(MaskComments([]byte(content), allFormats))
+	if want := "a\n           \n     {{name}}\n"; got != want {
+		t.Errorf("MaskComments = %q, want %q", got, want)
+	}
+	if strings.Count(got, "\n") != strings.Count(content, "\n") {
+		t.Errorf("MaskComments changed the number of lines")
+	}
+}
+
+func TestCommentPlaceholders(t *testing.T) {
+	content := []byte("{{!-- {{secret}} is not asked for\n__hidden__ --}}\nhello {{name}}\n")
+	if vars := ExtractVariablesFromFile(content, allFormats); len(vars) != 1 || vars[0] != "name" {
+		t.Errorf("ExtractVariablesFromFile = %q, want only name", vars)
+	}
+}
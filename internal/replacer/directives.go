@@ -0,0 +1,139 @@
+package replacer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Directive keywords recognized after a comment prefix
+const (
+	directiveIf    = "stencil:if"
+	directiveElse  = "stencil:else"
+	directiveEndif = "stencil:endif"
+)
+
+// directive is a parsed directive line
+type directive struct {
+	keyword string
+	// condition is the variable tested by an if directive, with an optional
+	// leading '!' to negate it
+	condition string
+}
+
+// parseDirective recognizes a directive written as a line comment with one
+// of the prefixes, e.g. "// stencil:if use_logging"
+func parseDirective(line []byte, prefixes []string) (directive, bool) {
+	text := strings.TrimSpace(string(line))
+	for _, prefix := range prefixes {
+		if prefix == "" || !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(text, prefix))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case directiveIf:
+			if len(fields) == 2 {
+				return directive{keyword: directiveIf, condition: fields[1]}, true
+			}
+		case directiveElse, directiveEndif:
+			if len(fields) == 1 {
+				return directive{keyword: fields[0]}, true
+			}
+		}
+	}
+	return directive{}, false
+}
+
+// ProcessDirectives evaluates conditional directives written as line
+// comments with one of the given prefixes and removes the directive lines:
+//
+//	// stencil:if use_logging
+//	log.Println("starting")
+//	// stencil:else
+//	// stencil:endif
+//
+// A block is kept when its variable is truthy (see IsTruthy); a leading '!'
+// negates the condition. Blocks may be nested.
+func (r *Replacer) ProcessDirectives(content []byte, prefixes []string) ([]byte, error) {
+	if len(prefixes) == 0 {
+		return content, nil
+	}
+
+	// Each open block records whether its current branch is kept, whether
+	// the enclosing blocks are kept, and the line that opened it
+	type block struct {
+		keep, parent bool
+		seenElse     bool
+		line         int
+	}
+	var stack []block
+	active := func() bool {
+		return len(stack) == 0 || stack[len(stack)-1].keep
+	}
+
+	var out bytes.Buffer
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	for i, line := range lines {
+		d, ok := parseDirective(line, prefixes)
+		if !ok {
+			if active() {
+				out.Write(line)
+			}
+			continue
+		}
+
+		switch d.keyword {
+		case directiveIf:
+			negate := strings.HasPrefix(d.condition, "!")
+			value, _ := r.lookup(strings.TrimPrefix(d.condition, "!"))
+			parent := active()
+			stack = append(stack, block{keep: parent && IsTruthy(value) != negate, parent: parent, line: i + 1})
+		case directiveElse:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: %s without %s", i+1, directiveElse, directiveIf)
+			}
+			top := &stack[len(stack)-1]
+			if top.seenElse {
+				return nil, fmt.Errorf("line %d: duplicate %s", i+1, directiveElse)
+			}
+			top.seenElse = true
+			top.keep = top.parent && !top.keep
+		case directiveEndif:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: %s without %s", i+1, directiveEndif, directiveIf)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: %s is never closed", stack[len(stack)-1].line, directiveIf)
+	}
+	return out.Bytes(), nil
+}
+
+// DirectiveVariables returns the variables tested by conditional directives
+// in content, in order of first appearance
+func DirectiveVariables(content []byte, prefixes []string) []string {
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	var variables []string
+	seen := make(map[string]bool)
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		d, ok := parseDirective(line, prefixes)
+		if !ok || d.keyword != directiveIf {
+			continue
+		}
+		name := strings.TrimPrefix(d.condition, "!")
+		if !seen[name] {
+			seen[name] = true
+			variables = append(variables, name)
+		}
+	}
+	return variables
+}
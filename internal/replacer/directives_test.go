@@ -0,0 +1,124 @@
+package replacer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProcessDirectives(t *testing.T) {
+	variables := map[string]string{"use_logging": "true", "use_metrics": "false"}
+	prefixes := []string{"//", "#"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "kept block",
+			content: "a\n// stencil:if use_logging\nlog\n// stencil:endif\nb\n",
+			want:    "a\nlog\nb\n",
+		},
+		{
+			name:    "dropped block",
+			content: "a\n// stencil:if use_metrics\nmetrics\n// stencil:endif\nb\n",
+			want:    "a\nb\n",
+		},
+		{
+			name:    "else branch",
+			content: "// stencil:if use_metrics\nmetrics\n// stencil:else\nnone\n// stencil:endif\n",
+			want:    "none\n",
+		},
+		{
+			name:    "negated",
+			content: "# stencil:if !use_metrics\nplain\n# stencil:endif\n",
+			want:    "plain\n",
+		},
+		{
+			name:    "unset variable is falsy",
+			content: "// stencil:if unset\nx\n// stencil:else\ny\n// stencil:endif\n",
+			want:    "y\n",
+		},
+		{
+			name: "nested inside a dropped block",
+			content: "// stencil:if use_metrics\n// stencil:if use_logging\nboth\n// stencil:else\n" +
+				"metrics only\n// stencil:endif\n// stencil:endif\nend\n",
+			want: "end\n",
+		},
+		{
+			name:    "nested inside a kept block",
+			content: "// stencil:if use_logging\n  // stencil:if !use_metrics\n  inner\n  // stencil:endif\n// stencil:endif\n",
+			want:    "  inner\n",
+		},
+		{
+			name:    "unknown prefix left alone",
+			content: "-- stencil:if use_metrics\nx\n-- stencil:endif\n",
+			want:    "-- stencil:if use_metrics\nx\n-- stencil:endif\n",
+		},
+		{
+			name:    "not a directive",
+			content: "// stencil:if\n// stencil:endif now\n",
+			want:    "// stencil:if\n// stencil:endif now\n",
+		},
+		{
+			name:    "no final newline",
+			content: "// stencil:if use_logging\nlog\n// stencil:endif",
+			want:    "log\n",
+		},
+		{
+			name:    "unclosed",
+			content: "a\n// stencil:if use_logging\nlog\n",
+			wantErr: "line 2: stencil:if is never closed",
+		},
+		{
+			name:    "stray endif",
+			content: "a\n// stencil:endif\n",
+			wantErr: "line 2: stencil:endif without stencil:if",
+		},
+		{
+			name:    "stray else",
+			content: "// stencil:else\n",
+			wantErr: "line 1: stencil:else without stencil:if",
+		},
+		{
+			name:    "duplicate else",
+			content: "// stencil:if use_logging\n// stencil:else\n// stencil:else\n// stencil:endif\n",
+			wantErr: "line 3: duplicate stencil:else",
+		},
+	}
+
+	r := NewReplacer(variables, allFormats)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ProcessDirectives([]byte(tt.content), prefixes)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProcessDirectives error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectives failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ProcessDirectives = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without prefixes directives are not recognized at all
+	content := []byte("// stencil:if use_metrics\nx\n")
+	if got, err := r.ProcessDirectives(content, nil); err != nil || string(got) != string(content) {
+		t.Errorf("ProcessDirectives without prefixes = %q, %v", got, err)
+	}
+}
+
+func TestDirectiveVariables(t *testing.T) {
+	content := []byte("// stencil:if b\n# stencil:if !a\n// stencil:if b\n-- stencil:if c\n")
+	got := DirectiveVariables(content, []string{"//", "#"})
+	if want := []string{"b", "a"}; !slices.Equal(got, want) {
+		t.Errorf("DirectiveVariables = %q, want %q", got, want)
+	}
+}
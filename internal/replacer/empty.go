@@ -0,0 +1,47 @@
+package replacer
+
+import "bytes"
+
+// hasEmptyValue reports whether any variable has an empty value
+func (r *Replacer) hasEmptyValue() bool {
+	for _, value := range r.variables {
+		if value == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DropEmptyLines removes the lines of content that hold a placeholder for
+// an empty variable and would be blank once substituted, so optional
+// values leave no empty lines behind. Other lines are kept as they are.
+func (r *Replacer) DropEmptyLines(content []byte) []byte {
+	if !r.hasEmptyValue() {
+		return content
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	for rest := content; len(rest) > 0; {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		rest = rest[len(line):]
+		if !r.blankWhenReplaced(line) {
+			out.Write(line)
+		}
+	}
+	return out.Bytes()
+}
+
+// blankWhenReplaced reports whether a line holds a placeholder for an
+// empty variable and has only whitespace left after substitution
+func (r *Replacer) blankWhenReplaced(line []byte) bool {
+	for _, rep := range r.FindReplacements(line) {
+		if rep.Value == "" {
+			return len(bytes.TrimSpace(r.ReplaceInContent(line))) == 0
+		}
+	}
+	return false
+}
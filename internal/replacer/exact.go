@@ -0,0 +1,181 @@
+package replacer
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+)
+
+// delimiterPair is the opening and closing delimiter of an enabled format,
+// with its name and extraction pattern
+type delimiterPair struct {
+	name        string
+	open, close string
+	pattern     *regexp.Regexp
+}
+
+// delimiters returns the delimiter pairs of the enabled formats, in the
+// order they are tried at a position
+func (r *Replacer) delimiters() []delimiterPair {
+	var pairs []delimiterPair
+	if r.formats.EnableBraces {
+		pairs = append(pairs, delimiterPair{"braces", "{{", "}}", bracesPattern})
+	}
+	if r.formats.EnableAngleBrackets {
+		pairs = append(pairs, delimiterPair{"angle-brackets", "<<", ">>", angleBracketsPattern})
+	}
+	if r.formats.EnableUnderscores {
+		pairs = append(pairs, delimiterPair{"underscores", "__", "__", underscoresPattern})
+	}
+	if r.formats.EnablePercent {
+		pairs = append(pairs, delimiterPair{"percent", "%", "%", percentPattern})
+	}
+	if r.formats.CustomEnabled() {
+		pairs = append(pairs, delimiterPair{"custom", r.formats.CustomOpen, r.formats.CustomClose,
+			customPattern(r.formats.CustomOpen, r.formats.CustomClose)})
+	}
+	return pairs
+}
+
+// replace substitutes the placeholders of known variables in a single
+// left-to-right pass. Substituted values are never scanned again, so a
+// value containing a placeholder is written as it is. With indent set,
+// multi-line values are re-indented to the placeholder's line.
+func (r *Replacer) replace(content []byte, indent bool) []byte {
+	var out []byte
+	prev := 0
+	r.scan(content, func(rep Replacement) {
+		if out == nil {
+			out = make([]byte, 0, len(content))
+		}
+		out = append(out, content[prev:rep.Start]...)
+		value := rep.Value
+		if indent {
+			value = indentValue(value, lineIndent(content, rep.Start))
+		}
+		out = append(out, value...)
+		prev = rep.End
+	})
+
+	if out == nil {
+		return content
+	}
+	return append(out, content[prev:]...)
+}
+
+// scan calls fn for each placeholder of a known variable in content, left
+// to right. Placeholders never overlap: where they would, the leftmost
+// wins, and at one position the first enabled format. When keys are
+// matched exactly, a placeholder's key is any variable name, the shortest
+// at a position winning; otherwise it is the key the format's extraction
+// pattern captures there, normalized before lookup.
+func (r *Replacer) scan(content []byte, fn func(Replacement)) {
+	pairs := r.delimiters()
+	if len(pairs) == 0 || len(r.variables) == 0 {
+		return
+	}
+
+	matchKeys := r.matchKeys()
+	maxKey := 0
+	for key := range r.variables {
+		maxKey = max(maxKey, len(key))
+	}
+	var openers [256]bool
+	for _, p := range pairs {
+		openers[p.open[0]] = true
+	}
+
+	for i := 0; i < len(content); {
+		j := indexOpener(content[i:], &openers)
+		if j < 0 {
+			return
+		}
+		i += j
+
+		var rep Replacement
+		var ok bool
+		if matchKeys {
+			rep, ok = r.matchPatternAt(content, i, pairs)
+		} else {
+			rep, ok = r.matchExactAt(content, i, pairs, maxKey)
+		}
+		if !ok {
+			i++
+			continue
+		}
+		fn(rep)
+		i = rep.End
+	}
+}
+
+// matchExactAt looks for a placeholder starting at content[i] whose key is
+// exactly a variable name
+func (r *Replacer) matchExactAt(content []byte, i int, pairs []delimiterPair, maxKey int) (Replacement, bool) {
+	for _, p := range pairs {
+		if !bytes.HasPrefix(content[i:], []byte(p.open)) {
+			continue
+		}
+		keyStart := i + len(p.open)
+		limit := min(len(content), keyStart+maxKey+len(p.close))
+
+		// Try every closing delimiter within reach, since keys such as
+		// "a__b" may contain the closing delimiter themselves
+		for k := keyStart; k <= limit; {
+			idx := bytes.Index(content[k:limit], []byte(p.close))
+			if idx < 0 {
+				break
+			}
+			keyEnd := k + idx
+			key := string(content[keyStart:keyEnd])
+			if value, ok := r.variables[key]; ok {
+				return Replacement{Name: key, Format: p.name, Start: i, End: keyEnd + len(p.close), Value: value}, true
+			}
+			k = keyEnd + 1
+		}
+	}
+	return Replacement{}, false
+}
+
+// matchPatternAt looks for a placeholder starting at content[i] that the
+// format's extraction pattern matches and whose normalized key has a value
+func (r *Replacer) matchPatternAt(content []byte, i int, pairs []delimiterPair) (Replacement, bool) {
+	for _, p := range pairs {
+		if !bytes.HasPrefix(content[i:], []byte(p.open)) {
+			continue
+		}
+		loc := anchored(p.pattern).FindSubmatchIndex(content[i:])
+		if loc == nil {
+			continue
+		}
+		key := string(content[i+loc[2] : i+loc[3]])
+		if value, ok := r.lookup(key); ok {
+			return Replacement{Name: key, Format: p.name, Start: i, End: i + loc[1], Value: value}, true
+		}
+	}
+	return Replacement{}, false
+}
+
+// anchoredPatterns caches the anchored form of extraction patterns
+var anchoredPatterns sync.Map
+
+// anchored returns pattern anchored at the start of the input, so matching
+// at a position costs no more than the placeholder there
+func anchored(pattern *regexp.Regexp) *regexp.Regexp {
+	if a, ok := anchoredPatterns.Load(pattern); ok {
+		return a.(*regexp.Regexp)
+	}
+	a := regexp.MustCompile(`^(?:` + pattern.String() + `)`)
+	anchoredPatterns.Store(pattern, a)
+	return a
+}
+
+// indexOpener returns the index of the first byte of s that can start a
+// placeholder, or -1
+func indexOpener(s []byte, openers *[256]bool) int {
+	for i, c := range s {
+		if openers[c] {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,243 @@
+package replacer
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+)
+
+// allFormats enables every format, including a custom "[[ ]]" pair
+var allFormats = config.FormatOptions{
+	EnableBraces:        true,
+	EnableAngleBrackets: true,
+	EnableUnderscores:   true,
+	EnablePercent:       true,
+	CustomOpen:          "[[",
+	CustomClose:         "]]",
+}
+
+// legacyReplace is the replacement ReplaceInContent used before the single
+// pass: one ReplaceAll per variable and format, with keys in the given order
+func legacyReplace(content []byte, variables map[string]string, keys []string, pairs []delimiterPair) []byte {
+	result := content
+	for _, key := range keys {
+		for _, p := range pairs {
+			placeholder := []byte(p.open + key + p.close)
+			result = bytes.ReplaceAll(result, placeholder, []byte(variables[key]))
+		}
+	}
+	return result
+}
+
+// legacyResult returns the legacy output for content if it does not depend
+// on the order keys and formats are visited in
+func legacyResult(content []byte, variables map[string]string, formats config.FormatOptions) ([]byte, bool) {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	reversedKeys := slices.Clone(keys)
+	slices.Reverse(reversedKeys)
+
+	pairs := NewReplacer(variables, formats).delimiters()
+	reversedPairs := slices.Clone(pairs)
+	slices.Reverse(reversedPairs)
+
+	want := legacyReplace(content, variables, keys, pairs)
+	for _, ks := range [][]string{keys, reversedKeys} {
+		for _, ps := range [][]delimiterPair{pairs, reversedPairs} {
+			if !bytes.Equal(legacyReplace(content, variables, ks, ps), want) {
+				return nil, false
+			}
+		}
+	}
+	return want, true
+}
+
+func TestReplaceInContentMatchesLegacy(t *testing.T) {
+	variables := map[string]string{
+		"a":    "X",
+		"b":    "YY",
+		"ab":   "",
+		"a_b":  "Z",
+		"name": "my-app",
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"no placeholders", "plain text"},
+		{"braces", "{{a}} and {{name}}"},
+		{"every format", "{{a}} <<b>> __a_b__ %name% [[ab]]"},
+		{"unknown key", "{{c}} {{a}}"},
+		{"adjacent", "{{a}}{{b}}__a____b__"},
+		{"unterminated", "{{a}} {{b"},
+		{"nested openers", "{{{a}}}"},
+		{"empty value", "x{{ab}}y"},
+		{"multiple lines", "{{a}}\n  <<b>>\n%a%"},
+		{"lone delimiters", "%% __ {{}} <<>>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, ok := legacyResult([]byte(tt.content), variables, allFormats)
+			if !ok {
+				t.Fatalf("legacy output depends on iteration order for %q", tt.content)
+			}
+			got := NewReplacer(variables, allFormats).ReplaceInContent([]byte(tt.content))
+			if !bytes.Equal(got, want) {
+				t.Errorf("ReplaceInContent(%q) = %q, want %q", tt.content, got, want)
+			}
+		})
+	}
+}
+
+// TestReplaceInContentMatchesLegacyRandom compares the single pass with the
+// legacy replacement on random inputs wherever the legacy output did not
+// depend on map iteration order
+func TestReplaceInContentMatchesLegacyRandom(t *testing.T) {
+	variables := map[string]string{"a": "X", "b": "YY", "ab": "", "a_b": "Z"}
+	pieces := []string{"a", "b", "_", "{{", "}}", "<<", ">>", "__", "%", "[[", "]]", " ", "\n"}
+	r := NewReplacer(variables, allFormats)
+	rng := rand.New(rand.NewSource(1))
+
+	compared := 0
+	for i := 0; i < 20000; i++ {
+		var b strings.Builder
+		for n := rng.Intn(12); n > 0; n-- {
+			b.WriteString(pieces[rng.Intn(len(pieces))])
+		}
+		content := []byte(b.String())
+
+		want, ok := legacyResult(content, variables, allFormats)
+		if !ok {
+			continue
+		}
+		compared++
+		if got := r.ReplaceInContent(content); !bytes.Equal(got, want) {
+			t.Fatalf("ReplaceInContent(%q) = %q, want %q", content, got, want)
+		}
+	}
+	if compared < 10000 {
+		t.Fatalf("only %d inputs had an order-independent legacy result", compared)
+	}
+}
+
+func TestReplaceDoesNotResubstitute(t *testing.T) {
+	variables := map[string]string{
+		"name":  "{{other}} <<other>> __other__ %other%",
+		"other": "X",
+	}
+	const want = "{{other}} <<other>> __other__ %other%"
+
+	tests := []struct {
+		name    string
+		content string
+		setup   func(r *Replacer)
+	}{
+		{"exact", "{{name}}", func(r *Replacer) {}},
+		{"exact underscores", "__name__", func(r *Replacer) {}},
+		{"case-insensitive", "{{NAME}}", func(r *Replacer) { r.SetCaseInsensitive(true) }},
+		{"trim spaces", "{{ name }}", func(r *Replacer) { r.SetTrimSpaces(true) }},
+		{"lowercase keys", "<<Name>>", func(r *Replacer) { r.SetLowercaseKeys(true) }},
+		{"indent", "%name%", func(r *Replacer) { r.SetIndentValues(true) }},
+		{"all options", "[[ NAME ]]", func(r *Replacer) {
+			r.SetCaseInsensitive(true)
+			r.SetTrimSpaces(true)
+			r.SetIndentValues(true)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(variables, allFormats)
+			tt.setup(r)
+			if got := string(r.ReplaceInContent([]byte(tt.content))); got != want {
+				t.Errorf("ReplaceInContent(%q) = %q, want %q", tt.content, got, want)
+			}
+			if got := r.ReplaceInPath(tt.content); got != want {
+				t.Errorf("ReplaceInPath(%q) = %q, want %q", tt.content, got, want)
+			}
+		})
+	}
+}
+
+func TestReplaceIndentValues(t *testing.T) {
+	variables := map[string]string{"block": "a: 1\nb: 2\n\nc: 3", "inline": "x"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"indented", "root:\n  {{block}}\n", "root:\n  a: 1\n  b: 2\n\n  c: 3\n"},
+		{"tabs", "\t<<block>>", "\ta: 1\n\tb: 2\n\n\tc: 3"},
+		{"unindented", "{{block}}", "a: 1\nb: 2\n\nc: 3"},
+		{"after text", "  key: {{inline}} {{block}}", "  key: x a: 1\n  b: 2\n\n  c: 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(variables, allFormats)
+			r.SetIndentValues(true)
+			if got := string(r.ReplaceInContent([]byte(tt.content))); got != tt.want {
+				t.Errorf("ReplaceInContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkInput returns 100 variables and content of about 64KB using
+// each of them in every format
+func benchmarkInput() (map[string]string, []byte) {
+	variables := make(map[string]string)
+	var b bytes.Buffer
+	for b.Len() < 64<<10 {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("variable_%d", i)
+			variables[key] = fmt.Sprintf("value-%d", i)
+			fmt.Fprintf(&b, "line {{%s}} with <<%s>> and __%s__ or %%%s%% text\n", key, key, key, key)
+		}
+	}
+	return variables, b.Bytes()
+}
+
+func BenchmarkReplaceInContent(b *testing.B) {
+	variables, content := benchmarkInput()
+	r := NewReplacer(variables, allFormats)
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		r.ReplaceInContent(content)
+	}
+}
+
+func BenchmarkReplaceInContentMatched(b *testing.B) {
+	variables, content := benchmarkInput()
+	r := NewReplacer(variables, allFormats)
+	r.SetTrimSpaces(true)
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		r.ReplaceInContent(content)
+	}
+}
+
+func BenchmarkLegacyReplace(b *testing.B) {
+	variables, content := benchmarkInput()
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	pairs := NewReplacer(variables, allFormats).delimiters()
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		legacyReplace(content, variables, keys, pairs)
+	}
+}
@@ -0,0 +1,27 @@
+package replacer
+
+// Replacement describes a placeholder that would be substituted
+type Replacement struct {
+	// Name is the variable name as written in the placeholder
+	Name string
+	// Format is the placeholder format: "braces", "angle-brackets",
+	// "underscores", "percent" or "custom"
+	Format string
+	// Start and End are the byte offsets of the placeholder, delimiters
+	// included, with End exclusive
+	Start, End int
+	// Value is the substituted value
+	Value string
+}
+
+// FindReplacements returns the placeholders in content that have a value,
+// ordered by offset, without modifying content. They are exactly the
+// placeholders ReplaceInContent substitutes: when placeholders overlap,
+// such as "__x__" inside "{{__x__}}", the one starting first wins.
+func (r *Replacer) FindReplacements(content []byte) []Replacement {
+	var found []Replacement
+	r.scan(content, func(rep Replacement) {
+		found = append(found, rep)
+	})
+	return found
+}
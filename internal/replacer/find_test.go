@@ -0,0 +1,80 @@
+package replacer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindReplacements(t *testing.T) {
+	variables := map[string]string{"a": "1", "b": "22", "x": "X", "__x__": "X", "name": "app"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []Replacement
+	}{
+		{
+			name:    "single",
+			content: "hi {{name}}!",
+			want:    []Replacement{{Name: "name", Format: "braces", Start: 3, End: 11, Value: "app"}},
+		},
+		{
+			name:    "adjacent",
+			content: "{{a}}{{b}}<<a>>",
+			want: []Replacement{
+				{Name: "a", Format: "braces", Start: 0, End: 5, Value: "1"},
+				{Name: "b", Format: "braces", Start: 5, End: 10, Value: "22"},
+				{Name: "a", Format: "angle-brackets", Start: 10, End: 15, Value: "1"},
+			},
+		},
+		{
+			name:    "adjacent underscores",
+			content: "__a____b__",
+			want: []Replacement{
+				{Name: "a", Format: "underscores", Start: 0, End: 5, Value: "1"},
+				{Name: "b", Format: "underscores", Start: 5, End: 10, Value: "22"},
+			},
+		},
+		{
+			// The braces placeholder starts first, so the underscores one
+			// inside it is not substituted on its own
+			name:    "overlapping",
+			content: "{{__x__}} __x__",
+			want: []Replacement{
+				{Name: "__x__", Format: "braces", Start: 0, End: 9, Value: "X"},
+				{Name: "x", Format: "underscores", Start: 10, End: 15, Value: "X"},
+			},
+		},
+		{
+			name:    "overlapping percent",
+			content: "%a%b%",
+			want:    []Replacement{{Name: "a", Format: "percent", Start: 0, End: 3, Value: "1"}},
+		},
+		{
+			name:    "multibyte offsets",
+			content: "é {{name}}",
+			want:    []Replacement{{Name: "name", Format: "braces", Start: 3, End: 11, Value: "app"}},
+		},
+		{
+			name:    "custom delimiters",
+			content: "[[b]]",
+			want:    []Replacement{{Name: "b", Format: "custom", Start: 0, End: 5, Value: "22"}},
+		},
+		{name: "unknown variable", content: "{{missing}}"},
+		{name: "no placeholders", content: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(variables, allFormats)
+			content := []byte(tt.content)
+			got := r.FindReplacements(content)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindReplacements(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+			if string(content) != tt.content {
+				t.Errorf("content modified to %q", content)
+			}
+		})
+	}
+}
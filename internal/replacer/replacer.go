@@ -2,9 +2,14 @@ package replacer
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/linxux/stencil/config"
 )
@@ -13,6 +18,19 @@ import (
 type Replacer struct {
 	variables map[string]string
 	formats   config.FormatOptions
+
+	// folded maps lowercased keys to values when matching case-insensitively
+	folded map[string]string
+
+	// trimSpaces ignores whitespace inside delimiters, e.g. "{{ name }}"
+	trimSpaces bool
+
+	// lowerKeys lowercases placeholder keys before looking them up
+	lowerKeys bool
+
+	// indent re-indents the lines of multi-line values in content to the
+	// indentation of the placeholder's line
+	indent bool
 }
 
 // NewReplacer creates a new Replacer with the given variables and format options
@@ -23,172 +41,288 @@ func NewReplacer(variables map[string]string, formats config.FormatOptions) *Rep
 	}
 }
 
-// ReplaceInContent replaces variables in file content
-func (r *Replacer) ReplaceInContent(content []byte) []byte {
-	result := content
-	for key, value := range r.variables {
-		// Replace {{key}} format
-		if r.formats.EnableBraces {
-			pattern := []byte("{{" + key + "}}")
-			result = bytes.ReplaceAll(result, pattern, []byte(value))
-		}
-
-		// Replace <<key>> format
-		if r.formats.EnableAngleBrackets {
-			pattern2 := []byte("<<" + key + ">>")
-			result = bytes.ReplaceAll(result, pattern2, []byte(value))
-		}
+// SetCaseInsensitive enables or disables case-insensitive key matching.
+// When several keys fold to the same spelling, the lexically first one wins.
+func (r *Replacer) SetCaseInsensitive(enabled bool) {
+	if !enabled {
+		r.folded = nil
+		return
+	}
 
-		// Replace __key__ format
-		if r.formats.EnableUnderscores {
-			pattern3 := []byte("__" + key + "__")
-			result = bytes.ReplaceAll(result, pattern3, []byte(value))
-		}
+	keys := make([]string, 0, len(r.variables))
+	for key := range r.variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		// Replace %key% format
-		if r.formats.EnablePercent {
-			pattern4 := []byte("%" + key + "%")
-			result = bytes.ReplaceAll(result, pattern4, []byte(value))
+	r.folded = make(map[string]string, len(keys))
+	for _, key := range keys {
+		folded := strings.ToLower(key)
+		if _, ok := r.folded[folded]; !ok {
+			r.folded[folded] = r.variables[key]
 		}
 	}
-	return result
 }
 
-// ReplaceInPath replaces variables in file or directory paths
+// SetTrimSpaces enables or disables tolerance for whitespace between the
+// delimiters and the key, so "{{ name }}" resolves like "{{name}}". Only the
+// braces and angle-bracket formats can contain whitespace.
+func (r *Replacer) SetTrimSpaces(enabled bool) {
+	r.trimSpaces = enabled
+}
+
+// SetLowercaseKeys enables or disables lowercasing placeholder keys before
+// they are looked up, so "{{ProjectName}}" and "{{PROJECTNAME}}" both
+// resolve the variable "projectname". Variable names and values are used as
+// given, unlike case-insensitive matching, which folds both sides.
+func (r *Replacer) SetLowercaseKeys(enabled bool) {
+	r.lowerKeys = enabled
+}
+
+// SetIndentValues enables or disables indent-aware substitution in content:
+// every line of a multi-line value after the first gets the leading
+// whitespace of the line holding the placeholder, so a value substituted
+// into an indented block such as YAML stays inside the block
+func (r *Replacer) SetIndentValues(enabled bool) {
+	r.indent = enabled
+}
+
+// matchKeys reports whether placeholders must be matched by pattern and
+// their keys normalized, rather than by exact string replacement
+func (r *Replacer) matchKeys() bool {
+	return r.folded != nil || r.trimSpaces || r.lowerKeys
+}
+
+// ReplaceInContent replaces variables in file content
+func (r *Replacer) ReplaceInContent(content []byte) []byte {
+	return r.replace(content, r.indent)
+}
+
+// ReplaceInPath replaces variables in file or directory paths. Paths may
+// also hold conditional placeholders, "{{flag ? a.ts : a.js}}", which choose
+// a segment by the truthiness of a variable.
 func (r *Replacer) ReplaceInPath(path string) string {
-	result := path
-	for key, value := range r.variables {
-		// Replace {{key}} format
-		if r.formats.EnableBraces {
-			result = strings.ReplaceAll(result, "{{"+key+"}}", value)
-		}
+	path = r.replaceTernaries(path)
+	return string(r.replace([]byte(path), false))
+}
 
-		// Replace <<key>> format
-		if r.formats.EnableAngleBrackets {
-			result = strings.ReplaceAll(result, "<<"+key+">>", value)
-		}
+// lineIndent returns the leading spaces and tabs of the line containing
+// content[pos]
+func lineIndent(content []byte, pos int) string {
+	start := bytes.LastIndexByte(content[:pos], '\n') + 1
+	end := start
+	for end < pos && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[start:end])
+}
 
-		// Replace __key__ format (common in folder names)
-		if r.formats.EnableUnderscores {
-			result = strings.ReplaceAll(result, "__"+key+"__", value)
+// indentValue prefixes every line of value after the first with indent.
+// Blank lines are left empty so no trailing whitespace is introduced.
+func indentValue(value, indent string) string {
+	if indent == "" || !strings.Contains(value, "\n") {
+		return value
+	}
+	lines := strings.Split(value, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], "\r") != "" {
+			lines[i] = indent + lines[i]
 		}
+	}
+	return strings.Join(lines, "\n")
+}
 
-		// Replace %key% format
-		if r.formats.EnablePercent {
-			result = strings.ReplaceAll(result, "%"+key+"%", value)
-		}
+// lookup returns the value for a placeholder key, applying whitespace
+// trimming, key lowercasing and case folding as configured
+func (r *Replacer) lookup(key string) (string, bool) {
+	if r.trimSpaces {
+		key = strings.TrimSpace(key)
 	}
-	return result
+	if r.lowerKeys {
+		key = strings.ToLower(key)
+	}
+	if r.folded != nil {
+		value, ok := r.folded[strings.ToLower(key)]
+		return value, ok
+	}
+	value, ok := r.variables[key]
+	return value, ok
 }
 
-// ExtractVariablesFromFile extracts variables from file content
-func ExtractVariablesFromFile(content []byte, formats config.FormatOptions) []string {
-	variables := make(map[string]bool)
+// Extraction patterns for each supported format.
+//
+// The extractors uphold a few invariants regardless of input:
+//   - they never panic and run in linear time (RE2 has no backtracking);
+//   - a captured name never contains its own delimiter characters or a
+//     newline, so runs like "{{{{{{" or unterminated delimiters yield nothing
+//     rather than names such as "{{{{x";
+//   - every extracted name is matched verbatim by ReplaceInContent and
+//     ReplaceInPath: with a value for each extracted name, every extracted
+//     placeholder is substituted or overlaps a placeholder that is;
+//   - adjacent placeholders such as "{{a}}{{b}}" or "__a____b__" yield two
+//     names, so an underscores name never contains "__" or starts or ends
+//     with an underscore.
+//
+// FuzzReplaceInContent enforces these, and FuzzFindReplacements that
+// FindReplacements reports exactly the substitutions ReplaceInContent makes.
+var (
+	bracesPattern        = regexp.MustCompile(`\{\{([^{}\r\n]+)\}\}`)
+	angleBracketsPattern = regexp.MustCompile(`<<([^<>\r\n]+)>>`)
+	underscoresPattern   = regexp.MustCompile(`__([A-Za-z0-9]+(?:_[A-Za-z0-9]+)*)__`)
+	percentPattern       = regexp.MustCompile(`%([A-Za-z0-9_]+)%`)
+)
 
-	// Pattern for {{var}}
+// formatPattern is the extraction pattern of a named format
+type formatPattern struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// enabledFormats returns the names and extraction patterns of the enabled
+// formats, using the names of .stencilattributes
+func enabledFormats(formats config.FormatOptions) []formatPattern {
+	var patterns []formatPattern
 	if formats.EnableBraces {
-		pattern1 := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-		matches := pattern1.FindAllSubmatch(content, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[string(match[1])] = true
-			}
-		}
+		patterns = append(patterns, formatPattern{"braces", bracesPattern})
 	}
-
-	// Pattern for <<var>>
 	if formats.EnableAngleBrackets {
-		pattern2 := regexp.MustCompile(`<<([^>]+)>>`)
-		matches := pattern2.FindAllSubmatch(content, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[string(match[1])] = true
-			}
-		}
+		patterns = append(patterns, formatPattern{"angle-brackets", angleBracketsPattern})
 	}
-
-	// Pattern for __var__
 	if formats.EnableUnderscores {
-		pattern3 := regexp.MustCompile(`__([A-Za-z0-9_]+)__`)
-		matches := pattern3.FindAllSubmatch(content, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[string(match[1])] = true
-			}
-		}
+		patterns = append(patterns, formatPattern{"underscores", underscoresPattern})
 	}
-
-	// Pattern for %var%
 	if formats.EnablePercent {
-		pattern4 := regexp.MustCompile(`%([A-Za-z0-9_]+)%`)
-		matches := pattern4.FindAllSubmatch(content, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[string(match[1])] = true
-			}
-		}
+		patterns = append(patterns, formatPattern{"percent", percentPattern})
+	}
+	if formats.CustomEnabled() {
+		patterns = append(patterns, formatPattern{"custom", customPattern(formats.CustomOpen, formats.CustomClose)})
 	}
+	return patterns
+}
 
-	result := make([]string, 0, len(variables))
-	for v := range variables {
-		result = append(result, v)
+// enabledPatterns returns the extraction patterns for the enabled formats
+func enabledPatterns(formats config.FormatOptions) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, f := range enabledFormats(formats) {
+		patterns = append(patterns, f.pattern)
 	}
-	return result
+	return patterns
 }
 
-// ExtractVariablesFromPath extracts variables from a path
-func ExtractVariablesFromPath(path string, formats config.FormatOptions) []string {
-	variables := make(map[string]bool)
+// customPatterns caches compiled patterns for custom delimiter pairs
+var customPatterns sync.Map
 
-	// Pattern for {{var}}
-	if formats.EnableBraces {
-		pattern1 := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-		matches := pattern1.FindAllStringSubmatch(path, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[match[1]] = true
-			}
-		}
+// customPattern returns the extraction pattern for a custom delimiter pair.
+// As with braces, a captured key never contains the delimiters' characters
+// or a newline.
+func customPattern(open, close string) *regexp.Regexp {
+	cacheKey := open + "\x00" + close
+	if pattern, ok := customPatterns.Load(cacheKey); ok {
+		return pattern.(*regexp.Regexp)
 	}
 
-	// Pattern for <<var>>
-	if formats.EnableAngleBrackets {
-		pattern2 := regexp.MustCompile(`<<([^>]+)>>`)
-		matches := pattern2.FindAllStringSubmatch(path, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[match[1]] = true
-			}
+	var excluded strings.Builder
+	for _, c := range open + close {
+		if c < utf8.RuneSelf && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			excluded.WriteByte('\\')
 		}
+		excluded.WriteRune(c)
 	}
 
-	// Pattern for __var__
-	if formats.EnableUnderscores {
-		pattern3 := regexp.MustCompile(`__([A-Za-z0-9_]+)__`)
-		matches := pattern3.FindAllStringSubmatch(path, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[match[1]] = true
+	pattern := regexp.MustCompile(regexp.QuoteMeta(open) + `([^` + excluded.String() + `\r\n]+)` + regexp.QuoteMeta(close))
+	customPatterns.Store(cacheKey, pattern)
+	return pattern
+}
+
+// Canonicalizer maps a placeholder name to the canonical name it is
+// reported and deduplicated under, e.g. resolving an alias. An empty result
+// drops the name.
+type Canonicalizer func(name string) string
+
+// extractVariables returns the distinct canonical variable names found in s,
+// in order of first appearance for each enabled format. A nil canon keeps
+// names as they are written. In a path, a conditional placeholder yields
+// the variable it tests.
+func extractVariables(s string, formats config.FormatOptions, canon Canonicalizer, path bool) []string {
+	seen := make(map[string]bool)
+	var result []string
+
+	for _, pattern := range enabledPatterns(formats) {
+		for _, match := range pattern.FindAllStringSubmatch(s, -1) {
+			if len(match) < 2 {
+				continue
+			}
+			name := match[1]
+			if path {
+				if condition, _, _, ok := parseTernary(name); ok {
+					name = condition
+				}
+			}
+			if canon != nil {
+				name = canon(name)
+			}
+			if name != "" && !seen[name] {
+				seen[name] = true
+				result = append(result, name)
 			}
 		}
 	}
 
-	// Pattern for %var%
-	if formats.EnablePercent {
-		pattern4 := regexp.MustCompile(`%([A-Za-z0-9_]+)%`)
-		matches := pattern4.FindAllStringSubmatch(path, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				variables[match[1]] = true
-			}
+	return result
+}
+
+// ExtractVariablesFromFile extracts variables from file content, ignoring
+// author comments
+func ExtractVariablesFromFile(content []byte, formats config.FormatOptions) []string {
+	return ExtractCanonicalFromFile(content, formats, nil)
+}
+
+// ExtractVariablesFromPath extracts variables from a path
+func ExtractVariablesFromPath(path string, formats config.FormatOptions) []string {
+	return ExtractCanonicalFromPath(path, formats, nil)
+}
+
+// ExtractCanonicalFromFile extracts variables from file content like
+// ExtractVariablesFromFile, passing each name through canon before
+// deduplicating, so variants of one variable are reported once
+func ExtractCanonicalFromFile(content []byte, formats config.FormatOptions, canon Canonicalizer) []string {
+	return extractVariables(string(StripComments(content, formats)), formats, canon, false)
+}
+
+// ExtractCanonicalFromPath extracts variables from a path like
+// ExtractVariablesFromPath, passing each name through canon before
+// deduplicating
+func ExtractCanonicalFromPath(path string, formats config.FormatOptions, canon Canonicalizer) []string {
+	return extractVariables(path, formats, canon, true)
+}
+
+// UnterminatedLines returns the 1-based line numbers containing a "{{" with
+// no closing "}}" later on the same line, which usually indicates a
+// malformed placeholder
+func UnterminatedLines(content []byte, formats config.FormatOptions) []int {
+	if !formats.EnableBraces {
+		return nil
+	}
+
+	var lines []int
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		open := bytes.LastIndex(line, []byte("{{"))
+		if open >= 0 && !bytes.Contains(line[open:], []byte("}}")) {
+			lines = append(lines, i+1)
 		}
 	}
+	return lines
+}
 
-	result := make([]string, 0, len(variables))
-	for v := range variables {
-		result = append(result, v)
+// IsTruthy reports whether a variable value counts as true in conditions.
+// "true", "yes", "y", "on" and "1" are truthy, case-insensitively; anything
+// else, including an empty value, is falsy.
+func IsTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "y", "on", "1":
+		return true
 	}
-	return result
+	return false
 }
 
 // IsBinaryFile checks if a file is binary (should skip content replacement)
@@ -199,9 +333,14 @@ func IsBinaryFile(filePath string) bool {
 	}
 	defer file.Close()
 
+	return IsBinary(file)
+}
+
+// IsBinary checks if the content read from r is binary
+func IsBinary(r io.Reader) bool {
 	// Read first 512 bytes to determine file type
 	buffer := make([]byte, 512)
-	n, err := file.Read(buffer)
+	n, err := r.Read(buffer)
 	if err != nil {
 		return false
 	}
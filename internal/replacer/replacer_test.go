@@ -0,0 +1,353 @@
+package replacer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds are delimiter soups and overlaps the extractors must handle
+var fuzzSeeds = []string{
+	"",
+	"{{name}}",
+	"{{{{{{",
+	"}}}}{{{{x}}}}",
+	"{{a}}{{b}}",
+	"__a____b__",
+	"___a___",
+	"__x__ inside {{__x__}}",
+	"%a%b%c%",
+	"<<<a>>> <<b>>>>",
+	"[[x]] [[[y]]]",
+	"{{ name }} << name >>",
+	"{{a\nb}}",
+	"{{flag ? a.ts : a.js}}",
+}
+
+// placeholder is a placeholder found by a format's extraction pattern
+type placeholder struct {
+	format     string
+	name       string
+	start, end int
+}
+
+// extractAll returns every placeholder the extraction patterns of the
+// enabled formats find in content
+func extractAll(content string) []placeholder {
+	var found []placeholder
+	for _, f := range enabledFormats(allFormats) {
+		for _, loc := range f.pattern.FindAllStringSubmatchIndex(content, -1) {
+			found = append(found, placeholder{f.name, content[loc[2]:loc[3]], loc[0], loc[1]})
+		}
+	}
+	return found
+}
+
+// FuzzReplaceInContent checks the extractor invariants documented on the
+// extraction patterns
+func FuzzReplaceInContent(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		// Never panic
+		ExtractVariablesFromFile([]byte(content), allFormats)
+		ExtractVariablesFromPath(content, allFormats)
+
+		found := extractAll(content)
+		variables := make(map[string]string)
+		for _, p := range found {
+			// A name never contains its own delimiter characters or a
+			// newline; for underscores the delimiter is the "__" run
+			var delims string
+			for _, d := range NewReplacer(nil, allFormats).delimiters() {
+				if d.name == p.format && p.format != "underscores" {
+					delims = d.open + d.close
+				}
+			}
+			if strings.ContainsAny(p.name, delims+"\r\n") {
+				t.Fatalf("%s name %q contains its delimiters or a newline", p.format, p.name)
+			}
+			// An underscores name has no "__" and no outer underscore
+			if p.format == "underscores" && (strings.Contains(p.name, "__") ||
+				strings.HasPrefix(p.name, "_") || strings.HasSuffix(p.name, "_")) {
+				t.Fatalf("underscores name %q has a double, leading or trailing underscore", p.name)
+			}
+			variables[p.name] = "V"
+		}
+
+		// With a value for every extracted name, every extracted placeholder
+		// is substituted or overlaps one that is
+		r := NewReplacer(variables, allFormats)
+		reps := r.FindReplacements([]byte(content))
+		for _, p := range found {
+			covered := false
+			for _, rep := range reps {
+				if rep.Start < p.end && p.start < rep.End {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				t.Fatalf("%s placeholder %q at %d is not replaced", p.format, content[p.start:p.end], p.start)
+			}
+		}
+
+		r.SetIndentValues(true)
+		r.ReplaceInContent([]byte(content))
+		r.ReplaceInPath(content)
+	})
+}
+
+// FuzzFindReplacements checks that FindReplacements reports exactly the
+// substitutions ReplaceInContent makes, with exact and normalized keys
+func FuzzFindReplacements(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+
+	f.Fuzz(func(t *testing.T, content string, trim bool) {
+		variables := make(map[string]string)
+		for _, p := range extractAll(content) {
+			name := p.name
+			if trim {
+				name = strings.TrimSpace(name)
+			}
+			// Values hold placeholders, which must be written as they are
+			variables[name] = "{{" + name + "}}"
+		}
+		r := NewReplacer(variables, allFormats)
+		r.SetTrimSpaces(trim)
+
+		reps := r.FindReplacements([]byte(content))
+		var spliced strings.Builder
+		prev := 0
+		for _, rep := range reps {
+			if rep.Start < prev || rep.End <= rep.Start || rep.End > len(content) {
+				t.Fatalf("replacement %+v overlaps the previous one or is out of bounds", rep)
+			}
+			if value, ok := r.lookup(rep.Name); !ok || value != rep.Value {
+				t.Fatalf("replacement %+v has value %q, want %q", rep, rep.Value, value)
+			}
+			spliced.WriteString(content[prev:rep.Start])
+			spliced.WriteString(rep.Value)
+			prev = rep.End
+		}
+		spliced.WriteString(content[prev:])
+
+		if got := string(r.ReplaceInContent([]byte(content))); got != spliced.String() {
+			t.Fatalf("ReplaceInContent(%q) = %q, want %q from FindReplacements", content, got, spliced.String())
+		}
+	})
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name        string
+		insensitive bool
+		content     string
+		want        string
+	}{
+		{
+			name:        "mixed case from one lowercase key",
+			insensitive: true,
+			content:     "{{ProjectName}} {{projectname}} {{PROJECTNAME}} <<projectName>> %ProjectName%",
+			want:        "app app app app app",
+		},
+		{
+			name:        "underscore format",
+			insensitive: true,
+			content:     "__ProjectName__ and __projectname__",
+			want:        "app and app",
+		},
+		{
+			name:        "other names untouched",
+			insensitive: true,
+			content:     "{{ProjectNames}} __Project__",
+			want:        "{{ProjectNames}} __Project__",
+		},
+		{
+			name:    "sensitive by default",
+			content: "{{ProjectName}} {{projectname}} __ProjectName__",
+			want:    "{{ProjectName}} app __ProjectName__",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(map[string]string{"projectname": "app"}, allFormats)
+			r.SetCaseInsensitive(tt.insensitive)
+			if got := string(r.ReplaceInContent([]byte(tt.content))); got != tt.want {
+				t.Errorf("ReplaceInContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+
+	r := NewReplacer(map[string]string{"projectname": "app"}, allFormats)
+	r.SetCaseInsensitive(true)
+	if got, want := r.ReplaceInPath("cmd/__ProjectName__/%PROJECTNAME%.go"), "cmd/app/app.go"; got != want {
+		t.Errorf("ReplaceInPath = %q, want %q", got, want)
+	}
+}
+
+// TestCaseInsensitiveFirstKeyWins checks that of several keys folding to
+// the same spelling, the lexically first one supplies every spelling
+func TestCaseInsensitiveFirstKeyWins(t *testing.T) {
+	r := NewReplacer(map[string]string{"name": "lower", "Name": "upper"}, allFormats)
+	r.SetCaseInsensitive(true)
+	if got, want := string(r.ReplaceInContent([]byte("{{NAME}} {{name}} {{Name}}"))), "upper upper upper"; got != want {
+		t.Errorf("ReplaceInContent = %q, want %q", got, want)
+	}
+}
+
+func TestTrimSpaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		trim    bool
+		content string
+		want    string
+	}{
+		{name: "spaced braces", trim: true, content: "{{ name }}", want: "app"},
+		{name: "unspaced braces", trim: true, content: "{{name}}", want: "app"},
+		{name: "tabs and uneven spaces", trim: true, content: "{{\tname  }}", want: "app"},
+		{name: "spaced angle brackets", trim: true, content: "<< name >>", want: "app"},
+		{name: "unknown key kept", trim: true, content: "{{ other }}", want: "{{ other }}"},
+		{name: "off by default", content: "{{ name }} {{name}}", want: "{{ name }} app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(map[string]string{"name": "app"}, allFormats)
+			r.SetTrimSpaces(tt.trim)
+			if got := string(r.ReplaceInContent([]byte(tt.content))); got != tt.want {
+				t.Errorf("ReplaceInContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLowercaseKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		lower   bool
+		vars    map[string]string
+		content string
+		want    string
+	}{
+		{
+			name:    "mixed case keys, value verbatim",
+			lower:   true,
+			vars:    map[string]string{"projectname": "MyApp"},
+			content: "{{ProjectName}} {{PROJECTNAME}} <<projectName>> __ProjectName__",
+			want:    "MyApp MyApp MyApp MyApp",
+		},
+		{
+			// Unlike case-insensitive matching, variable names are not
+			// folded, so a capitalized name is never reached
+			name:    "capitalized variable name",
+			lower:   true,
+			vars:    map[string]string{"ProjectName": "MyApp"},
+			content: "{{ProjectName}} {{projectname}}",
+			want:    "{{ProjectName}} {{projectname}}",
+		},
+		{
+			name:    "off by default",
+			vars:    map[string]string{"projectname": "MyApp"},
+			content: "{{ProjectName}} {{projectname}}",
+			want:    "{{ProjectName}} MyApp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(tt.vars, allFormats)
+			r.SetLowercaseKeys(tt.lower)
+			if got := string(r.ReplaceInContent([]byte(tt.content))); got != tt.want {
+				t.Errorf("ReplaceInContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+
+	r := NewReplacer(map[string]string{"projectname": "MyApp"}, allFormats)
+	r.SetLowercaseKeys(true)
+	if got, want := r.ReplaceInPath("cmd/__ProjectName__/%PROJECTNAME%.go"), "cmd/MyApp/MyApp.go"; got != want {
+		t.Errorf("ReplaceInPath = %q, want %q", got, want)
+	}
+}
+
+func TestReplaceInPathMultiple(t *testing.T) {
+	variables := map[string]string{"prefix": "user", "name": "auth", "a": "x", "b": "y", "project_name": "demo"}
+
+	tests := []struct {
+		name     string
+		path     string
+		want     string
+		wantVars []string
+	}{
+		{name: "braces with literals", path: "{{prefix}}_service_{{name}}.go", want: "user_service_auth.go", wantVars: []string{"name", "prefix"}},
+		{name: "braces adjacent", path: "{{a}}{{b}}.go", want: "xy.go", wantVars: []string{"a", "b"}},
+		{name: "angle brackets with literals", path: "<<prefix>>_service_<<name>>.go", want: "user_service_auth.go", wantVars: []string{"name", "prefix"}},
+		{name: "angle brackets adjacent", path: "<<a>><<b>>.go", want: "xy.go", wantVars: []string{"a", "b"}},
+		{name: "underscores with literals", path: "__prefix___service___name__.go", want: "user_service_auth.go", wantVars: []string{"name", "prefix"}},
+		{name: "underscores adjacent", path: "__a____b__.go", want: "xy.go", wantVars: []string{"a", "b"}},
+		{name: "underscores inner underscore", path: "__project_name__-__a__.go", want: "demo-x.go", wantVars: []string{"a", "project_name"}},
+		{name: "percent with literals", path: "%prefix%_service_%name%.go", want: "user_service_auth.go", wantVars: []string{"name", "prefix"}},
+		{name: "percent adjacent", path: "%a%%b%.go", want: "xy.go", wantVars: []string{"a", "b"}},
+		{name: "custom with literals", path: "[[prefix]]_service_[[name]].go", want: "user_service_auth.go", wantVars: []string{"name", "prefix"}},
+		{name: "custom adjacent", path: "[[a]][[b]].go", want: "xy.go", wantVars: []string{"a", "b"}},
+		{name: "mixed formats adjacent", path: "{{a}}<<b>>__a__%b%[[a]].go", want: "xyxyx.go", wantVars: []string{"a", "b"}},
+		{name: "several segments", path: "{{prefix}}/{{a}}{{b}}/{{name}}_test.go", want: "user/xy/auth_test.go", wantVars: []string{"a", "b", "name", "prefix"}},
+		{name: "unknown kept", path: "{{a}}{{missing}}.go", want: "x{{missing}}.go", wantVars: []string{"a", "missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(variables, allFormats)
+			if got := r.ReplaceInPath(tt.path); got != tt.want {
+				t.Errorf("ReplaceInPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			got := ExtractVariablesFromPath(tt.path, allFormats)
+			slices.Sort(got)
+			if got = slices.Compact(got); !slices.Equal(got, tt.wantVars) {
+				t.Errorf("ExtractVariablesFromPath(%q) = %q, want %q", tt.path, got, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestExtractCanonical(t *testing.T) {
+	content := []byte("{{Foo}} __Foo__ {{foo}} <<FOO>> {{bar}} {{skip}}")
+	lower := func(name string) string {
+		if name == "skip" {
+			return ""
+		}
+		return strings.ToLower(name)
+	}
+
+	tests := []struct {
+		name  string
+		canon Canonicalizer
+		want  []string
+	}{
+		{name: "identity by default", want: []string{"Foo", "foo", "bar", "skip", "FOO"}},
+		{name: "identity function", canon: func(name string) string { return name }, want: []string{"Foo", "foo", "bar", "skip", "FOO"}},
+		{name: "variants merged", canon: lower, want: []string{"foo", "bar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractCanonicalFromFile(content, allFormats, tt.canon); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtractCanonicalFromFile = %q, want %q", got, tt.want)
+			}
+			if got := ExtractCanonicalFromPath(string(content), allFormats, tt.canon); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtractCanonicalFromPath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := ExtractVariablesFromFile(content, allFormats); !slices.Equal(got, tests[0].want) {
+		t.Errorf("ExtractVariablesFromFile = %q, want %q", got, tests[0].want)
+	}
+}
@@ -0,0 +1,43 @@
+package replacer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ternaryPattern matches a conditional path placeholder key such as
+// "use_ts ? app.ts : app.js": a variable name and the two literal segments
+// to choose between, either of which may be empty
+var ternaryPattern = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*\?\s*([^?:]*?)\s*:\s*([^?:]*?)\s*$`)
+
+// parseTernary splits a conditional placeholder key into its variable and
+// its true and false segments
+func parseTernary(key string) (name, ifTrue, ifFalse string, ok bool) {
+	m := ternaryPattern.FindStringSubmatch(key)
+	if m == nil {
+		return "", "", "", false
+	}
+	return m[1], m[2], m[3], true
+}
+
+// replaceTernaries resolves conditional placeholders in a path to their true
+// segment when the variable is truthy, and to their false segment when it
+// is falsy or not set
+func (r *Replacer) replaceTernaries(path string) string {
+	if !strings.Contains(path, "?") {
+		return path
+	}
+	for _, pattern := range enabledPatterns(r.formats) {
+		path = pattern.ReplaceAllStringFunc(path, func(match string) string {
+			name, ifTrue, ifFalse, ok := parseTernary(pattern.FindStringSubmatch(match)[1])
+			if !ok {
+				return match
+			}
+			if value, _ := r.lookup(name); IsTruthy(value) {
+				return ifTrue
+			}
+			return ifFalse
+		})
+	}
+	return path
+}
@@ -0,0 +1,34 @@
+package replacer
+
+import "testing"
+
+func TestReplaceInPathTernary(t *testing.T) {
+	variables := map[string]string{"use_ts": "true", "use_docker": "no", "name": "app"}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"src/{{use_ts ? app.ts : app.js}}", "src/app.ts"},
+		{"src/{{use_docker ? Dockerfile : README.md}}", "src/README.md"},
+		{"{{unset ? a : b}}.txt", "b.txt"},
+		{"{{use_ts?index.ts:index.js}}", "index.ts"},
+		{"lib/{{use_docker ? docker : }}", "lib/"},
+		{"lib/{{use_ts ?  : js}}x", "lib/x"},
+		{"<<use_ts ? a.ts : a.js>>", "a.ts"},
+		{"[[use_docker ? on : off]]", "off"},
+		{"{{name}}-{{use_ts ? ts : js}}", "app-ts"},
+		{"{{name}}/main.go", "app/main.go"},
+		{"{{use_ts ? a : b : c}}", "{{use_ts ? a : b : c}}"},
+		{"what?.txt", "what?.txt"},
+	}
+
+	r := NewReplacer(variables, allFormats)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := r.ReplaceInPath(tt.path); got != tt.want {
+				t.Errorf("ReplaceInPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
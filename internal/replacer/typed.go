@@ -0,0 +1,57 @@
+package replacer
+
+import (
+	"encoding/json"
+	"regexp"
+	"sync"
+)
+
+// quotedPatterns caches the quoted variants of the extraction patterns
+var quotedPatterns sync.Map
+
+// quotedPattern returns a pattern matching a placeholder that is the entire
+// content of a double- or single-quoted string
+func quotedPattern(p *regexp.Regexp) *regexp.Regexp {
+	if cached, ok := quotedPatterns.Load(p.String()); ok {
+		return cached.(*regexp.Regexp)
+	}
+	quoted := regexp.MustCompile(`"(?:` + p.String() + `)"|'(?:` + p.String() + `)'`)
+	quotedPatterns.Store(p.String(), quoted)
+	return quoted
+}
+
+// ReplaceTyped replaces quoted placeholders that make up a whole string
+// value, e.g. "{{port}}", with their value unquoted when the value is a
+// number, boolean or null, so structured files keep the value's type.
+// Other placeholders are left for ReplaceInContent.
+func (r *Replacer) ReplaceTyped(content []byte) []byte {
+	for _, pattern := range enabledPatterns(r.formats) {
+		quoted := quotedPattern(pattern)
+		content = quoted.ReplaceAllFunc(content, func(match []byte) []byte {
+			groups := quoted.FindSubmatch(match)
+			key := groups[1]
+			if key == nil {
+				key = groups[2]
+			}
+			value, ok := r.lookup(string(key))
+			if !ok || !isTypedLiteral(value) {
+				return match
+			}
+			return []byte(value)
+		})
+	}
+	return content
+}
+
+// isTypedLiteral reports whether value is a JSON number, boolean or null
+func isTypedLiteral(value string) bool {
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case float64, bool, nil:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package replacer
+
+import "testing"
+
+func TestReplaceTyped(t *testing.T) {
+	variables := map[string]string{
+		"port":    "8080",
+		"ratio":   "-0.5",
+		"debug":   "true",
+		"parent":  "null",
+		"name":    "app",
+		"version": "1.2.3",
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "number", content: `{"port": "{{port}}"}`, want: `{"port": 8080}`},
+		{name: "negative float", content: `{"ratio": "{{ratio}}"}`, want: `{"ratio": -0.5}`},
+		{name: "boolean", content: `{"debug": "{{debug}}"}`, want: `{"debug": true}`},
+		{name: "null", content: `{"parent": "{{parent}}"}`, want: `{"parent": null}`},
+		{name: "single quotes", content: `port: '{{port}}'`, want: `port: 8080`},
+		{name: "other formats", content: `["<<port>>", "__debug__", "%port%"]`, want: `[8080, true, 8080]`},
+		{name: "string stays quoted", content: `{"name": "{{name}}"}`, want: `{"name": "{{name}}"}`},
+		{name: "not a JSON number", content: `{"version": "{{version}}"}`, want: `{"version": "{{version}}"}`},
+		{name: "part of a string", content: `{"addr": "localhost:{{port}}"}`, want: `{"addr": "localhost:{{port}}"}`},
+		{name: "unquoted", content: `port: {{port}}`, want: `port: {{port}}`},
+		{name: "unknown variable", content: `{"x": "{{missing}}"}`, want: `{"x": "{{missing}}"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReplacer(variables, allFormats)
+			if got := string(r.ReplaceTyped([]byte(tt.content))); got != tt.want {
+				t.Errorf("ReplaceTyped(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package reverser
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitignoreFile is the name of git's ignore file in the source root
+const gitignoreFile = ".gitignore"
+
+// stencilIgnoreFile is the name of the ignore file emitted in the template
+const stencilIgnoreFile = ".stencilignore"
+
+// ignoreRule is a single pattern of a .gitignore file
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// loadGitignore reads the rules of the source's root .gitignore, if present.
+// It supports the common subset of the syntax: comments, '!' negation, a
+// trailing '/' for directories and a leading or inner '/' to anchor a
+// pattern to the root.
+func loadGitignore(sourceDir string) ([]ignoreRule, error) {
+	file, err := os.Open(filepath.Join(sourceDir, gitignoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// ignored reports whether a source path is excluded by the rules; the last
+// matching rule wins
+func ignored(rules []ignoreRule, relPath string, isDir bool) bool {
+	slashPath := filepath.ToSlash(relPath)
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		name := filepath.Base(relPath)
+		if rule.anchored {
+			name = slashPath
+		}
+		if ok, _ := filepath.Match(rule.pattern, name); ok {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// writeStencilIgnore writes the rules to the template's ignore file so that
+// the same paths are never generated from it
+func writeStencilIgnore(templateDir string, rules []ignoreRule) error {
+	var b strings.Builder
+	b.WriteString("# Generated from the source project's .gitignore\n")
+	for _, rule := range rules {
+		if rule.negate {
+			b.WriteString("!")
+		}
+		b.WriteString(rule.pattern)
+		b.WriteString("\n")
+	}
+	return os.WriteFile(filepath.Join(templateDir, stencilIgnoreFile), []byte(b.String()), 0644)
+}
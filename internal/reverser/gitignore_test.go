@@ -0,0 +1,118 @@
+package reverser
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	gitignore := "# build output\n/dist\nbuild/\n*.log\n!keep.log\nlocal/*.env\n\n"
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "dist", isDir: true, want: true},
+		{path: "src/dist", isDir: true, want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "build", want: false},
+		{path: "src/build", isDir: true, want: true},
+		{path: "debug.log", want: true},
+		{path: "src/debug.log", want: true},
+		{path: "keep.log", want: false},
+		{path: "local/dev.env", want: true},
+		{path: "other/dev.env", want: false},
+		{path: "main.go", want: false},
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{gitignoreFile: gitignore})
+	rules, err := loadGitignore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ignored(rules, filepath.FromSlash(tt.path), tt.isDir); got != tt.want {
+				t.Errorf("ignored(%s, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReverseGitignore(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     map[string]string
+		want       map[string]string // template files, without the manifest
+		wantIgnore []string          // patterns in the emitted .stencilignore
+	}{
+		{
+			name: "ignored paths skipped",
+			source: map[string]string{
+				gitignoreFile:      "bin/\n*.log\n!keep.log\n",
+				"main.go":          "package main\n",
+				"bin/app":          "binary",
+				"debug.log":        "log",
+				"keep.log":         "kept",
+				"src/bin/tool.txt": "also ignored",
+			},
+			want: map[string]string{
+				gitignoreFile: "bin/\n*.log\n!keep.log\n",
+				"main.go":     "package main\n",
+				"keep.log":    "kept",
+			},
+			wantIgnore: []string{"bin", "*.log", "!keep.log"},
+		},
+		{
+			name:   "no gitignore",
+			source: map[string]string{"main.go": "package main\n"},
+			want:   map[string]string{"main.go": "package main\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceDir, templateDir := t.TempDir(), t.TempDir()
+			writeTree(t, sourceDir, tt.source)
+
+			if err := NewReverser(sourceDir, templateDir, nil).Reverse(); err != nil {
+				t.Fatalf("Reverse failed: %v", err)
+			}
+
+			got := readTree(t, templateDir)
+			ignoreFile, hasIgnore := got[stencilIgnoreFile]
+			delete(got, stencilIgnoreFile)
+			delete(got, "stencil.manifest.json")
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("template = %q, want %q", got, tt.want)
+			}
+
+			if tt.wantIgnore == nil {
+				if hasIgnore {
+					t.Errorf("unexpected %s: %q", stencilIgnoreFile, ignoreFile)
+				}
+				return
+			}
+			var patterns []string
+			for _, line := range strings.Split(strings.TrimSpace(ignoreFile), "\n") {
+				if !strings.HasPrefix(line, "#") {
+					patterns = append(patterns, line)
+				}
+			}
+			if strings.Join(patterns, "\n") != strings.Join(tt.wantIgnore, "\n") {
+				t.Errorf("%s patterns = %q, want %q", stencilIgnoreFile, patterns, tt.wantIgnore)
+			}
+		})
+	}
+}
+
+func TestLoadGitignoreMissing(t *testing.T) {
+	rules, err := loadGitignore(t.TempDir())
+	if err != nil || rules != nil {
+		t.Errorf("loadGitignore = %v, %v, want no rules", rules, err)
+	}
+}
@@ -0,0 +1,182 @@
+package reverser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/linxux/stencil/internal/manifest"
+	"github.com/linxux/stencil/internal/replacer"
+)
+
+// Reverser turns an existing project into a template by replacing literal
+// values with placeholders
+type Reverser struct {
+	sourceDir   string
+	templateDir string
+	values      map[string]string // literal value -> variable name
+}
+
+// NewReverser creates a new Reverser. values maps literal values found in the
+// source project to the variable names that should replace them.
+func NewReverser(sourceDir, templateDir string, values map[string]string) *Reverser {
+	return &Reverser{
+		sourceDir:   sourceDir,
+		templateDir: templateDir,
+		values:      values,
+	}
+}
+
+// Reverse writes the template directory and its manifest
+func (r *Reverser) Reverse() error {
+	if _, err := os.Stat(r.sourceDir); os.IsNotExist(err) {
+		return fmt.Errorf("source directory does not exist: %s", r.sourceDir)
+	}
+
+	if err := os.MkdirAll(r.templateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create template directory: %w", err)
+	}
+
+	contentReplacer, pathReplacer := r.replacers()
+
+	// Build artifacts and other paths git ignores are not captured
+	rules, err := loadGitignore(r.sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", gitignoreFile, err)
+	}
+
+	err = filepath.Walk(r.sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(r.sourceDir, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		// Never capture version control metadata
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if ignored(rules, relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		targetPath := filepath.Join(r.templateDir, pathReplacer.Replace(relPath))
+
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode())
+		}
+
+		return reverseFile(path, targetPath, info, contentReplacer)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(rules) > 0 {
+		if err := writeStencilIgnore(r.templateDir, rules); err != nil {
+			return fmt.Errorf("failed to write %s: %w", stencilIgnoreFile, err)
+		}
+	}
+
+	return manifest.Save(r.templateDir, r.buildManifest())
+}
+
+// reverseFile writes a single file with literals replaced by placeholders
+func reverseFile(sourcePath, targetPath string, info os.FileInfo, literals *strings.Replacer) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return err
+	}
+
+	if replacer.IsBinaryFile(sourcePath) {
+		return copyFile(sourcePath, targetPath, info.Mode())
+	}
+
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	return os.WriteFile(targetPath, []byte(literals.Replace(string(content))), info.Mode())
+}
+
+// replacers returns replacers turning literals into {{var}} placeholders for
+// content and __var__ placeholders for paths, which are safe to use in file
+// and directory names. Each replaces in a single pass, so a placeholder is
+// never rewritten by a later literal, and prefers the longest literal at any
+// position.
+func (r *Reverser) replacers() (content, path *strings.Replacer) {
+	var contentPairs, pathPairs []string
+	for _, literal := range r.sortedLiterals() {
+		name := r.values[literal]
+		contentPairs = append(contentPairs, literal, "{{"+name+"}}")
+		pathPairs = append(pathPairs, literal, "__"+name+"__")
+	}
+	return strings.NewReplacer(contentPairs...), strings.NewReplacer(pathPairs...)
+}
+
+// sortedLiterals returns the literal values longest first so that a value
+// containing another is replaced before the shorter one
+func (r *Reverser) sortedLiterals() []string {
+	literals := make([]string, 0, len(r.values))
+	for literal := range r.values {
+		if literal != "" {
+			literals = append(literals, literal)
+		}
+	}
+	sort.Slice(literals, func(i, j int) bool {
+		if len(literals[i]) != len(literals[j]) {
+			return len(literals[i]) > len(literals[j])
+		}
+		return literals[i] < literals[j]
+	})
+	return literals
+}
+
+// buildManifest declares each variable with its original value as default
+func (r *Reverser) buildManifest() *manifest.Manifest {
+	m := &manifest.Manifest{
+		Name: filepath.Base(r.templateDir),
+	}
+	for _, literal := range r.sortedLiterals() {
+		m.Variables = append(m.Variables, manifest.Variable{
+			Name:    r.values[literal],
+			Default: literal,
+		})
+	}
+	sort.Slice(m.Variables, func(i, j int) bool {
+		return m.Variables[i].Name < m.Variables[j].Name
+	})
+	return m
+}
+
+// copyFile copies a file from source to destination
+func copyFile(source, destination string, mode os.FileMode) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,185 @@
+package reverser
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linxux/stencil/config"
+	"github.com/linxux/stencil/internal/generator"
+	"github.com/linxux/stencil/internal/manifest"
+)
+
+// writeTree creates files under dir from a map of slash-separated relative
+// paths to contents
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the files under dir by slash-separated relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		name   string
+		source map[string]string
+		values map[string]string // literal -> variable
+		want   map[string]string // template files, without the manifest
+	}{
+		{
+			name:   "content and paths",
+			source: map[string]string{"README.md": "# MyApp\n", "cmd/MyApp/main.go": "package main // MyApp\n"},
+			values: map[string]string{"MyApp": "project_name"},
+			want: map[string]string{
+				"README.md":                    "# {{project_name}}\n",
+				"cmd/__project_name__/main.go": "package main // {{project_name}}\n",
+			},
+		},
+		{
+			name:   "longer literal first",
+			source: map[string]string{"a.txt": "acme-widgets by acme\n"},
+			values: map[string]string{"acme": "org", "acme-widgets": "repo"},
+			want:   map[string]string{"a.txt": "{{repo}} by {{org}}\n"},
+		},
+		{
+			name:   "literal inside a variable name",
+			source: map[string]string{"widget/widget.txt": "widget by vendor\n"},
+			values: map[string]string{"widget": "app_name", "name": "vendor"},
+			want:   map[string]string{"__app_name__/__app_name__.txt": "{{app_name}} by vendor\n"},
+		},
+		{
+			name:   "empty literal ignored",
+			source: map[string]string{"a.txt": "x\n"},
+			values: map[string]string{"": "nothing"},
+			want:   map[string]string{"a.txt": "x\n"},
+		},
+		{
+			name:   "binary copied unchanged",
+			source: map[string]string{"logo.bin": "\x00\x01MyApp"},
+			values: map[string]string{"MyApp": "project_name"},
+			want:   map[string]string{"logo.bin": "\x00\x01MyApp"},
+		},
+		{
+			name:   "git metadata skipped",
+			source: map[string]string{".git/HEAD": "ref: refs/heads/main\n", "a.txt": "a\n"},
+			want:   map[string]string{"a.txt": "a\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceDir, templateDir := t.TempDir(), filepath.Join(t.TempDir(), "tpl")
+			writeTree(t, sourceDir, tt.source)
+
+			if err := NewReverser(sourceDir, templateDir, tt.values).Reverse(); err != nil {
+				t.Fatalf("Reverse failed: %v", err)
+			}
+
+			got := readTree(t, templateDir)
+			delete(got, manifest.FileName)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("template = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReverseRoundTrip checks that generating from a reversed template with
+// its default values gives back the original project
+func TestReverseRoundTrip(t *testing.T) {
+	source := map[string]string{
+		"README.md":                "# MyApp by acme\n\nSee acme-widgets.\n",
+		"cmd/MyApp/main.go":        "package main\n\n// MyApp is built by acme\n",
+		"internal/acme/acme.go":    "package acme\n",
+		"docs/acme-widgets.md":     "acme-widgets for MyApp\n",
+		"assets/logo.bin":          "\x00\x01MyApp",
+		"config/settings.json":     `{"name": "MyApp", "org": "acme"}` + "\n",
+		"scripts/release-MyApp.sh": "echo MyApp\n",
+	}
+	values := map[string]string{"MyApp": "project_name", "acme": "org", "acme-widgets": "repo"}
+
+	dir := t.TempDir()
+	sourceDir, templateDir, outputDir := filepath.Join(dir, "source"), filepath.Join(dir, "template"), filepath.Join(dir, "output")
+	writeTree(t, sourceDir, source)
+
+	if err := NewReverser(sourceDir, templateDir, values).Reverse(); err != nil {
+		t.Fatalf("Reverse failed: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.TemplateDir = templateDir
+	cfg.OutputDir = outputDir
+	gen := generator.NewGenerator(cfg)
+	gen.SetLogger(generator.NewConsoleLogger(io.Discard, io.Discard))
+	if err := gen.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if got := readTree(t, outputDir); !maps.Equal(got, source) {
+		t.Errorf("generated %q, want %q", got, source)
+	}
+}
+
+func TestReverseManifest(t *testing.T) {
+	sourceDir, templateDir := t.TempDir(), filepath.Join(t.TempDir(), "service")
+	writeTree(t, sourceDir, map[string]string{"a.txt": "acme MyApp\n"})
+
+	values := map[string]string{"MyApp": "project_name", "acme": "org"}
+	if err := NewReverser(sourceDir, templateDir, values).Reverse(); err != nil {
+		t.Fatalf("Reverse failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(templateDir, manifest.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "service" {
+		t.Errorf("manifest name = %q, want %q", m.Name, "service")
+	}
+	want := []manifest.Variable{{Name: "org", Default: "acme"}, {Name: "project_name", Default: "MyApp"}}
+	if len(m.Variables) != len(want) || m.Variables[0] != want[0] || m.Variables[1] != want[1] {
+		t.Errorf("manifest variables = %+v, want %+v", m.Variables, want)
+	}
+}
+
+func TestReverseMissingSource(t *testing.T) {
+	err := NewReverser(filepath.Join(t.TempDir(), "missing"), t.TempDir(), nil).Reverse()
+	if err == nil {
+		t.Fatal("Reverse succeeded without a source directory")
+	}
+}
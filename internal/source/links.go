@@ -0,0 +1,134 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// within reports whether path is root or lies beneath it. Both must be
+// clean absolute paths.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realDir returns dir with symlinks resolved, so paths under it can be
+// compared with resolved paths
+func realDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// resolvePath resolves symlinks in path as far as it exists, and returns the
+// result joined with the part that does not exist yet
+func resolvePath(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	parent := filepath.Dir(path)
+	if !errors.Is(err, fs.ErrNotExist) || parent == path {
+		return "", err
+	}
+	resolvedParent, err := resolvePath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), nil
+}
+
+// checkWritable guards writing target, a path under dir, against symlinks
+// extracted earlier: its directory must resolve inside dir, so creating it
+// and the file cannot land elsewhere. A symlink already at target is
+// removed, so the new entry replaces it rather than being written through
+// it.
+func checkWritable(dir, target string) error {
+	root, err := realDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	resolved, err := resolvePath(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	if !within(root, resolved) {
+		return fmt.Errorf("unsafe path in artifact: %s is reached through a symlink leaving the template", filepath.Base(target))
+	}
+	return nil
+}
+
+// extractLink creates the symlink target pointing at linkname. A link that
+// is absolute or leads outside dir is an error, or is skipped when
+// skipUnsafe is set.
+func extractLink(dir, target, linkname string, skipUnsafe bool) error {
+	if err := checkWritable(dir, target); err != nil {
+		return err
+	}
+	root, err := realDir(dir)
+	if err != nil {
+		return err
+	}
+	parent, err := resolvePath(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	linkname = filepath.FromSlash(strings.ReplaceAll(linkname, `\`, "/"))
+	if filepath.IsAbs(linkname) || !within(root, filepath.Join(parent, linkname)) {
+		return unsafeLink(target, linkname, skipUnsafe)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(linkname, target)
+}
+
+// checkLinks resolves every symlink extracted into dir once all layers are
+// in place, catching links that only escape through other links, e.g. a
+// target of "sub/up/../x" where sub/up points to "..". Escaping or dangling
+// links are an error, or are removed when skipUnsafe is set.
+func checkLinks(dir string, skipUnsafe bool) error {
+	root, err := realDir(dir)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil && within(root, resolved) {
+			return nil
+		}
+		linkname, _ := os.Readlink(path)
+		if err := unsafeLink(path, linkname, skipUnsafe); err != nil {
+			return err
+		}
+		return os.Remove(path)
+	})
+}
+
+// unsafeLink reports a symlink leaving the template, or returns nil to skip
+// it when skipUnsafe is set
+func unsafeLink(path, linkname string, skipUnsafe bool) error {
+	if skipUnsafe {
+		return nil
+	}
+	return fmt.Errorf("unsafe symlink in artifact: %s points outside the template (%s); set skipUnsafeArchiveLinks to drop such links",
+		filepath.Base(path), linkname)
+}
@@ -0,0 +1,330 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Media types of the manifests stencil understands
+const (
+	ociManifestType    = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexType       = "application/vnd.oci.image.index.v1+json"
+	dockerManifestType = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerListType     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// titleAnnotation names the file a layer holds, as set by artifact tools
+// such as ORAS
+const titleAnnotation = "org.opencontainers.image.title"
+
+// maxManifestBytes limits the size of a manifest response
+const maxManifestBytes = 4 << 20
+
+// Limits on what an artifact may unpack to, so a hostile registry cannot
+// fill the disk. Variables so tests can lower them.
+var (
+	// maxBlobBytes limits the size of a single layer
+	maxBlobBytes int64 = 512 << 20
+	// maxExtractBytes limits the total size of the files in one archive
+	maxExtractBytes int64 = 1 << 30
+	// maxExtractEntries limits the number of entries in one archive
+	maxExtractEntries = 100000
+)
+
+// ociRef is a parsed "registry/repository:tag" or "registry/repository@digest"
+// reference
+type ociRef struct {
+	registry   string
+	repository string
+	// reference is the tag or digest
+	reference string
+}
+
+// descriptor points at a manifest or blob
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// manifest is an image manifest or, with Manifests set, an index
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// parseOCIRef parses a reference without its scheme. The tag defaults to
+// "latest".
+func parseOCIRef(s string) (ociRef, error) {
+	registry, rest, ok := strings.Cut(s, "/")
+	if !ok || registry == "" || rest == "" {
+		return ociRef{}, fmt.Errorf("invalid OCI reference %q: expected registry/repository:tag", s)
+	}
+
+	ref := ociRef{registry: registry, repository: rest, reference: "latest"}
+	if repo, digest, ok := strings.Cut(rest, "@"); ok {
+		ref.repository, ref.reference = repo, digest
+	} else if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		ref.repository, ref.reference = rest[:i], rest[i+1:]
+	}
+	if ref.repository == "" || ref.reference == "" {
+		return ociRef{}, fmt.Errorf("invalid OCI reference %q", s)
+	}
+	return ref, nil
+}
+
+// baseURL returns the registry's API root. Registries on the local host are
+// reached over plain HTTP; Docker Hub is served from its registry host.
+func (r ociRef) baseURL() string {
+	host := r.registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if name := strings.Split(host, ":")[0]; name == "localhost" || name == "127.0.0.1" {
+		scheme = "http"
+	}
+	return scheme + "://" + host + "/v2/" + r.repository
+}
+
+// pullOCI downloads the layers of an artifact into dir, retrying each
+// request up to opts.Retries times. Tar and zip layers are extracted; other
+// layers are written as the file named by their title annotation.
+func pullOCI(s, dir string, opts FetchOptions) error {
+	ref, err := parseOCIRef(s)
+	if err != nil {
+		return err
+	}
+	c := &registryClient{http: http.DefaultClient, registry: ref.registry, retries: opts.Retries}
+
+	m, err := c.manifest(ref, ref.reference)
+	if err != nil {
+		return err
+	}
+	// An index lists one manifest per platform; templates are platform
+	// independent, so the first will do
+	if len(m.Manifests) > 0 {
+		if m, err = c.manifest(ref, m.Manifests[0].Digest); err != nil {
+			return err
+		}
+	}
+	if len(m.Layers) == 0 {
+		return fmt.Errorf("artifact has no layers")
+	}
+
+	for _, layer := range m.Layers {
+		data, err := c.blob(ref, layer)
+		if err != nil {
+			return err
+		}
+		if err := writeLayer(layer, data, dir, opts.SkipUnsafeLinks); err != nil {
+			return fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+	}
+	return checkLinks(dir, opts.SkipUnsafeLinks)
+}
+
+// manifest fetches the manifest for a tag or digest
+func (c *registryClient) manifest(ref ociRef, reference string) (*manifest, error) {
+	var m *manifest
+	err := c.retry(func() error {
+		var err error
+		m, err = c.fetchManifest(ref, reference)
+		return err
+	})
+	return m, err
+}
+
+// fetchManifest makes one attempt at fetching a manifest
+func (c *registryClient) fetchManifest(ref ociRef, reference string) (*manifest, error) {
+	req, err := http.NewRequest(http.MethodGet, ref.baseURL()+"/manifests/"+url.PathEscape(reference), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join([]string{ociManifestType, ociIndexType, dockerManifestType, dockerListType}, ", "))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var m manifest
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// blob downloads a layer and verifies its digest
+func (c *registryClient) blob(ref ociRef, layer descriptor) ([]byte, error) {
+	var data []byte
+	err := c.retry(func() error {
+		var err error
+		data, err = c.fetchBlob(ref, layer)
+		return err
+	})
+	return data, err
+}
+
+// fetchBlob makes one attempt at downloading a layer
+func (c *registryClient) fetchBlob(ref ociRef, layer descriptor) ([]byte, error) {
+	algorithm, want, ok := strings.Cut(layer.Digest, ":")
+	if !ok || algorithm != "sha256" {
+		return nil, fmt.Errorf("unsupported digest %q", layer.Digest)
+	}
+	if layer.Size < 0 || layer.Size > maxBlobBytes {
+		return nil, fmt.Errorf("blob %s has size %d, over the limit of %d bytes", layer.Digest, layer.Size, maxBlobBytes)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ref.baseURL()+"/blobs/"+layer.Digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read one byte more than declared to notice an oversized body
+	data, err := io.ReadAll(io.LimitReader(resp.Body, layer.Size+1))
+	if err != nil {
+		return nil, transient(fmt.Errorf("failed to download blob %s: %w", layer.Digest, err))
+	}
+	if int64(len(data)) != layer.Size {
+		return nil, fmt.Errorf("blob %s is not %d bytes as its descriptor declares", layer.Digest, layer.Size)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != want {
+		return nil, fmt.Errorf("blob %s does not match its digest", layer.Digest)
+	}
+	return data, nil
+}
+
+// writeLayer stores a downloaded layer under dir
+func writeLayer(layer descriptor, data []byte, dir string, skipUnsafeLinks bool) error {
+	// A directory pushed by ORAS is a tarball whose entries already start
+	// with the directory's name, its title
+	title := layer.Annotations[titleAnnotation]
+	if strings.Contains(layer.MediaType, "tar") {
+		return extractTar(data, strings.Contains(layer.MediaType, "gzip"), dir, skipUnsafeLinks)
+	}
+	if isZip(layer.MediaType) {
+		return extractZip(data, dir, skipUnsafeLinks)
+	}
+
+	if title == "" {
+		return fmt.Errorf("layer of type %s has no %s annotation", layer.MediaType, titleAnnotation)
+	}
+	target, err := safeJoin(dir, title)
+	if err != nil {
+		return err
+	}
+	if err := checkWritable(dir, target); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+// extractTar extracts regular files, directories and symlinks from a tar
+// archive into dir. Entries that would land outside dir, or be written
+// through a symlink leading outside it, are rejected; so are symlinks
+// pointing outside dir, unless skipUnsafeLinks drops them instead. Hard
+// links and special files are skipped. Archives with more than
+// maxExtractEntries entries or maxExtractBytes of files are rejected.
+func extractTar(data []byte, gzipped bool, dir string, skipUnsafeLinks bool) error {
+	var r io.Reader = bytes.NewReader(data)
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	var entries int
+	var total int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if entries++; entries > maxExtractEntries {
+			return fmt.Errorf("archive has more than %d entries", maxExtractEntries)
+		}
+
+		target, err := safeJoin(dir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := checkWritable(dir, target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := extractLink(dir, target, header.Linkname, skipUnsafeLinks); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if total += header.Size; total > maxExtractBytes {
+				return fmt.Errorf("archive unpacks to more than %d bytes", maxExtractBytes)
+			}
+			if err := checkWritable(dir, target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0600)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// safeJoin joins a slash-separated archive path to dir, rejecting absolute
+// paths and paths with ".." components, which could escape dir
+func safeJoin(dir, name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || slices.Contains(strings.Split(name, "/"), "..") {
+		return "", fmt.Errorf("unsafe path in artifact: %s", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(path.Clean("/"+name))), nil
+}
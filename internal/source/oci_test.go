@@ -0,0 +1,412 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarEntry is one entry of a test archive
+type tarEntry struct {
+	name     string
+	content  string
+	linkname string
+	typeflag byte
+}
+
+// makeTar builds a tar archive, gzipped when asked
+func makeTar(t *testing.T, entries []tarEntry, gzipped bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if gzipped {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: e.typeflag, Linkname: e.linkname}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(e.content))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// readFiles returns the regular files and symlinks under dir by slash
+// path; symlinks map to "-> target"
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			files[filepath.ToSlash(rel)] = "-> " + target
+			return err
+		}
+		content, err := os.ReadFile(path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestExtractTar(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    []tarEntry
+		gzipped    bool
+		skipUnsafe bool
+		maxBytes   int64
+		maxEntries int
+		want       map[string]string
+		wantErr    string
+	}{
+		{
+			name: "files and directories",
+			entries: []tarEntry{
+				{name: "tpl/", typeflag: tar.TypeDir},
+				{name: "tpl/README.md", content: "# {{name}}\n"},
+				{name: "tpl/src/main.go", content: "package main\n"},
+			},
+			want: map[string]string{"tpl/README.md": "# {{name}}\n", "tpl/src/main.go": "package main\n"},
+		},
+		{
+			name:    "gzipped",
+			entries: []tarEntry{{name: "a.txt", content: "a"}},
+			gzipped: true,
+			want:    map[string]string{"a.txt": "a"},
+		},
+		{
+			name:    "parent path",
+			entries: []tarEntry{{name: "../escape.txt", content: "x"}},
+			wantErr: "unsafe path",
+		},
+		{
+			name:    "absolute path",
+			entries: []tarEntry{{name: "/etc/escape", content: "x"}},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "symlink inside",
+			entries: []tarEntry{
+				{name: "a.txt", content: "a"},
+				{name: "b.txt", linkname: "a.txt", typeflag: tar.TypeSymlink},
+			},
+			want: map[string]string{"a.txt": "a", "b.txt": "-> a.txt"},
+		},
+		{
+			name:    "symlink outside",
+			entries: []tarEntry{{name: "passwd", linkname: "/etc/passwd", typeflag: tar.TypeSymlink}},
+			wantErr: "passwd",
+		},
+		{
+			name:    "relative symlink outside",
+			entries: []tarEntry{{name: "sub/up", linkname: "../../..", typeflag: tar.TypeSymlink}},
+			wantErr: "unsafe symlink",
+		},
+		{
+			name: "symlink outside skipped",
+			entries: []tarEntry{
+				{name: "a.txt", content: "a"},
+				{name: "passwd", linkname: "../../etc/passwd", typeflag: tar.TypeSymlink},
+			},
+			skipUnsafe: true,
+			want:       map[string]string{"a.txt": "a"},
+		},
+		{
+			name: "write through symlink",
+			entries: []tarEntry{
+				{name: "out", linkname: "..", typeflag: tar.TypeSymlink},
+				{name: "out/escape.txt", content: "x"},
+			},
+			skipUnsafe: true,
+			want:       map[string]string{"out/escape.txt": "x"},
+		},
+		{
+			name: "hard link skipped",
+			entries: []tarEntry{
+				{name: "a.txt", content: "a"},
+				{name: "b.txt", linkname: "a.txt", typeflag: tar.TypeLink},
+			},
+			want: map[string]string{"a.txt": "a"},
+		},
+		{
+			name: "too large",
+			entries: []tarEntry{
+				{name: "a.txt", content: "12345"},
+				{name: "b.txt", content: "67890"},
+			},
+			maxBytes: 8,
+			wantErr:  "more than 8 bytes",
+		},
+		{
+			name: "too many entries",
+			entries: []tarEntry{
+				{name: "a/", typeflag: tar.TypeDir},
+				{name: "b/", typeflag: tar.TypeDir},
+				{name: "c/", typeflag: tar.TypeDir},
+			},
+			maxEntries: 2,
+			wantErr:    "more than 2 entries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxBytes > 0 {
+				defer func(old int64) { maxExtractBytes = old }(maxExtractBytes)
+				maxExtractBytes = tt.maxBytes
+			}
+			if tt.maxEntries > 0 {
+				defer func(old int) { maxExtractEntries = old }(maxExtractEntries)
+				maxExtractEntries = tt.maxEntries
+			}
+
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "root")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractTar(makeTar(t, tt.entries, tt.gzipped), tt.gzipped, dir, tt.skipUnsafe)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("extractTar error = %v, want one containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("extractTar failed: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(parent, "escape.txt")); err == nil {
+				t.Fatal("extractTar wrote outside its directory")
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			got := readFiles(t, dir)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// blobServer serves the given responses to successive blob requests and
+// counts the requests
+func blobServer(t *testing.T, responses ...func(w http.ResponseWriter)) (*registryClient, ociRef, *int) {
+	t.Helper()
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := min(requests, len(responses)-1)
+		requests++
+		responses[i](w)
+	}))
+	t.Cleanup(server.Close)
+
+	ref, err := parseOCIRef(strings.TrimPrefix(server.URL, "http://") + "/templates/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &registryClient{http: server.Client(), registry: ref.registry}, ref, &requests
+}
+
+// body answers with data
+func body(data string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) { w.Write([]byte(data)) }
+}
+
+// status answers with an error status
+func status(code int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) { w.WriteHeader(code) }
+}
+
+// layerOf returns a descriptor for data with the given declared size
+func layerOf(data string, size int64) descriptor {
+	return descriptor{MediaType: "application/octet-stream", Digest: "sha256:" + sha256Hex(data), Size: size}
+}
+
+func TestFetchBlob(t *testing.T) {
+	const data = "template content"
+
+	tests := []struct {
+		name    string
+		served  string
+		layer   descriptor
+		maxSize int64
+		wantErr string
+	}{
+		{name: "matching", served: data, layer: layerOf(data, int64(len(data)))},
+		{
+			name:    "longer than declared",
+			served:  data + "extra",
+			layer:   layerOf(data, int64(len(data))),
+			wantErr: "is not 16 bytes",
+		},
+		{
+			name:    "shorter than declared",
+			served:  data[:4],
+			layer:   layerOf(data, int64(len(data))),
+			wantErr: "is not 16 bytes",
+		},
+		{
+			name:    "over the limit",
+			served:  data,
+			layer:   layerOf(data, int64(len(data))),
+			maxSize: 8,
+			wantErr: "over the limit",
+		},
+		{
+			name:    "negative size",
+			served:  data,
+			layer:   layerOf(data, -1),
+			wantErr: "over the limit",
+		},
+		{
+			name:    "wrong digest",
+			served:  "other content, same len",
+			layer:   layerOf(data, int64(len("other content, same len"))),
+			wantErr: "does not match its digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxSize > 0 {
+				defer func(old int64) { maxBlobBytes = old }(maxBlobBytes)
+				maxBlobBytes = tt.maxSize
+			}
+			c, ref, _ := blobServer(t, body(tt.served))
+
+			got, err := c.fetchBlob(ref, tt.layer)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchBlob error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchBlob failed: %v", err)
+			}
+			if string(got) != data {
+				t.Errorf("fetchBlob = %q, want %q", got, data)
+			}
+		})
+	}
+}
+
+func TestBlobRetry(t *testing.T) {
+	const data = "template content"
+
+	tests := []struct {
+		name         string
+		retries      int
+		responses    []func(w http.ResponseWriter)
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "success",
+			retries:      1,
+			responses:    []func(w http.ResponseWriter){body(data)},
+			wantRequests: 1,
+		},
+		{
+			name:         "temporary failure retried",
+			retries:      1,
+			responses:    []func(w http.ResponseWriter){status(http.StatusServiceUnavailable), body(data)},
+			wantRequests: 2,
+		},
+		{
+			name:         "rate limit retried",
+			retries:      1,
+			responses:    []func(w http.ResponseWriter){status(http.StatusTooManyRequests), body(data)},
+			wantRequests: 2,
+		},
+		{
+			name:         "retries exhausted",
+			retries:      1,
+			responses:    []func(w http.ResponseWriter){status(http.StatusBadGateway)},
+			wantRequests: 2,
+			wantErr:      "502",
+		},
+		{
+			name:         "no retries",
+			responses:    []func(w http.ResponseWriter){status(http.StatusServiceUnavailable), body(data)},
+			wantRequests: 1,
+			wantErr:      "503",
+		},
+		{
+			name:         "not found fails at once",
+			retries:      3,
+			responses:    []func(w http.ResponseWriter){status(http.StatusNotFound)},
+			wantRequests: 1,
+			wantErr:      "404",
+		},
+		{
+			name:         "digest mismatch fails at once",
+			retries:      3,
+			responses:    []func(w http.ResponseWriter){body("corrupted conten")},
+			wantRequests: 1,
+			wantErr:      "does not match its digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ref, requests := blobServer(t, tt.responses...)
+			c.retries = tt.retries
+
+			got, err := c.blob(ref, layerOf(data, int64(len(data))))
+			if *requests != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", *requests, tt.wantRequests)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("blob error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("blob failed: %v", err)
+			}
+			if string(got) != data {
+				t.Errorf("blob = %q, want %q", got, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package source
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// registryClient sends requests to an OCI registry, answering
+// authentication challenges with credentials from the docker config
+type registryClient struct {
+	http     *http.Client
+	registry string
+
+	// authorization is the header value of the last successful challenge
+	authorization string
+
+	// retries is how many times a request failing with a network error or
+	// a temporary status is retried
+	retries int
+}
+
+// do sends a request, authenticating and retrying once when the registry
+// challenges it. Any status other than 200 is an error; network errors and
+// temporary statuses are marked transient.
+func (c *registryClient) do(req *http.Request) (*http.Response, error) {
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, transient(err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.authorization == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if c.authorization, err = c.authorize(challenge); err != nil {
+			return nil, err
+		}
+		retry := req.Clone(req.Context())
+		retry.Header.Set("Authorization", c.authorization)
+		if resp, err = c.http.Do(retry); err != nil {
+			return nil, transient(err)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err := fmt.Errorf("registry returned %s for %s", resp.Status, req.URL.Path)
+		if retryableStatus(resp.StatusCode) {
+			return nil, transient(err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// authorize answers a WWW-Authenticate challenge and returns the
+// Authorization header to send: the docker config credentials for a Basic
+// challenge, or a token from the challenge's realm for a Bearer one
+func (c *registryClient) authorize(challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	user, secret, err := dockerCredentials(c.registry)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if user == "" && secret == "" {
+			return "", fmt.Errorf("registry %s requires credentials; run docker login", c.registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+secret)), nil
+	case "bearer":
+		token, err := c.fetchToken(params, user, secret)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	return "", fmt.Errorf("unsupported authentication challenge from %s: %q", c.registry, challenge)
+}
+
+// fetchToken requests a bearer token from the challenge's realm, sending
+// credentials when there are any; anonymous tokens cover public artifacts
+func (c *registryClient) fetchToken(params map[string]string, user, secret string) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("authentication challenge from %s has no realm", c.registry)
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if user != "" || secret != "" {
+		req.SetBasicAuth(user, secret)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch registry token: %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid registry token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("registry token response has no token")
+}
+
+// parseChallenge splits a WWW-Authenticate header such as
+// `Bearer realm="https://auth",service="registry"` into its scheme and
+// parameters
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(rest, "=")
+		rest = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		rest = strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(rest, " "), ","), " ")
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return scheme, params
+}
+
+// dockerConfig is the part of ~/.docker/config.json holding credentials
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// dockerCredentials returns the credentials docker login stored for a
+// registry, from $DOCKER_CONFIG/config.json or ~/.docker/config.json. A
+// credential helper configured for the registry, or for all registries, is
+// asked first. No config or no entry means anonymous access.
+func dockerCredentials(registry string) (string, string, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read docker config: %w", err)
+	}
+
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", "", fmt.Errorf("invalid docker config: %w", err)
+	}
+
+	// Docker Hub credentials are stored under its legacy index URL
+	keys := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == "docker.io" {
+		keys = append(keys, "https://index.docker.io/v1/")
+	}
+
+	helper := cfg.CredsStore
+	if h, ok := cfg.CredHelpers[registry]; ok {
+		helper = h
+	}
+	if helper != "" {
+		return helperCredentials(helper, keys[0])
+	}
+
+	for _, key := range keys {
+		entry, ok := cfg.Auths[key]
+		if !ok || entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid docker config auth for %s: %w", key, err)
+		}
+		user, secret, _ := strings.Cut(string(decoded), ":")
+		return user, secret, nil
+	}
+	return "", "", nil
+}
+
+// helperCredentials asks a docker credential helper, e.g.
+// docker-credential-desktop, for a registry's credentials. A helper with no
+// entry for the registry means anonymous access.
+func helperCredentials(helper, registry string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(string(out)+stderr.String(), "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("credential helper %s failed: %w", helper, err)
+	}
+
+	var creds struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return "", "", fmt.Errorf("invalid output from credential helper %s: %w", helper, err)
+	}
+	return creds.Username, creds.Secret, nil
+}
@@ -0,0 +1,47 @@
+package source
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// retryDelay is the wait before the first retry; it doubles with each
+// further attempt
+const retryDelay = 500 * time.Millisecond
+
+// transientError marks a failure that may succeed when retried, such as a
+// network error or a 5xx response
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+
+func (e *transientError) Unwrap() error { return e.err }
+
+// transient marks err as worth retrying
+func transient(err error) error {
+	return &transientError{err: err}
+}
+
+// retryableStatus reports whether a registry response status is a
+// temporary condition. Other failures, such as 404 or 401, are permanent.
+func retryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// retry calls fn until it succeeds, fails permanently or has been retried
+// c.retries times, waiting with exponential backoff between attempts
+func (c *registryClient) retry(fn func() error) error {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		var t *transientError
+		if err == nil || !errors.As(err, &t) || attempt >= c.retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,63 @@
+// Package source fetches templates that are not local directories into a
+// temporary directory, so they can be generated like any other template.
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OCIScheme prefixes template references to OCI artifacts, e.g.
+// "oci://registry.example.com/templates/go-service:v1"
+const OCIScheme = "oci://"
+
+// IsRemote reports whether a template reference must be fetched rather than
+// read from a local directory
+func IsRemote(ref string) bool {
+	return strings.HasPrefix(ref, OCIScheme)
+}
+
+// FetchOptions controls how Fetch downloads and unpacks a template
+type FetchOptions struct {
+	// Retries is how many times a request failing with a network error or a
+	// temporary status is retried, with exponential backoff; errors such as
+	// 404 fail at once
+	Retries int
+
+	// SkipUnsafeLinks drops symlinks in the template that point outside
+	// it, instead of failing the fetch
+	SkipUnsafeLinks bool
+}
+
+// Fetch downloads the template ref refers to into a new directory under
+// tempDir (the system default when empty) and returns the template
+// directory and a function removing everything fetched
+func Fetch(ref, tempDir string, opts FetchOptions) (string, func(), error) {
+	if !strings.HasPrefix(ref, OCIScheme) {
+		return "", nil, fmt.Errorf("unsupported template source: %s", ref)
+	}
+
+	root, err := os.MkdirTemp(tempDir, "stencil-source-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create template directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(root) }
+
+	if err := pullOCI(strings.TrimPrefix(ref, OCIScheme), root, opts); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	return templateRoot(root), cleanup, nil
+}
+
+// templateRoot returns the directory to use as the template: the single
+// directory an artifact unpacked to, or dir itself
+func templateRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
@@ -0,0 +1,82 @@
+package source
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// registry serves an artifact with a single layer holding blob, which it
+// reports as having digest
+func registry(t *testing.T, blob []byte, digest string) string {
+	t.Helper()
+	layer := descriptor{MediaType: "application/vnd.oci.image.layer.v1.tar", Digest: digest, Size: int64(len(blob))}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/manifests/v1"):
+			json.NewEncoder(w).Encode(manifest{MediaType: ociManifestType, Layers: []descriptor{layer}})
+		case strings.HasSuffix(r.URL.Path, "/blobs/"+digest):
+			w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return OCIScheme + strings.TrimPrefix(server.URL, "http://") + "/templates/app:v1"
+}
+
+func TestFetchTempDir(t *testing.T) {
+	blob := makeTar(t, []tarEntry{
+		{name: "app/", typeflag: tar.TypeDir},
+		{name: "app/README.md", content: "# {{name}}\n"},
+	}, false)
+
+	tests := []struct {
+		name    string
+		digest  string
+		wantErr string
+	}{
+		{name: "fetched", digest: "sha256:" + sha256Hex(string(blob))},
+		{name: "failed fetch is cleaned up", digest: "sha256:" + sha256Hex("other"), wantErr: "does not match its digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := registry(t, blob, tt.digest)
+			tempDir := t.TempDir()
+
+			dir, cleanup, err := Fetch(ref, tempDir, FetchOptions{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch error = %v, want %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Fetch failed: %v", err)
+				}
+				rel, err := filepath.Rel(tempDir, dir)
+				if err != nil || strings.HasPrefix(rel, "..") {
+					t.Errorf("template directory %s is not under %s", dir, tempDir)
+				}
+				if got, want := readFiles(t, dir), map[string]string{"README.md": "# {{name}}\n"}; !maps.Equal(got, want) {
+					t.Errorf("template = %q, want %q", got, want)
+				}
+				cleanup()
+			}
+
+			entries, err := os.ReadDir(tempDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("temp directory holds %d entries after cleanup, want none", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package source
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	templatemanifest "github.com/linxux/stencil/internal/manifest"
+)
+
+// signature is the content of a template's signature file
+type signature struct {
+	// Digest is the template's TreeDigest at signing time
+	Digest string `json:"digest"`
+
+	// Signature is the base64 Ed25519 signature of Digest
+	Signature string `json:"signature"`
+}
+
+// Entry types and modes recorded in a TreeDigest
+const (
+	digestFile           = "file"
+	digestSymlink        = "symlink"
+	digestModeFile       = "100644"
+	digestModeExecutable = "100755"
+	digestModeSymlink    = "120000"
+)
+
+// TreeDigest returns a digest of a template's files, "sha256:<hex>". Each
+// regular file and symlink contributes a record of its entry type, git-style
+// mode, slash-separated path and content hash or link target, every field
+// ended by a NUL byte, which no path or link target can contain; records
+// are hashed in path order. The signature file and git metadata are left
+// out, and empty directories do not count.
+func TreeDigest(dir string) (string, error) {
+	type record struct {
+		kind, mode, path, value string
+	}
+	var records []record
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath == templatemanifest.SignatureFileName {
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			records = append(records, record{digestSymlink, digestModeSymlink, relPath, target})
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := digestModeFile
+		if info.Mode()&0111 != 0 {
+			mode = digestModeExecutable
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, file); err != nil {
+			return err
+		}
+		records = append(records, record{digestFile, mode, relPath, hex.EncodeToString(h.Sum(nil))})
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash template: %w", err)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].path < records[j].path })
+	h := sha256.New()
+	for _, r := range records {
+		for _, field := range []string{r.kind, r.mode, r.path, r.value} {
+			io.WriteString(h, field)
+			h.Write([]byte{0})
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Sign writes the signature file of the template in dir, signing its
+// current digest with key
+func Sign(dir string, key ed25519.PrivateKey) error {
+	digest, err := TreeDigest(dir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(signature{
+		Digest:    digest,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(digest))),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, templatemanifest.SignatureFileName), append(data, '\n'), 0644)
+}
+
+// Verify checks the template in dir against its signature file: its files
+// must still match the signed digest, and the digest must be signed by one
+// of trustedKeys. Verification fails closed, so a missing or unreadable
+// signature is an error.
+func Verify(dir string, trustedKeys []string) error {
+	data, err := os.ReadFile(filepath.Join(dir, templatemanifest.SignatureFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("template is not signed: %s is missing", templatemanifest.SignatureFileName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", templatemanifest.SignatureFileName, err)
+	}
+
+	var sig signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return fmt.Errorf("invalid %s: %w", templatemanifest.SignatureFileName, err)
+	}
+	signed, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature in %s: %w", templatemanifest.SignatureFileName, err)
+	}
+
+	digest, err := TreeDigest(dir)
+	if err != nil {
+		return err
+	}
+	if digest != sig.Digest {
+		return fmt.Errorf("template files do not match the signed digest")
+	}
+
+	for _, trusted := range trustedKeys {
+		key, err := ParsePublicKey(trusted)
+		if err != nil {
+			return err
+		}
+		if ed25519.Verify(key, []byte(sig.Digest), signed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("template is not signed by a trusted key")
+}
+
+// ParsePublicKey parses a trusted key: a base64 raw Ed25519 public key, or
+// the path of a PEM file holding one
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil && len(raw) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(raw), nil
+	}
+
+	data, err := os.ReadFile(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted key %q: not a base64 Ed25519 key or a readable PEM file", s)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid trusted key %s: no PEM block", s)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted key %s: %w", s, err)
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid trusted key %s: not an Ed25519 key", s)
+	}
+	return key, nil
+}
+
+// ParsePrivateKey parses a PEM-encoded PKCS #8 Ed25519 private key, as
+// written by "openssl genpkey -algorithm ed25519"
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 key")
+	}
+	return key, nil
+}
@@ -0,0 +1,193 @@
+package source
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under dir from a map of slash-separated
+// relative paths to contents
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublic, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted := base64.StdEncoding.EncodeToString(public)
+	untrusted := base64.StdEncoding.EncodeToString(otherPublic)
+
+	tests := []struct {
+		name    string
+		keys    []string
+		change  func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{name: "unchanged", keys: []string{trusted}},
+		{name: "one of several keys", keys: []string{untrusted, trusted}},
+		{
+			name:    "untrusted key",
+			keys:    []string{untrusted},
+			wantErr: "not signed by a trusted key",
+		},
+		{
+			name: "file changed",
+			keys: []string{trusted},
+			change: func(t *testing.T, dir string) {
+				writeFiles(t, dir, map[string]string{"main.go": "package other\n"})
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name: "file added",
+			keys: []string{trusted},
+			change: func(t *testing.T, dir string) {
+				writeFiles(t, dir, map[string]string{"extra.txt": ""})
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name: "file removed",
+			keys: []string{trusted},
+			change: func(t *testing.T, dir string) {
+				os.Remove(filepath.Join(dir, "README.md"))
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name: "made executable",
+			keys: []string{trusted},
+			change: func(t *testing.T, dir string) {
+				os.Chmod(filepath.Join(dir, "README.md"), 0755)
+			},
+			wantErr: "do not match the signed digest",
+		},
+		{
+			name: "git metadata ignored",
+			keys: []string{trusted},
+			change: func(t *testing.T, dir string) {
+				writeFiles(t, dir, map[string]string{".git/HEAD": "ref: refs/heads/main\n"})
+			},
+		},
+		{
+			name: "signature removed",
+			keys: []string{trusted},
+			change: func(t *testing.T, dir string) {
+				os.Remove(filepath.Join(dir, "stencil.signature"))
+			},
+			wantErr: "template is not signed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{
+				"README.md": "# {{name}}\n",
+				"main.go":   "package main\n",
+			})
+			if err := Sign(dir, private); err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			if tt.change != nil {
+				tt.change(t, dir)
+			}
+
+			err := Verify(dir, tt.keys)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestTreeDigestUnambiguous checks that trees differing only in how their
+// entries could be concatenated have different digests
+func TestTreeDigestUnambiguous(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b func(t *testing.T, dir string)
+	}{
+		{
+			// A link target holding a newline and a file record used to
+			// encode like the link and the file
+			name: "symlink target holding a record",
+			a: func(t *testing.T, dir string) {
+				symlink(t, "t\nb "+sha256Hex("x"), filepath.Join(dir, "a"))
+			},
+			b: func(t *testing.T, dir string) {
+				symlink(t, "t", filepath.Join(dir, "a"))
+				writeFiles(t, dir, map[string]string{"b": "x"})
+			},
+		},
+		{
+			name: "symlink and file with the same content",
+			a: func(t *testing.T, dir string) {
+				symlink(t, "target", filepath.Join(dir, "a"))
+			},
+			b: func(t *testing.T, dir string) {
+				writeFiles(t, dir, map[string]string{"a": "target"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := t.TempDir(), t.TempDir()
+			tt.a(t, a)
+			tt.b(t, b)
+			digestA, err := TreeDigest(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			digestB, err := TreeDigest(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if digestA == digestB {
+				t.Fatalf("both trees have digest %s", digestA)
+			}
+		})
+	}
+}
+
+// sha256Hex returns the hex SHA-256 of s
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// symlink creates a symlink, skipping the test where that is not allowed
+func symlink(t *testing.T, target, path string) {
+	t.Helper()
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+}
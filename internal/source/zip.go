@@ -0,0 +1,125 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// zipMediaType is the media type of zip archive layers
+const zipMediaType = "application/zip"
+
+// isZip reports whether a layer of the given media type is a zip archive
+func isZip(mediaType string) bool {
+	return mediaType == zipMediaType || strings.HasSuffix(mediaType, "+zip")
+}
+
+// extractZip extracts regular files, directories and symlinks from a zip
+// archive into dir, with the same checks as extractTar: entries landing
+// outside dir or written through a symlink leaving it are rejected, and so
+// are symlinks pointing outside dir unless skipUnsafeLinks drops them.
+// Special files are skipped.
+func extractZip(data []byte, dir string, skipUnsafeLinks bool) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if len(zr.File) > maxExtractEntries {
+		return fmt.Errorf("archive has more than %d entries", maxExtractEntries)
+	}
+
+	var total int64
+	for _, f := range zr.File {
+		target, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := checkWritable(dir, target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case mode&fs.ModeSymlink != 0:
+			linkname, err := readZipFile(f, 4096)
+			if err != nil {
+				return err
+			}
+			if err := extractLink(dir, target, string(linkname), skipUnsafeLinks); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			// The declared size may lie, so the copy is limited as well
+			remaining := maxExtractBytes - total
+			if f.UncompressedSize64 > uint64(remaining) {
+				return fmt.Errorf("archive unpacks to more than %d bytes", maxExtractBytes)
+			}
+			if err := checkWritable(dir, target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			written, err := writeZipFile(f, target, remaining)
+			if err != nil {
+				return err
+			}
+			total += written
+		}
+	}
+	return nil
+}
+
+// readZipFile returns the content of a small archive entry, such as a
+// symlink's target, failing if it is longer than limit
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("archive entry %s is longer than %d bytes", f.Name, limit)
+	}
+	return data, nil
+}
+
+// writeZipFile writes an archive entry to target and returns its size,
+// failing once more than limit bytes were read
+func writeZipFile(f *zip.File, target string, limit int64) (int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm()|0600)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(file, io.LimitReader(rc, limit+1))
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	if written > limit {
+		return 0, fmt.Errorf("archive unpacks to more than %d bytes", maxExtractBytes)
+	}
+	return written, nil
+}
@@ -0,0 +1,229 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipEntry is one entry of a test zip archive; a linkname makes it a symlink
+type zipEntry struct {
+	name     string
+	content  string
+	linkname string
+}
+
+// makeZip builds a zip archive
+func makeZip(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		content := e.content
+		switch {
+		case e.linkname != "":
+			header.SetMode(fs.ModeSymlink | 0777)
+			content = e.linkname
+		case strings.HasSuffix(e.name, "/"):
+			header.SetMode(fs.ModeDir | 0755)
+		default:
+			header.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractZip(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    []zipEntry
+		skipUnsafe bool
+		maxBytes   int64
+		maxEntries int
+		want       map[string]string
+		wantErr    string
+	}{
+		{
+			name: "files and directories",
+			entries: []zipEntry{
+				{name: "tpl/"},
+				{name: "tpl/README.md", content: "# {{name}}\n"},
+				{name: "tpl/src/main.go", content: "package main\n"},
+			},
+			want: map[string]string{"tpl/README.md": "# {{name}}\n", "tpl/src/main.go": "package main\n"},
+		},
+		{
+			name:    "parent path",
+			entries: []zipEntry{{name: "../escape.txt", content: "x"}},
+			wantErr: "unsafe path",
+		},
+		{
+			name:    "absolute path",
+			entries: []zipEntry{{name: "/etc/escape", content: "x"}},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "symlink inside",
+			entries: []zipEntry{
+				{name: "a.txt", content: "a"},
+				{name: "b.txt", linkname: "a.txt"},
+			},
+			want: map[string]string{"a.txt": "a", "b.txt": "-> a.txt"},
+		},
+		{
+			name:    "absolute symlink",
+			entries: []zipEntry{{name: "passwd", linkname: "/etc/passwd"}},
+			wantErr: "unsafe symlink",
+		},
+		{
+			name:    "relative symlink outside",
+			entries: []zipEntry{{name: "sub/up", linkname: "../../.."}},
+			wantErr: "unsafe symlink",
+		},
+		{
+			name: "symlink outside skipped",
+			entries: []zipEntry{
+				{name: "a.txt", content: "a"},
+				{name: "passwd", linkname: "../../etc/passwd"},
+			},
+			skipUnsafe: true,
+			want:       map[string]string{"a.txt": "a"},
+		},
+		{
+			name: "write through symlink",
+			entries: []zipEntry{
+				{name: "out", linkname: ".."},
+				{name: "out/escape.txt", content: "x"},
+			},
+			skipUnsafe: true,
+			want:       map[string]string{"out/escape.txt": "x"},
+		},
+		{
+			name: "too large",
+			entries: []zipEntry{
+				{name: "a.txt", content: "12345"},
+				{name: "b.txt", content: "67890"},
+			},
+			maxBytes: 8,
+			wantErr:  "more than 8 bytes",
+		},
+		{
+			name:       "too many entries",
+			entries:    []zipEntry{{name: "a/"}, {name: "b/"}, {name: "c/"}},
+			maxEntries: 2,
+			wantErr:    "more than 2 entries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.maxBytes > 0 {
+				defer func(old int64) { maxExtractBytes = old }(maxExtractBytes)
+				maxExtractBytes = tt.maxBytes
+			}
+			if tt.maxEntries > 0 {
+				defer func(old int) { maxExtractEntries = old }(maxExtractEntries)
+				maxExtractEntries = tt.maxEntries
+			}
+
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "root")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractZip(makeZip(t, tt.entries), dir, tt.skipUnsafe)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("extractZip error = %v, want one containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("extractZip failed: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(parent, "escape.txt")); err == nil {
+				t.Fatal("extractZip wrote outside its directory")
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			got := readFiles(t, dir)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteZipLayer checks that zip layers are extracted and their links
+// checked once all layers are in place
+func TestWriteZipLayer(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		entries   []zipEntry
+		want      map[string]string
+		wantErr   string
+	}{
+		{
+			name:      "zip",
+			mediaType: "application/zip",
+			entries:   []zipEntry{{name: "tpl/a.txt", content: "a"}},
+			want:      map[string]string{"tpl/a.txt": "a"},
+		},
+		{
+			name:      "structured suffix",
+			mediaType: "application/vnd.example.template+zip",
+			entries:   []zipEntry{{name: "tpl/a.txt", content: "a"}},
+			want:      map[string]string{"tpl/a.txt": "a"},
+		},
+		{
+			name:      "escape through a chain of links",
+			mediaType: "application/zip",
+			entries: []zipEntry{
+				{name: "sub/up", linkname: ".."},
+				{name: "link", linkname: "sub/up/.."},
+			},
+			wantErr: "unsafe symlink",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			layer := descriptor{MediaType: tt.mediaType}
+			err := writeLayer(layer, makeZip(t, tt.entries), dir, false)
+			if err == nil {
+				err = checkLinks(dir, false)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("writeLayer failed: %v", err)
+			}
+			if got := readFiles(t, dir); !maps.Equal(got, tt.want) {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+		})
+	}
+}